@@ -31,6 +31,9 @@ type ModelServerSpecApplyConfiguration struct {
 	WorkloadPort     *WorkloadPortApplyConfiguration     `json:"workloadPort,omitempty"`
 	TrafficPolicy    *TrafficPolicyApplyConfiguration    `json:"trafficPolicy,omitempty"`
 	KVConnector      *KVConnectorSpecApplyConfiguration  `json:"kvConnector,omitempty"`
+	MetricsEndpoint  *PodEndpointApplyConfiguration      `json:"metricsEndpoint,omitempty"`
+	ModelsEndpoint   *PodEndpointApplyConfiguration      `json:"modelsEndpoint,omitempty"`
+	HealthCheck      *HealthCheckApplyConfiguration      `json:"healthCheck,omitempty"`
 }
 
 // ModelServerSpecApplyConfiguration constructs a declarative configuration of the ModelServerSpec type for use with
@@ -86,3 +89,27 @@ func (b *ModelServerSpecApplyConfiguration) WithKVConnector(value *KVConnectorSp
 	b.KVConnector = value
 	return b
 }
+
+// WithMetricsEndpoint sets the MetricsEndpoint field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the MetricsEndpoint field is set to the value of the last call.
+func (b *ModelServerSpecApplyConfiguration) WithMetricsEndpoint(value *PodEndpointApplyConfiguration) *ModelServerSpecApplyConfiguration {
+	b.MetricsEndpoint = value
+	return b
+}
+
+// WithModelsEndpoint sets the ModelsEndpoint field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ModelsEndpoint field is set to the value of the last call.
+func (b *ModelServerSpecApplyConfiguration) WithModelsEndpoint(value *PodEndpointApplyConfiguration) *ModelServerSpecApplyConfiguration {
+	b.ModelsEndpoint = value
+	return b
+}
+
+// WithHealthCheck sets the HealthCheck field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the HealthCheck field is set to the value of the last call.
+func (b *ModelServerSpecApplyConfiguration) WithHealthCheck(value *HealthCheckApplyConfiguration) *ModelServerSpecApplyConfiguration {
+	b.HealthCheck = value
+	return b
+}
@@ -25,10 +25,16 @@ import (
 // RateLimitApplyConfiguration represents a declarative configuration of the RateLimit type for use
 // with apply.
 type RateLimitApplyConfiguration struct {
-	InputTokensPerUnit  *uint32                            `json:"inputTokensPerUnit,omitempty"`
-	OutputTokensPerUnit *uint32                            `json:"outputTokensPerUnit,omitempty"`
-	Unit                *networkingv1alpha1.RateLimitUnit  `json:"unit,omitempty"`
-	Global              *GlobalRateLimitApplyConfiguration `json:"global,omitempty"`
+	InputTokensPerUnit    *uint32                                   `json:"inputTokensPerUnit,omitempty"`
+	OutputTokensPerUnit   *uint32                                   `json:"outputTokensPerUnit,omitempty"`
+	RequestsPerUnit       *uint32                                   `json:"requestsPerUnit,omitempty"`
+	OutputTokensAdmission *networkingv1alpha1.OutputTokensAdmission `json:"outputTokensAdmission,omitempty"`
+	DefaultMaxTokens      *uint32                                   `json:"defaultMaxTokens,omitempty"`
+	Unit                  *networkingv1alpha1.RateLimitUnit         `json:"unit,omitempty"`
+	Global                *GlobalRateLimitApplyConfiguration        `json:"global,omitempty"`
+	Key                   *RateLimitKeyApplyConfiguration           `json:"key,omitempty"`
+	Overrides             []RateLimitOverrideApplyConfiguration     `json:"overrides,omitempty"`
+	Quota                 *TokenQuotaApplyConfiguration             `json:"quota,omitempty"`
 }
 
 // RateLimitApplyConfiguration constructs a declarative configuration of the RateLimit type for use with
@@ -53,6 +59,30 @@ func (b *RateLimitApplyConfiguration) WithOutputTokensPerUnit(value uint32) *Rat
 	return b
 }
 
+// WithRequestsPerUnit sets the RequestsPerUnit field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the RequestsPerUnit field is set to the value of the last call.
+func (b *RateLimitApplyConfiguration) WithRequestsPerUnit(value uint32) *RateLimitApplyConfiguration {
+	b.RequestsPerUnit = &value
+	return b
+}
+
+// WithOutputTokensAdmission sets the OutputTokensAdmission field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the OutputTokensAdmission field is set to the value of the last call.
+func (b *RateLimitApplyConfiguration) WithOutputTokensAdmission(value networkingv1alpha1.OutputTokensAdmission) *RateLimitApplyConfiguration {
+	b.OutputTokensAdmission = &value
+	return b
+}
+
+// WithDefaultMaxTokens sets the DefaultMaxTokens field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the DefaultMaxTokens field is set to the value of the last call.
+func (b *RateLimitApplyConfiguration) WithDefaultMaxTokens(value uint32) *RateLimitApplyConfiguration {
+	b.DefaultMaxTokens = &value
+	return b
+}
+
 // WithUnit sets the Unit field in the declarative configuration to the given value
 // and returns the receiver, so that objects can be built by chaining "With" function invocations.
 // If called multiple times, the Unit field is set to the value of the last call.
@@ -68,3 +98,32 @@ func (b *RateLimitApplyConfiguration) WithGlobal(value *GlobalRateLimitApplyConf
 	b.Global = value
 	return b
 }
+
+// WithKey sets the Key field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Key field is set to the value of the last call.
+func (b *RateLimitApplyConfiguration) WithKey(value *RateLimitKeyApplyConfiguration) *RateLimitApplyConfiguration {
+	b.Key = value
+	return b
+}
+
+// WithOverrides adds the given value to the Overrides field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Overrides field.
+func (b *RateLimitApplyConfiguration) WithOverrides(values ...*RateLimitOverrideApplyConfiguration) *RateLimitApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithOverrides")
+		}
+		b.Overrides = append(b.Overrides, *values[i])
+	}
+	return b
+}
+
+// WithQuota sets the Quota field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Quota field is set to the value of the last call.
+func (b *RateLimitApplyConfiguration) WithQuota(value *TokenQuotaApplyConfiguration) *RateLimitApplyConfiguration {
+	b.Quota = value
+	return b
+}
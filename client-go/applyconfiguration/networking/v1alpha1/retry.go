@@ -25,8 +25,9 @@ import (
 // RetryApplyConfiguration represents a declarative configuration of the Retry type for use
 // with apply.
 type RetryApplyConfiguration struct {
-	Attempts      *int32       `json:"attempts,omitempty"`
-	RetryInterval *v1.Duration `json:"retryInterval,omitempty"`
+	Attempts          *int32       `json:"attempts,omitempty"`
+	RetryInterval     *v1.Duration `json:"retryInterval,omitempty"`
+	FirstEventTimeout *v1.Duration `json:"firstEventTimeout,omitempty"`
 }
 
 // RetryApplyConfiguration constructs a declarative configuration of the Retry type for use with
@@ -50,3 +51,11 @@ func (b *RetryApplyConfiguration) WithRetryInterval(value v1.Duration) *RetryApp
 	b.RetryInterval = &value
 	return b
 }
+
+// WithFirstEventTimeout sets the FirstEventTimeout field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the FirstEventTimeout field is set to the value of the last call.
+func (b *RetryApplyConfiguration) WithFirstEventTimeout(value v1.Duration) *RetryApplyConfiguration {
+	b.FirstEventTimeout = &value
+	return b
+}
@@ -25,9 +25,11 @@ import (
 // RuleApplyConfiguration represents a declarative configuration of the Rule type for use
 // with apply.
 type RuleApplyConfiguration struct {
-	Name         *string                           `json:"name,omitempty"`
-	ModelMatch   *ModelMatchApplyConfiguration     `json:"modelMatch,omitempty"`
-	TargetModels []*networkingv1alpha1.TargetModel `json:"targetModels,omitempty"`
+	Name         *string                              `json:"name,omitempty"`
+	ModelMatch   *ModelMatchApplyConfiguration        `json:"modelMatch,omitempty"`
+	TargetModels []*networkingv1alpha1.TargetModel    `json:"targetModels,omitempty"`
+	Mirror       *MirrorTargetApplyConfiguration      `json:"mirror,omitempty"`
+	Fallbacks    []*networkingv1alpha1.FallbackTarget `json:"fallbacks,omitempty"`
 }
 
 // RuleApplyConfiguration constructs a declarative configuration of the Rule type for use with
@@ -64,3 +66,24 @@ func (b *RuleApplyConfiguration) WithTargetModels(values ...**networkingv1alpha1
 	}
 	return b
 }
+
+// WithMirror sets the Mirror field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Mirror field is set to the value of the last call.
+func (b *RuleApplyConfiguration) WithMirror(value *MirrorTargetApplyConfiguration) *RuleApplyConfiguration {
+	b.Mirror = value
+	return b
+}
+
+// WithFallbacks adds the given value to the Fallbacks field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Fallbacks field.
+func (b *RuleApplyConfiguration) WithFallbacks(values ...**networkingv1alpha1.FallbackTarget) *RuleApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithFallbacks")
+		}
+		b.Fallbacks = append(b.Fallbacks, *values[i])
+	}
+	return b
+}
@@ -25,8 +25,12 @@ import (
 // TrafficPolicyApplyConfiguration represents a declarative configuration of the TrafficPolicy type for use
 // with apply.
 type TrafficPolicyApplyConfiguration struct {
-	Timeout *v1.Duration             `json:"timeout,omitempty"`
-	Retry   *RetryApplyConfiguration `json:"retry,omitempty"`
+	Timeout          *v1.Duration                        `json:"timeout,omitempty"`
+	Retry            *RetryApplyConfiguration            `json:"retry,omitempty"`
+	ConcurrencyLimit *ConcurrencyLimitApplyConfiguration `json:"concurrencyLimit,omitempty"`
+	OutlierDetection *OutlierDetectionApplyConfiguration `json:"outlierDetection,omitempty"`
+	Hedging          *HedgingApplyConfiguration          `json:"hedging,omitempty"`
+	SessionAffinity  *SessionAffinityApplyConfiguration  `json:"sessionAffinity,omitempty"`
 }
 
 // TrafficPolicyApplyConfiguration constructs a declarative configuration of the TrafficPolicy type for use with
@@ -50,3 +54,35 @@ func (b *TrafficPolicyApplyConfiguration) WithRetry(value *RetryApplyConfigurati
 	b.Retry = value
 	return b
 }
+
+// WithConcurrencyLimit sets the ConcurrencyLimit field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ConcurrencyLimit field is set to the value of the last call.
+func (b *TrafficPolicyApplyConfiguration) WithConcurrencyLimit(value *ConcurrencyLimitApplyConfiguration) *TrafficPolicyApplyConfiguration {
+	b.ConcurrencyLimit = value
+	return b
+}
+
+// WithOutlierDetection sets the OutlierDetection field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the OutlierDetection field is set to the value of the last call.
+func (b *TrafficPolicyApplyConfiguration) WithOutlierDetection(value *OutlierDetectionApplyConfiguration) *TrafficPolicyApplyConfiguration {
+	b.OutlierDetection = value
+	return b
+}
+
+// WithHedging sets the Hedging field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Hedging field is set to the value of the last call.
+func (b *TrafficPolicyApplyConfiguration) WithHedging(value *HedgingApplyConfiguration) *TrafficPolicyApplyConfiguration {
+	b.Hedging = value
+	return b
+}
+
+// WithSessionAffinity sets the SessionAffinity field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SessionAffinity field is set to the value of the last call.
+func (b *TrafficPolicyApplyConfiguration) WithSessionAffinity(value *SessionAffinityApplyConfiguration) *TrafficPolicyApplyConfiguration {
+	b.SessionAffinity = value
+	return b
+}
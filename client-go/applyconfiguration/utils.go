@@ -36,10 +36,20 @@ func ForKind(kind schema.GroupVersionKind) interface{} {
 	// Group=networking.serving.volcano.sh, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithKind("BodyMatch"):
 		return &networkingv1alpha1.BodyMatchApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("ConcurrencyLimit"):
+		return &networkingv1alpha1.ConcurrencyLimitApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("FallbackTarget"):
+		return &networkingv1alpha1.FallbackTargetApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("GlobalRateLimit"):
 		return &networkingv1alpha1.GlobalRateLimitApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("HealthCheck"):
+		return &networkingv1alpha1.HealthCheckApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("Hedging"):
+		return &networkingv1alpha1.HedgingApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("KVConnectorSpec"):
 		return &networkingv1alpha1.KVConnectorSpecApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("MirrorTarget"):
+		return &networkingv1alpha1.MirrorTargetApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("ModelMatch"):
 		return &networkingv1alpha1.ModelMatchApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("ModelRoute"):
@@ -50,20 +60,32 @@ func ForKind(kind schema.GroupVersionKind) interface{} {
 		return &networkingv1alpha1.ModelServerApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("ModelServerSpec"):
 		return &networkingv1alpha1.ModelServerSpecApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("OutlierDetection"):
+		return &networkingv1alpha1.OutlierDetectionApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("PDGroup"):
 		return &networkingv1alpha1.PDGroupApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("PodEndpoint"):
+		return &networkingv1alpha1.PodEndpointApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("RateLimit"):
 		return &networkingv1alpha1.RateLimitApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("RateLimitKey"):
+		return &networkingv1alpha1.RateLimitKeyApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("RateLimitOverride"):
+		return &networkingv1alpha1.RateLimitOverrideApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("RedisConfig"):
 		return &networkingv1alpha1.RedisConfigApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("Retry"):
 		return &networkingv1alpha1.RetryApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("Rule"):
 		return &networkingv1alpha1.RuleApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("SessionAffinity"):
+		return &networkingv1alpha1.SessionAffinityApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("StringMatch"):
 		return &networkingv1alpha1.StringMatchApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("TargetModel"):
 		return &networkingv1alpha1.TargetModelApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("TokenQuota"):
+		return &networkingv1alpha1.TokenQuotaApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("TrafficPolicy"):
 		return &networkingv1alpha1.TrafficPolicyApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("WorkloadPort"):
@@ -36,6 +36,7 @@ import (
 	kthenaInformers "github.com/volcano-sh/kthena/client-go/informers/externalversions"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/controller"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/auth"
 )
 
 type Controller interface {
@@ -48,7 +49,7 @@ type aggregatedController struct {
 
 var _ Controller = &aggregatedController{}
 
-func startControllers(store datastore.Store, stop <-chan struct{}, enableGatewayAPI bool, defaultPort string) Controller {
+func startControllers(store datastore.Store, apiKeys *auth.APIKeyAuthenticator, stop <-chan struct{}, enableGatewayAPI bool, defaultPort string) Controller {
 	cfg, err := clientcmd.BuildConfigFromFlags("", "")
 	if err != nil {
 		klog.Fatalf("Error building kubeconfig: %s", err.Error())
@@ -90,6 +91,28 @@ func startControllers(store datastore.Store, stop <-chan struct{}, enableGateway
 		modelServerController,
 	}
 
+	// API key controller is only started when API key authentication is enabled
+	if apiKeys.IsEnabled() {
+		// Only the labeled Secrets are watched, rather than all the Secrets of the cluster
+		apiKeyInformerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, 0,
+			informers.WithNamespace(apiKeys.Namespace()),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = auth.APIKeyLabel + "=true"
+			}),
+		)
+		apiKeyController := controller.NewAPIKeyController(apiKeyInformerFactory, apiKeys)
+
+		apiKeyInformerFactory.Start(stop)
+
+		go func() {
+			if err := apiKeyController.Run(stop); err != nil {
+				klog.Fatalf("Error running API key controller: %s", err.Error())
+			}
+		}()
+
+		controllers = append(controllers, apiKeyController)
+	}
+
 	// Gateway API controllers are optional
 	if enableGatewayAPI {
 		gatewayClient, err := gatewayclientset.NewForConfig(cfg)
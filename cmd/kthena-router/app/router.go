@@ -27,11 +27,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"istio.io/istio/pkg/env"
 	"k8s.io/klog/v2"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/debug"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/quota"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/router"
 )
 
@@ -40,6 +42,9 @@ const (
 	routerConfigFile        = "/etc/config/routerConfiguration.yaml"
 )
 
+// quotaAdminToken is the bearer token of the quota admin endpoints, which are disabled if it is not set
+var quotaAdminToken = env.RegisterStringVar("QUOTA_ADMIN_TOKEN", "", "Bearer token of the quota admin endpoints").Get()
+
 func NewRouter(store datastore.Store) *router.Router {
 	return router.NewRouter(store, routerConfigFile)
 }
@@ -125,12 +130,26 @@ func (s *Server) startDefaultServer(ctx context.Context, router *router.Router,
 		debugGroup.GET("/namespaces/:namespace/pods/:name", debugHandler.GetPod)
 	}
 
+	// Quota admin endpoints
+	quotaHandler := quota.NewAdminHandler(router.Quotas(), quotaAdminToken)
+	quotaGroup := engine.Group("/admin/quota")
+	{
+		quotaGroup.GET("/models/*model", quotaHandler.GetUsage)
+		quotaGroup.DELETE("/models/*model", quotaHandler.ResetUsage)
+	}
+
 	// Handle /v1/*path with middleware
 	v1Group := engine.Group("/v1")
 	v1Group.Use(AccessLogMiddleware(router))
 	v1Group.Use(AuthMiddleware(router))
 	v1Group.Any("/*path", router.HandlerFunc())
 
+	// Handle Open Inference Protocol (KServe v2) infer requests, e.g. for Triton
+	v2Group := engine.Group("/v2")
+	v2Group.Use(AccessLogMiddleware(router))
+	v2Group.Use(AuthMiddleware(router))
+	v2Group.POST("/models/*path", router.HandlerFunc())
+
 	server := &http.Server{
 		Addr:    ":" + s.Port,
 		Handler: engine.Handler(),
@@ -315,6 +334,21 @@ func (lm *ListenerManager) createPortHandler(port int32) gin.HandlerFunc {
 					}
 				}
 			}
+			if model, ok := strings.CutPrefix(path, "/admin/quota/models/"); ok {
+				quotaHandler := quota.NewAdminHandler(lm.router.Quotas(), quotaAdminToken)
+				c.Params = []gin.Param{{Key: "model", Value: model}}
+				switch c.Request.Method {
+				case http.MethodGet:
+					quotaHandler.GetUsage(c)
+				case http.MethodDelete:
+					quotaHandler.ResetUsage(c)
+				default:
+					c.JSON(http.StatusMethodNotAllowed, gin.H{
+						"message": "Method not allowed",
+					})
+				}
+				return
+			}
 		}
 
 		hostname := c.Request.Host
@@ -345,8 +379,8 @@ func (lm *ListenerManager) createPortHandler(port int32) gin.HandlerFunc {
 			return
 		}
 
-		// Handle /v1/*path
-		if strings.HasPrefix(c.Request.URL.Path, "/v1/") {
+		// Handle /v1/*path and /v2/models/*path
+		if strings.HasPrefix(c.Request.URL.Path, "/v1/") || strings.HasPrefix(c.Request.URL.Path, router.OpenInferenceModelsPrefix) {
 			lm.router.HandlerFunc()(c)
 			return
 		}
@@ -617,8 +651,8 @@ func AccessLogMiddleware(gwRouter *router.Router) gin.HandlerFunc {
 
 func AuthMiddleware(gwRouter *router.Router) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Auth for "/v1/" only
-		if !strings.HasPrefix(c.Request.URL.Path, "/v1/") {
+		// Auth for "/v1/" and the Open Inference Protocol model endpoints only
+		if !strings.HasPrefix(c.Request.URL.Path, "/v1/") && !strings.HasPrefix(c.Request.URL.Path, router.OpenInferenceModelsPrefix) {
 			c.Next()
 			return
 		}
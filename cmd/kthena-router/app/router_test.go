@@ -0,0 +1,63 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/router"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "routerConfiguration")
+	require.NoError(t, os.WriteFile(configPath, []byte("auth:\n  apiKey:\n    enabled: true\n"), 0o600))
+	r := router.NewRouter(datastore.New(), configPath)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{name: "openai", path: "/v1/chat/completions", expected: http.StatusUnauthorized},
+		{name: "open inference", path: "/v2/models/m/infer", expected: http.StatusUnauthorized},
+		{name: "other", path: "/healthz", expected: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(`{}`))
+
+			AuthMiddleware(r)(c)
+			if !c.IsAborted() {
+				c.Status(http.StatusOK)
+			}
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
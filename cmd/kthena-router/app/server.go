@@ -54,8 +54,14 @@ func (s *Server) Run(ctx context.Context) {
 
 	// must be run before the controller, because it will register callbacks
 	r := NewRouter(store)
+	// export usage records and flush the quota ledger until the router shuts down
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		r.Run(ctx)
+	}()
 	// start controller
-	s.controllers = startControllers(store, ctx.Done(), s.EnableGatewayAPI, s.Port)
+	s.controllers = startControllers(store, r.APIKeys(), ctx.Done(), s.EnableGatewayAPI, s.Port)
 
 	// Start store's periodic update loop after controllers have synced
 	if !cache.WaitForCacheSync(ctx.Done(), s.controllers.HasSynced) {
@@ -70,6 +76,7 @@ func (s *Server) Run(ctx context.Context) {
 	klog.Info("Router server started, waiting for shutdown signal...")
 	<-ctx.Done()
 	klog.Info("Router server shutting down...")
+	<-runDone
 }
 
 func (s *Server) HasSynced() bool {
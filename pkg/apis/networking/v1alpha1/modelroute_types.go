@@ -65,6 +65,62 @@ type Rule struct {
 	ModelMatch *ModelMatch `json:"modelMatch,omitempty"`
 	// +kubebuilder:validation:MaxItems=16
 	TargetModels []*TargetModel `json:"targetModels"`
+	// Mirror sends a copy of the requests matching the rule to another modelServer, e.g. to compare the
+	// outputs of a new model build with live traffic. Mirrored requests are not charged against rate limits
+	// and their responses are discarded, they never affect the responses to the clients.
+	// +optional
+	Mirror *MirrorTarget `json:"mirror,omitempty"`
+	// Fallbacks are the modelServers the requests matching the rule are sent to, in order, when the modelServer
+	// selected from targetModels can't serve them, e.g. a smaller model when a large one is overloaded.
+	// The model of the requests is rewritten to the model of the fallback modelServer.
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	Fallbacks []*FallbackTarget `json:"fallbacks,omitempty"`
+}
+
+// FallbackTrigger is a condition under which a request falls back to the next modelServer.
+// +kubebuilder:validation:Enum=NoHealthyPods;AllFiltered;RateLimited;ServerError
+type FallbackTrigger string
+
+const (
+	// FallbackOnNoHealthyPods falls back when the modelServer has no ready and healthy pod.
+	FallbackOnNoHealthyPods FallbackTrigger = "NoHealthyPods"
+	// FallbackOnAllFiltered falls back when all the pods are filtered out by the scheduler or have reached
+	// their concurrency limit.
+	FallbackOnAllFiltered FallbackTrigger = "AllFiltered"
+	// FallbackOnRateLimited falls back when the pods respond with 429 Too Many Requests.
+	FallbackOnRateLimited FallbackTrigger = "RateLimited"
+	// FallbackOnServerError falls back when all the attempts fail with a connection error, a timeout or a 5xx response.
+	FallbackOnServerError FallbackTrigger = "ServerError"
+)
+
+// FallbackTarget defines a modelServer requests fall back to, and when.
+type FallbackTarget struct {
+	// ModelServerName is the modelServer within the same namespace the requests fall back to.
+	//
+	// +kubebuilder:validation:required
+	ModelServerName string `json:"modelServerName"`
+	// On are the failures of the previous modelServer triggering the fallback.
+	// If this field is not specified, the requests fall back on any of them.
+	//
+	// +optional
+	On []FallbackTrigger `json:"on,omitempty"`
+}
+
+// MirrorTarget defines where and how much of the traffic of a rule is mirrored.
+type MirrorTarget struct {
+	// ModelServerName is the modelServer within the same namespace the requests are mirrored to.
+	//
+	// +kubebuilder:validation:required
+	ModelServerName string `json:"modelServerName"`
+	// Percentage is the percentage of the requests matching the rule which are mirrored.
+	// The value should be in the range of [0, 100].
+	//
+	// +optional
+	// +kubebuilder:default=100
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percentage *uint32 `json:"percentage,omitempty"`
 }
 
 // ModelMatch defines the predicate used to match LLM inference requests to a given
@@ -118,6 +174,7 @@ type TargetModel struct {
 	Weight *uint32 `json:"weight,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!has(self.overrides) || has(self.key)", message="overrides require a key"
 type RateLimit struct {
 	// InputTokensPerUnit is the maximum number of input tokens allowed per unit of time.
 	// If this field is not set, there is no limit on input tokens.
@@ -129,6 +186,25 @@ type RateLimit struct {
 	// +optional
 	// +kubebuilder:validation:Minimum=1
 	OutputTokensPerUnit *uint32 `json:"outputTokensPerUnit,omitempty"`
+	// RequestsPerUnit is the maximum number of requests allowed per unit of time.
+	// If this field is not set, there is no limit on the number of requests.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RequestsPerUnit *uint32 `json:"requestsPerUnit,omitempty"`
+	// OutputTokensAdmission is how requests are admitted against OutputTokensPerUnit.
+	// With Check, requests are admitted as long as output tokens are available, and their output tokens are
+	// charged once they complete. With Reserve, the max_tokens of requests are reserved when they are admitted,
+	// and the part they did not use is refunded once they complete, so that a burst of long generations
+	// can't exceed the limit.
+	// +optional
+	// +kubebuilder:default=Check
+	OutputTokensAdmission OutputTokensAdmission `json:"outputTokensAdmission,omitempty"`
+	// DefaultMaxTokens is the number of output tokens reserved for the requests which do not set max_tokens,
+	// with the Reserve admission of output tokens.
+	// +optional
+	// +kubebuilder:default=1024
+	// +kubebuilder:validation:Minimum=1
+	DefaultMaxTokens *uint32 `json:"defaultMaxTokens,omitempty"`
 	// Unit is the time unit for the rate limit.
 	// +kubebuilder:default=second
 	// +kubebuilder:validation:Enum=second;minute;hour;day;month
@@ -137,6 +213,111 @@ type RateLimit struct {
 	// If this field is set, global rate limiting will be used; otherwise, local rate limiting will be used.
 	// +optional
 	Global *GlobalRateLimit `json:"global,omitempty"`
+	// Key partitions the rate limit by the identity of the client, each key getting its own budget of tokens.
+	// If this field is not set, the budget is shared by all the requests of the model.
+	// +optional
+	Key *RateLimitKey `json:"key,omitempty"`
+	// Overrides are the limits of specific keys, which replace the limits above for their requests.
+	// +optional
+	// +listType=map
+	// +listMapKey=key
+	// +kubebuilder:validation:MaxItems=64
+	Overrides []RateLimitOverride `json:"overrides,omitempty"`
+	// Quota limits the tokens consumed per day or month, by key if the rate limit is keyed. Unlike the limits
+	// above, the consumption is persisted in a usage ledger, so that it survives the restarts of the router.
+	// The ledger is kept in Redis with global rate limiting, and in a file of the router otherwise.
+	// +optional
+	Quota *TokenQuota `json:"quota,omitempty"`
+}
+
+// TokenQuota defines the budget of input and output tokens consumed per period.
+// +kubebuilder:validation:XValidation:rule="has(self.tokensPerPeriod) || has(self.softTokensPerPeriod)", message="tokensPerPeriod or softTokensPerPeriod must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.tokensPerPeriod) || !has(self.softTokensPerPeriod) || self.softTokensPerPeriod <= self.tokensPerPeriod", message="softTokensPerPeriod must not exceed tokensPerPeriod"
+type TokenQuota struct {
+	// Period is the calendar period of the quota, in UTC. The consumption is reset at the start of each period.
+	// +kubebuilder:default=month
+	Period QuotaPeriod `json:"period"`
+	// TokensPerPeriod is the hard budget of tokens per period. Requests are rejected once it is consumed.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TokensPerPeriod *int64 `json:"tokensPerPeriod,omitempty"`
+	// SoftTokensPerPeriod is the soft budget of tokens per period. Requests are still admitted once it is
+	// consumed, but their responses carry a warning header.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	SoftTokensPerPeriod *int64 `json:"softTokensPerPeriod,omitempty"`
+}
+
+// QuotaPeriod is the calendar period of a token quota.
+// +kubebuilder:validation:Enum=day;month
+type QuotaPeriod string
+
+const (
+	QuotaDay   QuotaPeriod = "day"
+	QuotaMonth QuotaPeriod = "month"
+)
+
+// OutputTokensAdmission is how requests are admitted against the output token rate limit.
+// +kubebuilder:validation:Enum=Check;Reserve
+type OutputTokensAdmission string
+
+const (
+	// OutputTokensCheck admits requests while output tokens are available and charges their output tokens once they complete.
+	OutputTokensCheck OutputTokensAdmission = "Check"
+	// OutputTokensReserve reserves the max_tokens of requests at admission and refunds the unused tokens once they complete.
+	OutputTokensReserve OutputTokensAdmission = "Reserve"
+)
+
+// RateLimitKey defines where the rate limit key of a request is read from.
+// Requests without a key share the budget of the empty key.
+// +kubebuilder:validation:XValidation:rule="self.source != 'Header' || has(self.header)", message="header must be set for the Header source"
+// +kubebuilder:validation:XValidation:rule="self.source != 'JWTClaim' || has(self.jwtClaim)", message="jwtClaim must be set for the JWTClaim source"
+type RateLimitKey struct {
+	// Source is the source of the key.
+	// +kubebuilder:validation:Required
+	Source RateLimitKeySource `json:"source"`
+	// Header is the name of the request header holding the key, for the Header source.
+	// +optional
+	Header string `json:"header,omitempty"`
+	// JWTClaim is the claim of the authenticated JWT of the request holding the key, e.g. tenant, for the JWTClaim source.
+	// +optional
+	JWTClaim string `json:"jwtClaim,omitempty"`
+}
+
+// RateLimitKeySource is the source of the rate limit key of a request.
+// +kubebuilder:validation:Enum=User;APIKey;Header;JWTClaim
+type RateLimitKeySource string
+
+const (
+	// RateLimitKeyUser keys requests by their authenticated user, the subject of their JWT.
+	RateLimitKeyUser RateLimitKeySource = "User"
+	// RateLimitKeyAPIKey keys requests by their API key, sent as a bearer token or in the x-api-key header.
+	// The key is the hex encoded SHA-256 digest of the API key, so that API keys are not kept by the router.
+	RateLimitKeyAPIKey RateLimitKeySource = "APIKey"
+	// RateLimitKeyHeader keys requests by the value of a request header.
+	RateLimitKeyHeader RateLimitKeySource = "Header"
+	// RateLimitKeyJWTClaim keys requests by a claim of their authenticated JWT.
+	RateLimitKeyJWTClaim RateLimitKeySource = "JWTClaim"
+)
+
+// RateLimitOverride defines the limits of a rate limit key. The limits which are not set are the ones of the RateLimit.
+type RateLimitOverride struct {
+	// Key is the rate limit key the limits apply to.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+	// InputTokensPerUnit is the maximum number of input tokens allowed per unit of time for the key.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	InputTokensPerUnit *uint32 `json:"inputTokensPerUnit,omitempty"`
+	// OutputTokensPerUnit is the maximum number of output tokens allowed per unit of time for the key.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	OutputTokensPerUnit *uint32 `json:"outputTokensPerUnit,omitempty"`
+	// RequestsPerUnit is the maximum number of requests allowed per unit of time for the key.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RequestsPerUnit *uint32 `json:"requestsPerUnit,omitempty"`
 }
 
 // GlobalRateLimit contains configuration for global rate limiting
@@ -47,11 +47,26 @@ type ModelServerSpec struct {
 	// KVConnector specifies the KV connector configuration for PD disaggregated routing
 	// +optional
 	KVConnector *KVConnectorSpec `json:"kvConnector,omitempty"`
+
+	// MetricsEndpoint is the endpoint of the model serving instances the router scrapes metrics from,
+	// e.g. a sidecar exporter. Unset fields default to the metrics endpoint of the inference engine.
+	// +optional
+	MetricsEndpoint *PodEndpoint `json:"metricsEndpoint,omitempty"`
+
+	// ModelsEndpoint is the endpoint of the model serving instances the router discovers the served models
+	// and LoRA adapters from. Unset fields default to the models endpoint of the inference engine.
+	// +optional
+	ModelsEndpoint *PodEndpoint `json:"modelsEndpoint,omitempty"`
+
+	// HealthCheck enables the active probing of the model serving instances by the router. Instances failing
+	// the probe are not routed to until they pass it again. By default, the instances are not probed.
+	// +optional
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
 }
 
 // InferenceEngine defines the inference framework used by the modelServer to serve LLM requests.
 //
-// +kubebuilder:validation:Enum=vLLM;SGLang
+// +kubebuilder:validation:Enum=vLLM;SGLang;Triton
 type InferenceEngine string
 
 const (
@@ -59,6 +74,8 @@ const (
 	VLLM InferenceEngine = "vLLM"
 	// https://github.com/sgl-project/sglang
 	SGLang InferenceEngine = "SGLang"
+	// https://github.com/triton-inference-server/server
+	Triton InferenceEngine = "Triton"
 )
 
 // WorkloadSelector is used to match the model serving instances.
@@ -101,6 +118,70 @@ type WorkloadPort struct {
 	Protocol string `json:"protocol,omitempty"`
 }
 
+// PodEndpoint defines an HTTP endpoint exposed by the model serving instances.
+type PodEndpoint struct {
+	// The port of the endpoint. The number must be between 1 and 65535.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+
+	// The path of the endpoint, e.g. "/metrics".
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path,omitempty"`
+
+	// The scheme of the endpoint. Supported values are "http" and "https".
+	// +optional
+	// +kubebuilder:validation:Enum=http;https
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// HealthCheckProbe defines the request the router probes the model serving instances with.
+//
+// +kubebuilder:validation:Enum=Completion;Tokenize
+type HealthCheckProbe string
+
+const (
+	// ProbeCompletion sends a completion request generating a single token.
+	ProbeCompletion HealthCheckProbe = "Completion"
+	// ProbeTokenize sends a request tokenizing a short prompt, it is not supported by Triton.
+	ProbeTokenize HealthCheckProbe = "Tokenize"
+)
+
+// HealthCheck defines the active probing of the model serving instances by the router.
+// Unlike the readiness probes of the kubelet, the probe exercises the inference engine,
+// so that instances accepting connections but not serving requests are detected.
+type HealthCheck struct {
+	// The request the instances are probed with.
+	// +optional
+	// +kubebuilder:default="Completion"
+	Probe HealthCheckProbe `json:"probe,omitempty"`
+	// The path the probe is sent to on the workload port. By default, it is the path of the probe
+	// in the API of the inference engine, e.g. "/v1/completions" for a completion probe of vLLM.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path,omitempty"`
+	// The interval between two probes of an instance.
+	// +optional
+	// +kubebuilder:default="10s"
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// The timeout of a probe.
+	// +optional
+	// +kubebuilder:default="5s"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// The number of consecutive failed probes before an instance is considered unhealthy.
+	// +optional
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=1
+	UnhealthyThreshold *int32 `json:"unhealthyThreshold,omitempty"`
+	// The number of consecutive successful probes before an unhealthy instance is considered healthy again.
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	HealthyThreshold *int32 `json:"healthyThreshold,omitempty"`
+}
+
 type KVConnectorType string
 
 const (
@@ -127,10 +208,128 @@ type TrafficPolicy struct {
 	// The retry policy for the inference request.
 	// +optional
 	Retry *Retry `json:"retry,omitempty"`
+	// The limit of the concurrent inference requests the router sends to each model server instance.
+	// By default, there is no limit.
+	// +optional
+	ConcurrencyLimit *ConcurrencyLimit `json:"concurrencyLimit,omitempty"`
+	// The outlier detection of the model server instances. Instances failing inference requests are
+	// temporarily ejected from load balancing. By default, no instance is ejected.
+	// +optional
+	OutlierDetection *OutlierDetection `json:"outlierDetection,omitempty"`
+	// The hedging of streaming inference requests. A request whose first token is slow to arrive is also sent to
+	// other instances, and the first instance producing a token serves it. By default, requests are not hedged.
+	// +optional
+	Hedging *Hedging `json:"hedging,omitempty"`
+	// The session affinity of inference requests. The requests of a session are preferably sent to the same
+	// model server instance, so that multi-turn conversations reuse its KV cache. By default, there is no affinity.
+	// +optional
+	SessionAffinity *SessionAffinity `json:"sessionAffinity,omitempty"`
 
 	// TODO: add LoadBalancer policy
 }
 
+// SessionAffinity defines where the session ID of an inference request is read from, and how the requests of
+// a session are spread when the instance of the session is overloaded. Exactly one source must be set.
+// +kubebuilder:validation:XValidation:rule="[has(self.header), has(self.jwtClaim), has(self.bodyField)].filter(x, x).size() == 1", message="exactly one of header, jwtClaim and bodyField must be set"
+type SessionAffinity struct {
+	// Header is the name of the request header holding the session ID, e.g. x-session-id.
+	// +optional
+	Header string `json:"header,omitempty"`
+	// JWTClaim is the claim of the authenticated JWT of the request holding the session ID, e.g. sub.
+	// +optional
+	JWTClaim string `json:"jwtClaim,omitempty"`
+	// BodyField is the top-level field of the request body holding the session ID, e.g. user.
+	// +optional
+	BodyField string `json:"bodyField,omitempty"`
+	// MaxLoadPercent bounds the in-flight requests of the instance of a session, as a percentage of the average
+	// of the instances. Requests of a session whose instance is above it go to the next instance of the session.
+	// +optional
+	// +kubebuilder:default=125
+	// +kubebuilder:validation:Minimum=100
+	MaxLoadPercent *int32 `json:"maxLoadPercent,omitempty"`
+}
+
+// Hedging defines when a streaming inference request is sent to another model server instance
+// while the router is waiting for its first token.
+type Hedging struct {
+	// Delay is how long the router waits for the first token of an instance before sending the request to the next one.
+	// +kubebuilder:validation:Required
+	Delay metav1.Duration `json:"delay"`
+	// MaxHedges is the maximum number of instances the request is sent to in addition to the first one.
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	MaxHedges *int32 `json:"maxHedges,omitempty"`
+}
+
+// OutlierDetection defines when a model server instance is ejected from load balancing.
+// Connection failures and 5xx responses are counted as failures.
+type OutlierDetection struct {
+	// The number of consecutive failures before an instance is ejected. Zero disables the check.
+	// +optional
+	// +kubebuilder:default=5
+	// +kubebuilder:validation:Minimum=0
+	ConsecutiveFailures *int32 `json:"consecutiveFailures,omitempty"`
+	// The percentage of failed requests within Interval before an instance is ejected.
+	// By default, the failure percentage is not checked.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	FailurePercentage *int32 `json:"failurePercentage,omitempty"`
+	// The minimum number of requests within Interval for FailurePercentage to be checked.
+	// +optional
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	MinimumRequests *int32 `json:"minimumRequests,omitempty"`
+	// The window over which the failure percentage is computed.
+	// +optional
+	// +kubebuilder:default="10s"
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// The duration of the first ejection of an instance. It doubles every time the instance is ejected again
+	// within MaxEjectionTime after it was brought back.
+	// +optional
+	// +kubebuilder:default="30s"
+	BaseEjectionTime *metav1.Duration `json:"baseEjectionTime,omitempty"`
+	// The maximum duration of an ejection.
+	// +optional
+	// +kubebuilder:default="300s"
+	MaxEjectionTime *metav1.Duration `json:"maxEjectionTime,omitempty"`
+	// The maximum percentage of the instances of the model server which can be ejected at the same time.
+	// One instance can always be ejected, unless the percentage is 0, but the last instance able to serve requests
+	// is never ejected.
+	// +optional
+	// +kubebuilder:default=50
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MaxEjectionPercent *int32 `json:"maxEjectionPercent,omitempty"`
+}
+
+// OverflowPolicy defines what the router does with a request when all the model server instances are saturated.
+//
+// +kubebuilder:validation:Enum=Queue;Reject
+type OverflowPolicy string
+
+const (
+	// OverflowQueue holds the request until an instance has capacity, or the queue timeout expires.
+	OverflowQueue OverflowPolicy = "Queue"
+	// OverflowReject rejects the request with 429 Too Many Requests.
+	OverflowReject OverflowPolicy = "Reject"
+)
+
+type ConcurrencyLimit struct {
+	// The maximum number of inference requests in flight from the router to a single model server instance.
+	// +kubebuilder:validation:Minimum=1
+	MaxRequestsPerPod int32 `json:"maxRequestsPerPod"`
+	// Overflow defines what to do with a request when all the instances have reached MaxRequestsPerPod.
+	// +optional
+	// +kubebuilder:default="Reject"
+	Overflow OverflowPolicy `json:"overflow,omitempty"`
+	// QueueTimeout is the maximum time a request is queued for when Overflow is Queue.
+	// +optional
+	// +kubebuilder:default="10s"
+	QueueTimeout *metav1.Duration `json:"queueTimeout,omitempty"`
+}
+
 type Retry struct {
 	// The maximum number of times an individual inference request to a model server should be retried.
 	// If the maximum number of retries has been done without a successgful response, the request will be considered failed.
@@ -139,6 +338,13 @@ type Retry struct {
 	// RetryInterval is the interval between retries.
 	// +kubebuilder:default="100ms"
 	RetryInterval *metav1.Duration `json:"retryInterval,omitempty"`
+	// FirstEventTimeout is how long the response of a streaming request is held back waiting for its first event,
+	// so that the request can still be retried on another instance if it fails before producing any output.
+	// Once it expires, the response is streamed to the client and the request is no longer retried.
+	// Zero disables the buffering, streaming requests are then not retried once the upstream has responded.
+	// +optional
+	// +kubebuilder:default="10s"
+	FirstEventTimeout *metav1.Duration `json:"firstEventTimeout,omitempty"`
 }
 
 // ModelServerStatus defines the observed state of ModelServer.
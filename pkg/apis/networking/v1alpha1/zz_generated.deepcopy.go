@@ -46,6 +46,46 @@ func (in *BodyMatch) DeepCopy() *BodyMatch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcurrencyLimit) DeepCopyInto(out *ConcurrencyLimit) {
+	*out = *in
+	if in.QueueTimeout != nil {
+		in, out := &in.QueueTimeout, &out.QueueTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrencyLimit.
+func (in *ConcurrencyLimit) DeepCopy() *ConcurrencyLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcurrencyLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FallbackTarget) DeepCopyInto(out *FallbackTarget) {
+	*out = *in
+	if in.On != nil {
+		in, out := &in.On, &out.On
+		*out = make([]FallbackTrigger, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FallbackTarget.
+func (in *FallbackTarget) DeepCopy() *FallbackTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(FallbackTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GlobalRateLimit) DeepCopyInto(out *GlobalRateLimit) {
 	*out = *in
@@ -66,6 +106,62 @@ func (in *GlobalRateLimit) DeepCopy() *GlobalRateLimit {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheck) DeepCopyInto(out *HealthCheck) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.UnhealthyThreshold != nil {
+		in, out := &in.UnhealthyThreshold, &out.UnhealthyThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HealthyThreshold != nil {
+		in, out := &in.HealthyThreshold, &out.HealthyThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheck.
+func (in *HealthCheck) DeepCopy() *HealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hedging) DeepCopyInto(out *Hedging) {
+	*out = *in
+	out.Delay = in.Delay
+	if in.MaxHedges != nil {
+		in, out := &in.MaxHedges, &out.MaxHedges
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hedging.
+func (in *Hedging) DeepCopy() *Hedging {
+	if in == nil {
+		return nil
+	}
+	out := new(Hedging)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KVConnectorSpec) DeepCopyInto(out *KVConnectorSpec) {
 	*out = *in
@@ -81,6 +177,26 @@ func (in *KVConnectorSpec) DeepCopy() *KVConnectorSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorTarget) DeepCopyInto(out *MirrorTarget) {
+	*out = *in
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(uint32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorTarget.
+func (in *MirrorTarget) DeepCopy() *MirrorTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ModelMatch) DeepCopyInto(out *ModelMatch) {
 	*out = *in
@@ -322,6 +438,21 @@ func (in *ModelServerSpec) DeepCopyInto(out *ModelServerSpec) {
 		*out = new(KVConnectorSpec)
 		**out = **in
 	}
+	if in.MetricsEndpoint != nil {
+		in, out := &in.MetricsEndpoint, &out.MetricsEndpoint
+		*out = new(PodEndpoint)
+		**out = **in
+	}
+	if in.ModelsEndpoint != nil {
+		in, out := &in.ModelsEndpoint, &out.ModelsEndpoint
+		*out = new(PodEndpoint)
+		**out = **in
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(HealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelServerSpec.
@@ -349,6 +480,56 @@ func (in *ModelServerStatus) DeepCopy() *ModelServerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutlierDetection) DeepCopyInto(out *OutlierDetection) {
+	*out = *in
+	if in.ConsecutiveFailures != nil {
+		in, out := &in.ConsecutiveFailures, &out.ConsecutiveFailures
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailurePercentage != nil {
+		in, out := &in.FailurePercentage, &out.FailurePercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinimumRequests != nil {
+		in, out := &in.MinimumRequests, &out.MinimumRequests
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.BaseEjectionTime != nil {
+		in, out := &in.BaseEjectionTime, &out.BaseEjectionTime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxEjectionTime != nil {
+		in, out := &in.MaxEjectionTime, &out.MaxEjectionTime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxEjectionPercent != nil {
+		in, out := &in.MaxEjectionPercent, &out.MaxEjectionPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutlierDetection.
+func (in *OutlierDetection) DeepCopy() *OutlierDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(OutlierDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PDGroup) DeepCopyInto(out *PDGroup) {
 	*out = *in
@@ -378,6 +559,21 @@ func (in *PDGroup) DeepCopy() *PDGroup {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodEndpoint) DeepCopyInto(out *PodEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodEndpoint.
+func (in *PodEndpoint) DeepCopy() *PodEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(PodEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RateLimit) DeepCopyInto(out *RateLimit) {
 	*out = *in
@@ -391,11 +587,38 @@ func (in *RateLimit) DeepCopyInto(out *RateLimit) {
 		*out = new(uint32)
 		**out = **in
 	}
+	if in.RequestsPerUnit != nil {
+		in, out := &in.RequestsPerUnit, &out.RequestsPerUnit
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.DefaultMaxTokens != nil {
+		in, out := &in.DefaultMaxTokens, &out.DefaultMaxTokens
+		*out = new(uint32)
+		**out = **in
+	}
 	if in.Global != nil {
 		in, out := &in.Global, &out.Global
 		*out = new(GlobalRateLimit)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Key != nil {
+		in, out := &in.Key, &out.Key
+		*out = new(RateLimitKey)
+		**out = **in
+	}
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]RateLimitOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(TokenQuota)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimit.
@@ -408,6 +631,51 @@ func (in *RateLimit) DeepCopy() *RateLimit {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitKey) DeepCopyInto(out *RateLimitKey) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitKey.
+func (in *RateLimitKey) DeepCopy() *RateLimitKey {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitOverride) DeepCopyInto(out *RateLimitOverride) {
+	*out = *in
+	if in.InputTokensPerUnit != nil {
+		in, out := &in.InputTokensPerUnit, &out.InputTokensPerUnit
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.OutputTokensPerUnit != nil {
+		in, out := &in.OutputTokensPerUnit, &out.OutputTokensPerUnit
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.RequestsPerUnit != nil {
+		in, out := &in.RequestsPerUnit, &out.RequestsPerUnit
+		*out = new(uint32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitOverride.
+func (in *RateLimitOverride) DeepCopy() *RateLimitOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RedisConfig) DeepCopyInto(out *RedisConfig) {
 	*out = *in
@@ -431,6 +699,11 @@ func (in *Retry) DeepCopyInto(out *Retry) {
 		*out = new(metav1.Duration)
 		**out = **in
 	}
+	if in.FirstEventTimeout != nil {
+		in, out := &in.FirstEventTimeout, &out.FirstEventTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Retry.
@@ -462,6 +735,22 @@ func (in *Rule) DeepCopyInto(out *Rule) {
 			}
 		}
 	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(MirrorTarget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Fallbacks != nil {
+		in, out := &in.Fallbacks, &out.Fallbacks
+		*out = make([]*FallbackTarget, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(FallbackTarget)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rule.
@@ -474,6 +763,26 @@ func (in *Rule) DeepCopy() *Rule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SessionAffinity) DeepCopyInto(out *SessionAffinity) {
+	*out = *in
+	if in.MaxLoadPercent != nil {
+		in, out := &in.MaxLoadPercent, &out.MaxLoadPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SessionAffinity.
+func (in *SessionAffinity) DeepCopy() *SessionAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(SessionAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StringMatch) DeepCopyInto(out *StringMatch) {
 	*out = *in
@@ -524,6 +833,31 @@ func (in *TargetModel) DeepCopy() *TargetModel {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenQuota) DeepCopyInto(out *TokenQuota) {
+	*out = *in
+	if in.TokensPerPeriod != nil {
+		in, out := &in.TokensPerPeriod, &out.TokensPerPeriod
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SoftTokensPerPeriod != nil {
+		in, out := &in.SoftTokensPerPeriod, &out.SoftTokensPerPeriod
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenQuota.
+func (in *TokenQuota) DeepCopy() *TokenQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrafficPolicy) DeepCopyInto(out *TrafficPolicy) {
 	*out = *in
@@ -537,6 +871,26 @@ func (in *TrafficPolicy) DeepCopyInto(out *TrafficPolicy) {
 		*out = new(Retry)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ConcurrencyLimit != nil {
+		in, out := &in.ConcurrencyLimit, &out.ConcurrencyLimit
+		*out = new(ConcurrencyLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutlierDetection != nil {
+		in, out := &in.OutlierDetection, &out.OutlierDetection
+		*out = new(OutlierDetection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hedging != nil {
+		in, out := &in.Hedging, &out.Hedging
+		*out = new(Hedging)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SessionAffinity != nil {
+		in, out := &in.SessionAffinity, &out.SessionAffinity
+		*out = new(SessionAffinity)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficPolicy.
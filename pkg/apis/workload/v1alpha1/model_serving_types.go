@@ -95,7 +95,8 @@ type RolloutStrategy struct {
 type RolloutStrategyType string
 
 const (
-	// ServingGroupRollingUpdate indicates that ServingGroup replicas will be updated one by one.
+	// ServingGroupRollingUpdate indicates that ServingGroup replicas will be updated in a rolling way,
+	// bounded by maxSurge and maxUnavailable.
 	ServingGroupRollingUpdate RolloutStrategyType = "ServingGroupRollingUpdate"
 )
 
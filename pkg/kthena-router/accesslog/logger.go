@@ -146,7 +146,8 @@ func (l *accessLoggerImpl) formatJSON(entry *AccessLogEntry) (string, error) {
 // formatText formats the entry as structured text
 func (l *accessLoggerImpl) formatText(entry *AccessLogEntry) (string, error) {
 	// Format: [timestamp] "METHOD /path PROTOCOL" status_code [error=type:message]
-	// model_name=name model_route=route model_server=server selected_pod=pod request_id=id tokens=input/output
+	// model_name=name model_route=route model_server=server selected_pod=pod request_id=id [mirror=true]
+	// [fallback_from=server fallback_trigger=trigger] tokens=input/output
 	// timings=total(req+upstream+resp)ms
 
 	timestamp := entry.Timestamp.Format(time.RFC3339Nano)
@@ -177,6 +178,12 @@ func (l *accessLoggerImpl) formatText(entry *AccessLogEntry) (string, error) {
 	if entry.RequestID != "" {
 		line += fmt.Sprintf(" request_id=%s", entry.RequestID)
 	}
+	if entry.Mirror {
+		line += " mirror=true"
+	}
+	if entry.FallbackFrom != "" {
+		line += fmt.Sprintf(" fallback_from=%s fallback_trigger=%s", entry.FallbackFrom, entry.FallbackTrigger)
+	}
 
 	// Add token information
 	if entry.InputTokens > 0 || entry.OutputTokens > 0 {
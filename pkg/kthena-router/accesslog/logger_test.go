@@ -127,6 +127,58 @@ func TestAccessLogEntry_ToText(t *testing.T) {
 	}
 }
 
+func TestAccessLogEntry_Mirror(t *testing.T) {
+	entry := &AccessLogEntry{
+		Timestamp:   time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.UTC),
+		Method:      "POST",
+		Path:        "/v1/chat/completions",
+		Protocol:    "HTTP/1.1",
+		StatusCode:  200,
+		ModelName:   "llama2-7b",
+		ModelServer: "default/llama2-server-candidate",
+		RequestID:   "test-request-id",
+		Mirror:      true,
+	}
+
+	logger := &accessLoggerImpl{config: &AccessLoggerConfig{Format: FormatText}}
+	output, err := logger.formatText(entry)
+	require.NoError(t, err)
+	assert.Contains(t, output, "request_id=test-request-id mirror=true")
+
+	output, err = logger.formatJSON(entry)
+	require.NoError(t, err)
+	assert.Contains(t, output, `"mirror":true`)
+
+	entry.Mirror = false
+	output, err = logger.formatJSON(entry)
+	require.NoError(t, err)
+	assert.NotContains(t, output, "mirror")
+}
+
+func TestAccessLogEntry_Fallback(t *testing.T) {
+	entry := &AccessLogEntry{
+		Timestamp:       time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.UTC),
+		Method:          "POST",
+		Path:            "/v1/chat/completions",
+		Protocol:        "HTTP/1.1",
+		StatusCode:      200,
+		ModelName:       "llama2",
+		ModelServer:     "default/llama2-7b-server",
+		RequestID:       "test-request-id",
+		FallbackFrom:    "default/llama2-70b-server",
+		FallbackTrigger: "RateLimited",
+	}
+
+	logger := &accessLoggerImpl{config: &AccessLoggerConfig{Format: FormatText}}
+	output, err := logger.formatText(entry)
+	require.NoError(t, err)
+	assert.Contains(t, output, "request_id=test-request-id fallback_from=default/llama2-70b-server fallback_trigger=RateLimited")
+
+	output, err = logger.formatJSON(entry)
+	require.NoError(t, err)
+	assert.Contains(t, output, `"fallback_from":"default/llama2-70b-server","fallback_trigger":"RateLimited"`)
+}
+
 func TestAccessLogEntry_WithError(t *testing.T) {
 	entry := &AccessLogEntry{
 		Timestamp:  time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.UTC),
@@ -87,6 +87,13 @@ func SetRequestRouting(c *gin.Context, modelRoute, modelServer, selectedPod stri
 	}
 }
 
+// SetFallback records a fallback to another model server in the access log context
+func SetFallback(c *gin.Context, fallbackFrom, trigger string) {
+	if ctx := GetAccessLogContext(c); ctx != nil {
+		ctx.SetFallback(fallbackFrom, trigger)
+	}
+}
+
 // SetTokenCounts sets token counts in the access log context
 func SetTokenCounts(c *gin.Context, inputTokens, outputTokens int) {
 	if ctx := GetAccessLogContext(c); ctx != nil {
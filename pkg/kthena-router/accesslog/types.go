@@ -38,6 +38,12 @@ type AccessLogEntry struct {
 	ModelServer string `json:"model_server,omitempty"`
 	SelectedPod string `json:"selected_pod,omitempty"`
 	RequestID   string `json:"request_id,omitempty"`
+	// Mirror marks the entries of mirrored requests, which share the request ID of the original request
+	Mirror bool `json:"mirror,omitempty"`
+	// FallbackFrom is the model server selected by the rule which failed to serve the request, and
+	// FallbackTrigger its failure. ModelServer is then the fallback model server the request was sent to.
+	FallbackFrom    string `json:"fallback_from,omitempty"`
+	FallbackTrigger string `json:"fallback_trigger,omitempty"`
 
 	// Token information
 	InputTokens  int `json:"input_tokens,omitempty"`
@@ -69,6 +75,10 @@ type AccessLogContext struct {
 	ModelServer string
 	SelectedPod string
 
+	// Fallback information
+	FallbackFrom    string
+	FallbackTrigger string
+
 	// Token counts
 	InputTokens  int
 	OutputTokens int
@@ -109,6 +119,14 @@ func (ctx *AccessLogContext) SetModelRouting(modelRoute string, modelServer stri
 	ctx.SelectedPod = selectedPod
 }
 
+// SetFallback records that the request fell back from the model server fallbackFrom on trigger.
+// The error of the failed model server is cleared, it is only reported if the fallback fails as well.
+func (ctx *AccessLogContext) SetFallback(fallbackFrom, trigger string) {
+	ctx.FallbackFrom = fallbackFrom
+	ctx.FallbackTrigger = trigger
+	ctx.Error = nil
+}
+
 // SetTokenCounts sets the input and output token counts
 func (ctx *AccessLogContext) SetTokenCounts(inputTokens, outputTokens int) {
 	ctx.InputTokens = inputTokens
@@ -187,6 +205,8 @@ func (ctx *AccessLogContext) ToAccessLogEntry(statusCode int) *AccessLogEntry {
 		ModelServer:                modelServerName,
 		SelectedPod:                ctx.SelectedPod,
 		RequestID:                  ctx.RequestID,
+		FallbackFrom:               ctx.FallbackFrom,
+		FallbackTrigger:            ctx.FallbackTrigger,
 		InputTokens:                ctx.InputTokens,
 		OutputTokens:               ctx.OutputTokens,
 		DurationTotal:              total,
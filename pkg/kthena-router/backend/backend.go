@@ -17,39 +17,49 @@ limitations under the License.
 package backend
 
 import (
+	"context"
 	"fmt"
 
 	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/sglang"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/triton"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/vllm"
 )
 
 type MetricsProvider interface {
-	GetPodMetrics(pod *corev1.Pod) (map[string]*dto.MetricFamily, error)
-	GetPodModels(pod *corev1.Pod) ([]string, error)
+	// GetPodMetrics scrapes the metrics of the pod from endpoint, or from the default endpoint of the engine if it is nil.
+	GetPodMetrics(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint) (map[string]*dto.MetricFamily, error)
+	// GetPodModels lists the models served by the pod from endpoint, or from the default endpoint of the engine if it is nil.
+	GetPodModels(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint) ([]string, error)
+	// ProbePod sends a probe request for model to the pod at endpoint, which defaults to the path of the probe in
+	// the API of the engine. An error is returned unless the pod answers it successfully before ctx is done.
+	ProbePod(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint, probe v1alpha1.HealthCheckProbe, model string) error
 	GetCountMetricsInfo(allMetrics map[string]*dto.MetricFamily) map[string]float64
 	GetHistogramPodMetrics(allMetrics map[string]*dto.MetricFamily, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram)
 }
 
 var engineRegistry = map[string]MetricsProvider{
 	"SGLang": sglang.NewSglangEngine(),
+	"Triton": triton.NewTritonEngine(),
 	"vLLM":   vllm.NewVllmEngine(),
 }
 
-func GetPodMetrics(engine string, pod *corev1.Pod, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram) {
+// GetPodMetrics scrapes the metrics of the pod, the scrape is aborted once ctx is done.
+func GetPodMetrics(ctx context.Context, engine string, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram, error) {
 	provider, err := GetMetricsProvider(engine)
 	if err != nil {
 		klog.Errorf("Failed to get inference engine: %v", err)
-		return nil, nil
+		return nil, nil, err
 	}
 
-	allMetrics, err := provider.GetPodMetrics(pod)
+	allMetrics, err := provider.GetPodMetrics(ctx, pod, endpoint)
 	if err != nil {
 		klog.V(4).Infof("failed to get metrics of pod: %s/%s: %v", pod.GetNamespace(), pod.GetName(), err)
-		return nil, nil
+		return nil, nil, err
 	}
 
 	countMetricsInfo := provider.GetCountMetricsInfo(allMetrics)
@@ -61,7 +71,7 @@ func GetPodMetrics(engine string, pod *corev1.Pod, previousHistogram map[string]
 		countMetricsInfo[name] = value
 	}
 
-	return countMetricsInfo, histogramMetrics
+	return countMetricsInfo, histogramMetrics, nil
 }
 
 func GetMetricsProvider(engine string) (MetricsProvider, error) {
@@ -71,12 +81,23 @@ func GetMetricsProvider(engine string) (MetricsProvider, error) {
 	return nil, fmt.Errorf("unsupported engine: %s", engine)
 }
 
-func GetPodModels(engine string, pod *corev1.Pod) ([]string, error) {
+// GetPodModels lists the models served by the pod, the request is aborted once ctx is done.
+func GetPodModels(ctx context.Context, engine string, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint) ([]string, error) {
 	provider, err := GetMetricsProvider(engine)
 	if err != nil {
 		klog.Errorf("Failed to get inference engine: %v", err)
 		return nil, nil
 	}
 
-	return provider.GetPodModels(pod)
+	return provider.GetPodModels(ctx, pod, endpoint)
+}
+
+// ProbePod actively probes the pod with a tiny inference request, the probe is aborted once ctx is done.
+func ProbePod(ctx context.Context, engine string, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint, probe v1alpha1.HealthCheckProbe, model string) error {
+	provider, err := GetMetricsProvider(engine)
+	if err != nil {
+		return err
+	}
+
+	return provider.ProbePod(ctx, pod, endpoint, probe, model)
 }
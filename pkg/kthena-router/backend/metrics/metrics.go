@@ -17,16 +17,45 @@ limitations under the License.
 package metrics
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
 
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
 )
 
+// EndpointURL returns the url of an endpoint of the pod. The fields which are not set in endpoint
+// default to http, defaultPort and defaultPath.
+func EndpointURL(pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint, defaultPort uint32, defaultPath string) string {
+	scheme := "http"
+	port := strconv.FormatUint(uint64(defaultPort), 10)
+	path := defaultPath
+	if endpoint != nil {
+		if endpoint.Scheme != "" {
+			scheme = endpoint.Scheme
+		}
+		if endpoint.Port != 0 {
+			port = strconv.Itoa(int(endpoint.Port))
+		}
+		if endpoint.Path != "" {
+			path = endpoint.Path
+		}
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(pod.Status.PodIP, port), path)
+}
+
 // This function refer to aibrix(https://github.com/vllm-project/aibrix/blob/main/pkg/metrics/utils.go)
-func ParseMetricsURL(url string) (map[string]*dto.MetricFamily, error) {
-	resp, err := http.Get(url)
+func ParseMetricsURL(ctx context.Context, url string) (map[string]*dto.MetricFamily, error) {
+	resp, err := get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to fetch metrics from %s: %v", url, err)
 	}
@@ -60,3 +89,98 @@ func LastPeriodAvg(previous, current *dto.Histogram) float64 {
 
 	return deltaSum / float64(deltaCount)
 }
+
+type Model struct {
+	ID string `json:"id"`
+}
+
+type ModelList struct {
+	Data []Model `json:"data"`
+}
+
+// ParseModelsURL returns the ids of the models listed by an OpenAI compatible models endpoint.
+func ParseModelsURL(ctx context.Context, url string) ([]string, error) {
+	resp, err := get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelList ModelList
+	err = json.Unmarshal(body, &modelList)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(modelList.Data))
+	for _, model := range modelList.Data {
+		models = append(models, model.ID)
+	}
+	return models, nil
+}
+
+// Paths of the probes in the OpenAI compatible API of vLLM and SGLang.
+const (
+	CompletionProbePath = "/v1/completions"
+	TokenizeProbePath   = "/tokenize"
+)
+
+// ProbePrompt is the prompt of the probe requests, it is kept tiny so that probes cost next to nothing.
+const ProbePrompt = "Hi"
+
+// ProbeOpenAI probes a pod serving an OpenAI compatible API. An empty model lets the engine pick its served model.
+func ProbeOpenAI(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint, defaultPort uint32, probe v1alpha1.HealthCheckProbe, model string) error {
+	body := map[string]any{"prompt": ProbePrompt}
+	if model != "" {
+		body["model"] = model
+	}
+	switch probe {
+	case v1alpha1.ProbeTokenize:
+		return PostJSON(ctx, EndpointURL(pod, endpoint, defaultPort, TokenizeProbePath), body)
+	default:
+		body["max_tokens"] = 1
+		return PostJSON(ctx, EndpointURL(pod, endpoint, defaultPort, CompletionProbePath), body)
+	}
+}
+
+// PostJSON sends a POST request with body encoded as JSON to url, which is canceled once ctx is done.
+// It returns an error unless the response status is 2xx.
+func PostJSON(ctx context.Context, url string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+// get sends a GET request to url, which is canceled once ctx is done.
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
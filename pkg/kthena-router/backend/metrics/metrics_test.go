@@ -0,0 +1,120 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+func TestEndpointURL(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{PodIP: "10.0.0.1"}}
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		endpoint *v1alpha1.PodEndpoint
+		want     string
+	}{
+		{
+			name: "defaults",
+			pod:  pod,
+			want: "http://10.0.0.1:8000/metrics",
+		},
+		{
+			name:     "empty endpoint",
+			pod:      pod,
+			endpoint: &v1alpha1.PodEndpoint{},
+			want:     "http://10.0.0.1:8000/metrics",
+		},
+		{
+			name:     "sidecar exporter",
+			pod:      pod,
+			endpoint: &v1alpha1.PodEndpoint{Port: 9400, Path: "/exporter/metrics", Scheme: "https"},
+			want:     "https://10.0.0.1:9400/exporter/metrics",
+		},
+		{
+			name:     "ipv6",
+			pod:      &corev1.Pod{Status: corev1.PodStatus{PodIP: "fd00::1"}},
+			endpoint: &v1alpha1.PodEndpoint{Port: 9090},
+			want:     "http://[fd00::1]:9090/metrics",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EndpointURL(tt.pod, tt.endpoint, 8000, "/metrics"))
+		})
+	}
+}
+
+func TestParseModelsURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		fmt.Fprint(w, `{"object": "list", "data": [{"id": "base"}, {"id": "lora-1"}]}`)
+	}))
+	defer server.Close()
+
+	models, err := ParseModelsURL(context.Background(), server.URL+"/v1/models")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base", "lora-1"}, models)
+}
+
+func TestProbeOpenAI(t *testing.T) {
+	var path string
+	var body map[string]any
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	pod := &corev1.Pod{Status: corev1.PodStatus{PodIP: host}}
+
+	err = ProbeOpenAI(context.Background(), pod, nil, uint32(portNum), v1alpha1.ProbeCompletion, "base")
+	require.NoError(t, err)
+	assert.Equal(t, CompletionProbePath, path)
+	assert.Equal(t, map[string]any{"model": "base", "prompt": ProbePrompt, "max_tokens": float64(1)}, body)
+
+	// The model is left to the engine when it is unknown
+	err = ProbeOpenAI(context.Background(), pod, nil, uint32(portNum), v1alpha1.ProbeTokenize, "")
+	require.NoError(t, err)
+	assert.Equal(t, TokenizeProbePath, path)
+	assert.Equal(t, map[string]any{"prompt": ProbePrompt}, body)
+
+	endpoint := &v1alpha1.PodEndpoint{Path: "/custom"}
+	status = http.StatusServiceUnavailable
+	err = ProbeOpenAI(context.Background(), pod, endpoint, uint32(portNum), v1alpha1.ProbeCompletion, "base")
+	assert.ErrorContains(t, err, "unexpected status code 503")
+	assert.Equal(t, "/custom", path)
+}
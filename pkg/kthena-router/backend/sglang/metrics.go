@@ -17,11 +17,12 @@ limitations under the License.
 package sglang
 
 import (
-	"fmt"
+	"context"
 
 	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/metrics"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
 )
@@ -65,9 +66,9 @@ func NewSglangEngine() *sglangEngine {
 	}
 }
 
-func (engine *sglangEngine) GetPodMetrics(pod *corev1.Pod) (map[string]*dto.MetricFamily, error) {
-	url := fmt.Sprintf("http://%s:%d/metrics", pod.Status.PodIP, engine.MetricPort)
-	allMetrics, err := metrics.ParseMetricsURL(url)
+func (engine *sglangEngine) GetPodMetrics(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint) (map[string]*dto.MetricFamily, error) {
+	url := metrics.EndpointURL(pod, endpoint, engine.MetricPort, "/metrics")
+	allMetrics, err := metrics.ParseMetricsURL(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +116,12 @@ func (engine *sglangEngine) GetHistogramPodMetrics(allMetrics map[string]*dto.Me
 	return wantMetrics, histogramMetrics
 }
 
-// TODO： Methods to get Models from sglang
-func (engine *sglangEngine) GetPodModels(pod *corev1.Pod) ([]string, error) {
-	return nil, nil
+// GetPodModels lists the models of the OpenAI compatible models endpoint of sglang, only when it is configured.
+// TODO： Methods to get Models from sglang by default
+func (engine *sglangEngine) GetPodModels(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint) ([]string, error) {
+	if endpoint == nil {
+		return nil, nil
+	}
+	url := metrics.EndpointURL(pod, endpoint, engine.MetricPort, "/v1/models")
+	return metrics.ParseModelsURL(ctx, url)
 }
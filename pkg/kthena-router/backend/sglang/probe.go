@@ -0,0 +1,30 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sglang
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/metrics"
+)
+
+func (engine *sglangEngine) ProbePod(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint, probe v1alpha1.HealthCheckProbe, model string) error {
+	return metrics.ProbeOpenAI(ctx, pod, endpoint, engine.MetricPort, probe, model)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package triton
+
+import (
+	"context"
+
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/metrics"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+// Triton reports its metrics per model and version, they are summed up for the pod.
+var (
+	PendingRequestCount = "nv_inference_pending_request_count"
+	QueueDuration       = "nv_inference_queue_duration_us"
+	RequestSuccess      = "nv_inference_request_success"
+)
+
+type tritonEngine struct {
+	// The address of triton's query metrics is http://{model server}:MetricPort/metrics
+	// Default is 8002
+	MetricPort uint32
+	// The address of triton's HTTP endpoint is http://{model server}:HTTPPort
+	// Default is 8000
+	HTTPPort uint32
+}
+
+func NewTritonEngine() *tritonEngine {
+	// TODO: Get ports from triton configuration
+	return &tritonEngine{
+		MetricPort: 8002,
+		HTTPPort:   8000,
+	}
+}
+
+func (engine *tritonEngine) GetPodMetrics(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint) (map[string]*dto.MetricFamily, error) {
+	url := metrics.EndpointURL(pod, endpoint, engine.MetricPort, "/metrics")
+	allMetrics, err := metrics.ParseMetricsURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return allMetrics, nil
+}
+
+func (engine *tritonEngine) GetCountMetricsInfo(allMetrics map[string]*dto.MetricFamily) map[string]float64 {
+	wantMetrics := make(map[string]float64)
+	// Pending requests are queued and not yet executed by the backend
+	if pending, exist := sumMetric(allMetrics, PendingRequestCount); exist {
+		wantMetrics[utils.RequestWaitingNum] = pending
+	}
+	return wantMetrics
+}
+
+// GetHistogramPodMetrics returns the average time requests waited in the scheduling queue during the last period as TTFT,
+// which is the latency the scheduler compares between pods. Triton only reports the cumulative queue duration
+// and request count, so they are kept as the sum and count of a histogram.
+func (engine *tritonEngine) GetHistogramPodMetrics(allMetrics map[string]*dto.MetricFamily, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram) {
+	wantMetrics := make(map[string]float64)
+	histogramMetrics := make(map[string]*dto.Histogram)
+
+	queueDuration, exist := sumMetric(allMetrics, QueueDuration)
+	if !exist {
+		return wantMetrics, histogramMetrics
+	}
+	requests, exist := sumMetric(allMetrics, RequestSuccess)
+	if !exist {
+		return wantMetrics, histogramMetrics
+	}
+
+	sampleSum := queueDuration / 1e6
+	sampleCount := uint64(requests)
+	histogram := &dto.Histogram{SampleSum: &sampleSum, SampleCount: &sampleCount}
+	histogramMetrics[utils.TTFT] = histogram
+	if previousMetric := previousHistogram[utils.TTFT]; previousMetric == nil {
+		// Ignore the effects of history and give each pod a fair chance at the initial.
+		wantMetrics[utils.TTFT] = float64(0.0)
+	} else {
+		wantMetrics[utils.TTFT] = metrics.LastPeriodAvg(previousMetric, histogram)
+	}
+
+	return wantMetrics, histogramMetrics
+}
+
+// sumMetric sums up the values of all the series of a counter or gauge metric.
+func sumMetric(allMetrics map[string]*dto.MetricFamily, metricName string) (float64, bool) {
+	metricInfo, exist := allMetrics[metricName]
+	if !exist {
+		return 0, false
+	}
+	sum := 0.0
+	for _, metric := range metricInfo.Metric {
+		switch {
+		case metric.Counter != nil:
+			sum += metric.GetCounter().GetValue()
+		case metric.Gauge != nil:
+			sum += metric.GetGauge().GetValue()
+		case metric.Untyped != nil:
+			sum += metric.GetUntyped().GetValue()
+		}
+	}
+	return sum, true
+}
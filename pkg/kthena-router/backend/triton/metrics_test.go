@@ -0,0 +1,108 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package triton
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+func parseMetrics(t *testing.T, text string) map[string]*dto.MetricFamily {
+	var parser expfmt.TextParser
+	allMetrics, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	require.NoError(t, err)
+	return allMetrics
+}
+
+func tritonMetrics(queueDurationUs, requests int) string {
+	return fmt.Sprintf(`# HELP nv_inference_pending_request_count Instantaneous number of pending requests awaiting execution per-model.
+# TYPE nv_inference_pending_request_count gauge
+nv_inference_pending_request_count{model="resnet",version="1"} 3
+nv_inference_pending_request_count{model="bert",version="1"} 2
+# HELP nv_inference_queue_duration_us Cumulative inference queuing duration in microseconds (includes cached requests)
+# TYPE nv_inference_queue_duration_us counter
+nv_inference_queue_duration_us{model="resnet",version="1"} %d
+nv_inference_queue_duration_us{model="bert",version="1"} 0
+# HELP nv_inference_request_success Number of successful inference requests, all batch sizes
+# TYPE nv_inference_request_success counter
+nv_inference_request_success{model="resnet",version="1"} %d
+nv_inference_request_success{model="bert",version="1"} 0
+`, queueDurationUs, requests)
+}
+
+func TestTritonEngine_GetCountMetricsInfo(t *testing.T) {
+	engine := NewTritonEngine()
+
+	got := engine.GetCountMetricsInfo(parseMetrics(t, tritonMetrics(0, 0)))
+	assert.Equal(t, map[string]float64{utils.RequestWaitingNum: 5}, got)
+
+	assert.Empty(t, engine.GetCountMetricsInfo(map[string]*dto.MetricFamily{}))
+}
+
+func TestTritonEngine_GetHistogramPodMetrics(t *testing.T) {
+	engine := NewTritonEngine()
+
+	// The first scrape has no previous period
+	got, histograms := engine.GetHistogramPodMetrics(parseMetrics(t, tritonMetrics(1000000, 10)), map[string]*dto.Histogram{})
+	assert.Equal(t, map[string]float64{utils.TTFT: 0}, got)
+	require.Contains(t, histograms, utils.TTFT)
+	assert.Equal(t, 1.0, histograms[utils.TTFT].GetSampleSum())
+	assert.Equal(t, uint64(10), histograms[utils.TTFT].GetSampleCount())
+
+	// 10 more requests waited 3s in total
+	got, _ = engine.GetHistogramPodMetrics(parseMetrics(t, tritonMetrics(4000000, 20)), histograms)
+	assert.InDelta(t, 0.3, got[utils.TTFT], 1e-9)
+
+	got, histograms = engine.GetHistogramPodMetrics(map[string]*dto.MetricFamily{}, nil)
+	assert.Empty(t, got)
+	assert.Empty(t, histograms)
+}
+
+func TestTritonEngine_GetPodModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v2/repository/index", r.URL.Path)
+		fmt.Fprint(w, `[
+			{"name": "resnet", "version": "1", "state": "READY"},
+			{"name": "resnet", "version": "2", "state": "READY"},
+			{"name": "bert", "version": "1", "state": "UNAVAILABLE"}
+		]`)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+
+	engine := &tritonEngine{HTTPPort: uint32(port)}
+	pod := &corev1.Pod{Status: corev1.PodStatus{PodIP: serverURL.Hostname()}}
+	models, err := engine.GetPodModels(context.Background(), pod, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"resnet"}, models)
+}
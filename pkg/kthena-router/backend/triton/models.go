@@ -0,0 +1,78 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package triton
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/metrics"
+)
+
+// RepositoryModel is an entry of the model repository index of triton.
+type RepositoryModel struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	State   string `json:"state"`
+}
+
+func (engine *tritonEngine) GetPodModels(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint) ([]string, error) {
+	url := metrics.EndpointURL(pod, endpoint, engine.HTTPPort, "/v2/repository/index")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBufferString(`{"ready": true}`))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+
+	var index []RepositoryModel
+	err = json.Unmarshal(body, &index)
+	if err != nil {
+		return nil, err
+	}
+
+	// A model is listed once per loaded version
+	seen := make(map[string]bool, len(index))
+	models := make([]string, 0, len(index))
+	for _, model := range index {
+		if model.State != "READY" || seen[model.Name] {
+			continue
+		}
+		seen[model.Name] = true
+		models = append(models, model.Name)
+	}
+	return models, nil
+}
@@ -0,0 +1,43 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package triton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/metrics"
+)
+
+// ProbePod probes the pod with the generate extension of triton, which has no tokenize endpoint.
+func (engine *tritonEngine) ProbePod(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint, probe v1alpha1.HealthCheckProbe, model string) error {
+	if probe == v1alpha1.ProbeTokenize {
+		return errors.New("triton does not support the tokenize probe")
+	}
+	if model == "" {
+		return errors.New("no model to probe")
+	}
+	url := metrics.EndpointURL(pod, endpoint, engine.HTTPPort, fmt.Sprintf("/v2/models/%s/generate", model))
+	return metrics.PostJSON(ctx, url, map[string]any{
+		"text_input": metrics.ProbePrompt,
+		"parameters": map[string]any{"max_tokens": 1},
+	})
+}
@@ -17,11 +17,12 @@ limitations under the License.
 package vllm
 
 import (
-	"fmt"
+	"context"
 
 	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/metrics"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
 )
@@ -68,9 +69,9 @@ func NewVllmEngine() *vllmEngine {
 	}
 }
 
-func (engine *vllmEngine) GetPodMetrics(pod *corev1.Pod) (map[string]*dto.MetricFamily, error) {
-	url := fmt.Sprintf("http://%s:%d/metrics", pod.Status.PodIP, engine.MetricPort)
-	allMetrics, err := metrics.ParseMetricsURL(url)
+func (engine *vllmEngine) GetPodMetrics(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint) (map[string]*dto.MetricFamily, error) {
+	url := metrics.EndpointURL(pod, endpoint, engine.MetricPort, "/metrics")
+	allMetrics, err := metrics.ParseMetricsURL(ctx, url)
 	if err != nil {
 		return nil, err
 	}
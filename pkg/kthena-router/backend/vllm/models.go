@@ -17,44 +17,15 @@ limitations under the License.
 package vllm
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
+	"context"
 
 	corev1 "k8s.io/api/core/v1"
-)
-
-type Model struct {
-	ID string `json:"id"`
-}
 
-type ModelList struct {
-	Data []Model `json:"data"`
-}
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/backend/metrics"
+)
 
-func (engine *vllmEngine) GetPodModels(pod *corev1.Pod) ([]string, error) {
-	url := fmt.Sprintf("http://%s:%d/v1/models", pod.Status.PodIP, engine.MetricPort)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var modelList ModelList
-	err = json.Unmarshal(body, &modelList)
-	if err != nil {
-		return nil, err
-	}
-
-	models := make([]string, 0, len(modelList.Data))
-	for _, model := range modelList.Data {
-		models = append(models, model.ID)
-	}
-	return models, nil
+func (engine *vllmEngine) GetPodModels(ctx context.Context, pod *corev1.Pod, endpoint *v1alpha1.PodEndpoint) ([]string, error) {
+	url := metrics.EndpointURL(pod, endpoint, engine.MetricPort, "/v1/models")
+	return metrics.ParseModelsURL(ctx, url)
 }
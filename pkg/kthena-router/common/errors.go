@@ -0,0 +1,51 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxUpstreamErrorBodySize bounds how much of an upstream error response is kept in memory.
+const maxUpstreamErrorBodySize = 64 * 1024
+
+// UpstreamStatusError is returned when an inference pod answers with a non-2xx status code.
+type UpstreamStatusError struct {
+	// Op describes the upstream operation, e.g. "prefill request".
+	Op         string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// NewUpstreamStatusError builds an UpstreamStatusError from resp and consumes a bounded part of its body.
+// The caller is still responsible for closing resp.Body.
+func NewUpstreamStatusError(op string, resp *http.Response) *UpstreamStatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxUpstreamErrorBodySize))
+	return &UpstreamStatusError{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+	}
+}
+
+func (e *UpstreamStatusError) Error() string {
+	return fmt.Sprintf("%s failed with status %d", e.Op, e.StatusCode)
+}
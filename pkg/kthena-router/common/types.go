@@ -19,12 +19,47 @@ package common
 const (
 	UserIdKey     = "user_id"
 	TokenUsageKey = "token_usage"
+	// RateLimitKey is the key of the request in the rate limit of its model
+	RateLimitKey = "rate_limit_key"
+	// OutputTokensKey is the number of output tokens generated for the request, charged to its rate limit once it completes
+	OutputTokensKey = "output_tokens"
+	// CachedTokensKey is the number of input tokens of the request served from the prefix cache of the inference engine
+	CachedTokensKey = "cached_tokens"
+	// ModelServerKey is the namespaced name of the ModelServer which served the request
+	ModelServerKey = "model_server"
+	// TenantKey is the tenant of the authenticated user, when its credentials tell it
+	TenantKey = "tenant"
+)
+
+// ResponsesPath is the path of the OpenAI Responses API.
+const ResponsesPath = "/v1/responses"
+
+// Content part types of multimodal chat messages.
+const (
+	ContentPartText       = "text"
+	ContentPartImageURL   = "image_url"
+	ContentPartInputAudio = "input_audio"
+	ContentPartAudioURL   = "audio_url"
+	ContentPartVideoURL   = "video_url"
 )
 
 // Message represents a single message in a chat conversation
 type Message struct {
-	Role    string `json:"role"`
+	Role string `json:"role"`
+	// Content is the text of the message. For multimodal messages, it is the concatenation of the text parts,
+	// so that the message can be hashed and tokenized like a plain text message.
 	Content string `json:"content"`
+
+	// Parts is set when the content is an array of content parts, e.g. text, image_url or input_audio.
+	// It is not serialized since tokenizers only accept the text content.
+	Parts []ContentPart `json:"-"`
+}
+
+// ContentPart represents one part of a multimodal chat message content
+type ContentPart struct {
+	Type string `json:"type"`
+	// Text is only set for text parts
+	Text string `json:"text,omitempty"`
 }
 
 // ChatMessage represents either a direct text prompt or structured chat messages
@@ -104,7 +104,7 @@ func (h *HTTPConnector) Proxy(c *gin.Context, reqBody map[string]interface{}, pr
 	}
 
 	if err != nil {
-		return 0, err
+		return 0, &PrefillError{Err: err}
 	}
 
 	result, decodeErr := h.decode(c, h.decodeRequest, decodeAddr)
@@ -29,3 +29,17 @@ type KVConnector interface {
 	// Returns the number of output tokens consumed, or error if the operation fails
 	Proxy(c *gin.Context, reqBody map[string]interface{}, prefillAddr, decodeAddr string) (int, error)
 }
+
+// PrefillError is returned by Proxy when the prefill request fails, to tell the failures of the prefill pod
+// apart from the ones of the decode pod
+type PrefillError struct {
+	Err error
+}
+
+func (e *PrefillError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PrefillError) Unwrap() error {
+	return e.Err
+}
@@ -17,9 +17,7 @@ limitations under the License.
 package connectors
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
 	"maps"
 	"net/http"
@@ -28,6 +26,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"k8s.io/klog/v2"
 
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
 )
 
@@ -94,7 +93,7 @@ func (n *NIXLConnector) Proxy(c *gin.Context, reqBody map[string]interface{}, pr
 	}
 
 	if err != nil {
-		return 0, err
+		return 0, &PrefillError{Err: err}
 	}
 
 	// 2. send decode request
@@ -121,13 +120,16 @@ func (n *NIXLConnector) prefill(req *http.Request, prefillAddr string) (interfac
 	klog.V(4).Infof("%s prefill: sending to %s", n.name, req.URL.String())
 
 	// Send prefill request
+	if err := ResetRequestBody(req); err != nil {
+		return nil, err
+	}
 	resp, err := http.DefaultTransport.RoundTrip(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("prefill request failed with status %d", resp.StatusCode)
+		return nil, common.NewUpstreamStatusError("prefill request", resp)
 	}
 
 	// Parse prefill response
@@ -157,8 +159,7 @@ func (n *NIXLConnector) buildDecodeRequest(c *gin.Context, reqBody map[string]in
 	// build request
 	reqCopy := c.Request.Clone(c.Request.Context())
 	reqCopy.URL.Scheme = "http"
-	reqCopy.Body = io.NopCloser(bytes.NewBuffer(body))
-	reqCopy.ContentLength = int64(len(body))
+	SetRequestBody(reqCopy, body)
 
 	return reqCopy
 }
@@ -200,8 +201,7 @@ func (n *NIXLConnector) buildPrefillRequest(req *http.Request, reqBody map[strin
 
 	prefillReq := req.Clone(req.Context())
 	prefillReq.URL.Scheme = "http"
-	prefillReq.Body = io.NopCloser(bytes.NewBuffer(body))
-	prefillReq.ContentLength = int64(len(body))
+	SetRequestBody(prefillReq, body)
 
 	return prefillReq
 }
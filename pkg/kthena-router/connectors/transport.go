@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
@@ -31,6 +32,9 @@ import (
 )
 
 func prefillerProxy(_ *gin.Context, req *http.Request) error {
+	if err := ResetRequestBody(req); err != nil {
+		return fmt.Errorf("prefill request failed: %w", err)
+	}
 	resp, err := http.DefaultTransport.RoundTrip(req)
 	if err != nil {
 		return fmt.Errorf("prefill request failed: %w", err)
@@ -38,7 +42,7 @@ func prefillerProxy(_ *gin.Context, req *http.Request) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("prefill request failed with status %d", resp.StatusCode)
+		return common.NewUpstreamStatusError("prefill request", resp)
 	}
 
 	klog.V(4).Infof("Prefill request completed successfully")
@@ -46,6 +50,9 @@ func prefillerProxy(_ *gin.Context, req *http.Request) error {
 }
 
 func decoderProxy(c *gin.Context, req *http.Request) (int, error) {
+	if err := ResetRequestBody(req); err != nil {
+		return 0, fmt.Errorf("decode request failed: %w", err)
+	}
 	resp, err := http.DefaultTransport.RoundTrip(req)
 	if err != nil {
 		return 0, fmt.Errorf("decode request failed: %w", err)
@@ -53,7 +60,7 @@ func decoderProxy(c *gin.Context, req *http.Request) (int, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return 0, fmt.Errorf("decode request failed with status %d", resp.StatusCode)
+		return 0, common.NewUpstreamStatusError("decode request", resp)
 	}
 
 	// Copy response headers
@@ -101,14 +108,16 @@ func buildPrefillRequest(req *http.Request, modelRequest map[string]interface{})
 	// build request
 	reqCopy := req.Clone(req.Context())
 	reqCopy.URL.Scheme = "http"
-	reqCopy.Body = io.NopCloser(bytes.NewBuffer(body))
-	reqCopy.ContentLength = int64(len(body))
+	SetRequestBody(reqCopy, body)
 
 	return reqCopy
 }
 
 func BuildDecodeRequest(c *gin.Context, req *http.Request, modelRequest map[string]interface{}) *http.Request {
-	modelRequest = addTokenUsage(c, modelRequest)
+	// The Responses API always reports the usage and has no stream_options
+	if strings.TrimSuffix(req.URL.Path, "/") != common.ResponsesPath {
+		modelRequest = addTokenUsage(c, modelRequest)
+	}
 	body, err := json.Marshal(modelRequest)
 	if err != nil {
 		return nil
@@ -116,12 +125,34 @@ func BuildDecodeRequest(c *gin.Context, req *http.Request, modelRequest map[stri
 
 	// build request
 	req.URL.Scheme = "http"
-	req.Body = io.NopCloser(bytes.NewBuffer(body))
-	req.ContentLength = int64(len(body))
+	SetRequestBody(req, body)
 
 	return req
 }
 
+// SetRequestBody sets a replayable body on req, so that the same request can be resent
+// to another pod when the previous attempt failed.
+func SetRequestBody(req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+}
+
+// ResetRequestBody rewinds the body of a request built by this package before it is sent again.
+func ResetRequestBody(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
 // addTokenUsage adds token usage to the request body if it is not already present
 // should be used for decode requests or non PD disaggregated mode
 func addTokenUsage(c *gin.Context, reqBody map[string]interface{}) map[string]interface{} {
@@ -0,0 +1,160 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/auth"
+)
+
+// APIKeyController keeps the API keys of the authenticator up to date with the API key Secrets.
+// The informer factory must only list the Secrets labeled with auth.APIKeyLabel.
+type APIKeyController struct {
+	secretLister listerv1.SecretLister
+	registration cache.ResourceEventHandlerRegistration
+
+	workqueue     workqueue.TypedRateLimitingInterface[any]
+	initialSync   *atomic.Bool
+	authenticator *auth.APIKeyAuthenticator
+}
+
+func NewAPIKeyController(
+	apiKeyInformerFactory informers.SharedInformerFactory,
+	authenticator *auth.APIKeyAuthenticator,
+) *APIKeyController {
+	secretInformer := apiKeyInformerFactory.Core().V1().Secrets()
+
+	controller := &APIKeyController{
+		secretLister:  secretInformer.Lister(),
+		workqueue:     workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[any]()),
+		initialSync:   &atomic.Bool{},
+		authenticator: authenticator,
+	}
+
+	controller.registration, _ = secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueSecret,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueSecret(new)
+		},
+		DeleteFunc: controller.enqueueSecret,
+	})
+
+	return controller
+}
+
+func (c *APIKeyController) Run(stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	if ok := cache.WaitForCacheSync(stopCh, c.registration.HasSynced); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+	// add initialSync signal
+	c.workqueue.Add(initialSyncSignal)
+
+	go wait.Until(c.runWorker, time.Second, stopCh)
+
+	<-stopCh
+	return nil
+}
+
+func (c *APIKeyController) HasSynced() bool {
+	return c.initialSync.Load()
+}
+
+func (c *APIKeyController) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *APIKeyController) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.workqueue.Done(obj)
+
+	if obj == initialSyncSignal {
+		klog.V(2).Info("initial API key secrets have been synced")
+		c.workqueue.Forget(obj)
+		c.initialSync.Store(true)
+		return true
+	}
+
+	var key string
+	var ok bool
+	if key, ok = obj.(string); !ok {
+		c.workqueue.Forget(obj)
+		utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+		return true
+	}
+
+	if err := c.syncHandler(key); err != nil {
+		if c.workqueue.NumRequeues(key) < maxRetries {
+			klog.V(2).Infof("error syncing API key secret %q: %s, requeuing", key, err.Error())
+			c.workqueue.AddRateLimited(key)
+			return true
+		}
+		klog.V(2).Infof("giving up on syncing API key secret %q after %d retries: %s", key, maxRetries, err)
+		c.workqueue.Forget(obj)
+	}
+	return true
+}
+
+func (c *APIKeyController) syncHandler(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	secret, err := c.secretLister.Secrets(namespace).Get(name)
+	if errors.IsNotFound(err) {
+		c.authenticator.DeleteSecret(key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// An invalid secret won't become valid by retrying, until it is updated
+	if err := c.authenticator.AddOrUpdateSecret(key, secret); err != nil {
+		klog.Errorf("failed to load API key: %v", err)
+	}
+	return nil
+}
+
+func (c *APIKeyController) enqueueSecret(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
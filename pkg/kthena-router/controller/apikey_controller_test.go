@@ -0,0 +1,89 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/auth"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+)
+
+func TestAPIKeyController(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	apiKeyInformerFactory := informers.NewSharedInformerFactory(kubeClient, 0)
+	authenticator := auth.NewAPIKeyAuthenticator(&conf.RouterConfiguration{
+		Auth: conf.AuthenticationConfig{APIKey: conf.APIKeyConfig{Enabled: true}},
+	})
+	controller := NewAPIKeyController(apiKeyInformerFactory, authenticator)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	apiKeyInformerFactory.Start(stop)
+	go func() {
+		assert.NoError(t, controller.Run(stop))
+	}()
+	assert.Eventually(t, controller.HasSynced, 5*time.Second, 10*time.Millisecond)
+
+	authenticate := authenticator.Authenticate(&auth.JWTAuthenticator{})
+	statusOf := func(apiKey string) int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+apiKey)
+		authenticate(c)
+		return w.Code
+	}
+
+	digest := sha256.Sum256([]byte("sk-batch"))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "batch",
+			Namespace: "default",
+			Labels:    map[string]string{auth.APIKeyLabel: "true"},
+		},
+		Data: map[string][]byte{
+			"keyHash": []byte(hex.EncodeToString(digest[:])),
+			"owner":   []byte("batch-job"),
+		},
+	}
+	_, err := kubeClient.CoreV1().Secrets("default").Create(context.Background(), secret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return statusOf("sk-batch") == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The key is revoked when its Secret is deleted
+	err = kubeClient.CoreV1().Secrets("default").Delete(context.Background(), "batch", metav1.DeleteOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return statusOf("sk-batch") == http.StatusUnauthorized
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -931,14 +931,14 @@ func waitForObjectInCache(t *testing.T, timeout time.Duration, checkFunc func()
 // Helper function to setup mock for backend calls
 func setupMockBackend() *gomonkey.Patches {
 	patch := gomonkey.NewPatches()
-	patch.ApplyFunc(backend.GetPodMetrics, func(backend string, pod *corev1.Pod, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram) {
+	patch.ApplyFunc(backend.GetPodMetrics, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram, error) {
 		return map[string]float64{
 			utils.GPUCacheUsage:     0.5,
 			utils.RequestWaitingNum: 10,
 			utils.RequestRunningNum: 5,
-		}, map[string]*dto.Histogram{}
+		}, map[string]*dto.Histogram{}, nil
 	})
-	patch.ApplyFunc(backend.GetPodModels, func(backend string, pod *corev1.Pod) ([]string, error) {
+	patch.ApplyFunc(backend.GetPodModels, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint) ([]string, error) {
 		return []string{"test-model"}, nil
 	})
 	return patch
@@ -0,0 +1,263 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+)
+
+// Defaults of OutlierDetection, they match the defaults in the ModelServer CRD.
+const (
+	defaultConsecutiveFailures = 5
+	defaultMinimumRequests     = 10
+	defaultOutlierInterval     = 10 * time.Second
+	defaultBaseEjectionTime    = 30 * time.Second
+	defaultMaxEjectionTime     = 300 * time.Second
+	defaultMaxEjectionPercent  = 50
+)
+
+// outlierConfig is the outlier detection of a ModelServer with the defaults applied.
+type outlierConfig struct {
+	consecutiveFailures int
+	failurePercentage   int
+	minimumRequests     int
+	interval            time.Duration
+	baseEjectionTime    time.Duration
+	maxEjectionTime     time.Duration
+	maxEjectionPercent  int
+}
+
+func newOutlierConfig(od *aiv1alpha1.OutlierDetection) outlierConfig {
+	config := outlierConfig{
+		consecutiveFailures: defaultConsecutiveFailures,
+		minimumRequests:     defaultMinimumRequests,
+		interval:            defaultOutlierInterval,
+		baseEjectionTime:    defaultBaseEjectionTime,
+		maxEjectionTime:     defaultMaxEjectionTime,
+		maxEjectionPercent:  defaultMaxEjectionPercent,
+	}
+	if od.ConsecutiveFailures != nil {
+		config.consecutiveFailures = int(*od.ConsecutiveFailures)
+	}
+	if od.FailurePercentage != nil {
+		config.failurePercentage = int(*od.FailurePercentage)
+	}
+	if od.MinimumRequests != nil {
+		config.minimumRequests = int(*od.MinimumRequests)
+	}
+	if od.Interval != nil && od.Interval.Duration > 0 {
+		config.interval = od.Interval.Duration
+	}
+	if od.BaseEjectionTime != nil && od.BaseEjectionTime.Duration > 0 {
+		config.baseEjectionTime = od.BaseEjectionTime.Duration
+	}
+	if od.MaxEjectionTime != nil && od.MaxEjectionTime.Duration > 0 {
+		config.maxEjectionTime = od.MaxEjectionTime.Duration
+	}
+	config.maxEjectionTime = max(config.maxEjectionTime, config.baseEjectionTime)
+	if od.MaxEjectionPercent != nil {
+		config.maxEjectionPercent = int(*od.MaxEjectionPercent)
+	}
+	return config
+}
+
+// PodHealthStatus is a snapshot of the passive health tracking of a pod.
+type PodHealthStatus struct {
+	// ConsecutiveFailures is the number of requests failed in a row.
+	ConsecutiveFailures int
+	// Ejections is the number of times in a row the pod has been ejected, the ejection time doubles with each of them.
+	Ejections int
+	// EjectedUntil is when the current or last ejection of the pod ends, it is zero if the pod has never been ejected.
+	EjectedUntil time.Time
+}
+
+// podHealth tracks the results of the requests to a pod. Like the in-flight requests,
+// it is shared by all the PodInfo objects of the same pod across pod updates.
+type podHealth struct {
+	mutex sync.Mutex
+
+	consecutiveFailures int
+	// The requests and failures since windowStart, for the failure percentage.
+	windowStart    time.Time
+	windowRequests int
+	windowFailures int
+
+	ejections    int
+	ejectedUntil time.Time
+}
+
+// record records the result of a request and returns whether the pod should be ejected.
+func (h *podHealth) record(success bool, now time.Time, config outlierConfig) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// Requests dispatched before the pod was ejected may still complete
+	if now.Before(h.ejectedUntil) {
+		return false
+	}
+
+	if now.Sub(h.windowStart) >= config.interval {
+		h.windowStart = now
+		h.windowRequests = 0
+		h.windowFailures = 0
+	}
+	h.windowRequests++
+	if success {
+		h.consecutiveFailures = 0
+		return false
+	}
+	h.windowFailures++
+	h.consecutiveFailures++
+
+	if config.consecutiveFailures > 0 && h.consecutiveFailures >= config.consecutiveFailures {
+		return true
+	}
+	return config.failurePercentage > 0 && h.windowRequests >= config.minimumRequests &&
+		h.windowFailures*100 >= config.failurePercentage*h.windowRequests
+}
+
+// eject ejects the pod, with an exponential back-off if it fails again soon after its last ejection.
+func (h *podHealth) eject(now time.Time, config outlierConfig) time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.ejections > 0 && now.Sub(h.ejectedUntil) < config.maxEjectionTime {
+		h.ejections++
+	} else {
+		h.ejections = 1
+	}
+	ejectionTime := config.maxEjectionTime
+	// Avoid overflowing the shift, the ejection time is capped anyway
+	if h.ejections < 32 {
+		ejectionTime = min(config.baseEjectionTime<<(h.ejections-1), config.maxEjectionTime)
+	}
+	h.ejectedUntil = now.Add(ejectionTime)
+	h.consecutiveFailures = 0
+	h.windowStart = h.ejectedUntil
+	h.windowRequests = 0
+	h.windowFailures = 0
+	return ejectionTime
+}
+
+func (h *podHealth) isEjected(now time.Time) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return now.Before(h.ejectedUntil)
+}
+
+func (h *podHealth) status() PodHealthStatus {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return PodHealthStatus{
+		ConsecutiveFailures: h.consecutiveFailures,
+		Ejections:           h.ejections,
+		EjectedUntil:        h.ejectedUntil,
+	}
+}
+
+// ReportRequestResult records the result of a request to a pod of the ModelServer. With the outlier detection
+// of the ModelServer, the pod is ejected once it fails too many requests, unless too many pods are ejected already
+// or it is the last pod able to serve requests.
+func (s *store) ReportRequestResult(modelServerName types.NamespacedName, pod *PodInfo, success bool) {
+	if pod.health == nil {
+		return
+	}
+	ms := s.GetModelServer(modelServerName)
+	if ms == nil || ms.Spec.TrafficPolicy == nil || ms.Spec.TrafficPolicy.OutlierDetection == nil {
+		return
+	}
+	config := newOutlierConfig(ms.Spec.TrafficPolicy.OutlierDetection)
+	now := time.Now()
+	if !pod.health.record(success, now, config) {
+		return
+	}
+
+	// Serialize the ejections so that concurrent ones cannot exceed the max ejection percentage
+	s.ejectionMutex.Lock()
+	defer s.ejectionMutex.Unlock()
+	pods, err := s.GetPodsByModelServer(modelServerName)
+	if err != nil {
+		return
+	}
+	ejected := 0
+	for _, p := range pods {
+		if p.IsEjected() {
+			ejected++
+		}
+	}
+	// Like Envoy, one pod can always be ejected, otherwise the pods of small ModelServers could never be ejected
+	// because of the rounding, e.g. one of two pods at 10%. A max ejection percentage of 0 disables ejections.
+	if config.maxEjectionPercent == 0 || ejected > 0 && (ejected+1)*100 > len(pods)*config.maxEjectionPercent {
+		klog.V(2).Infof("pod %s/%s of model server %s is not ejected: %d of %d pods are ejected already",
+			pod.Pod.Namespace, pod.Pod.Name, modelServerName, ejected, len(pods))
+		return
+	}
+	// Like the panic threshold of Envoy, the last pod is kept even if it is failing, since no request could be
+	// scheduled without it, e.g. the only pod of the ModelServer.
+	if !s.hasRoutablePeer(ms, pod, pods) {
+		klog.V(2).Infof("pod %s/%s of model server %s is not ejected: it is the last pod able to serve requests",
+			pod.Pod.Namespace, pod.Pod.Name, modelServerName)
+		return
+	}
+	ejectionTime := pod.health.eject(now, config)
+	klog.Infof("ejected pod %s/%s of model server %s for %v", pod.Pod.Namespace, pod.Pod.Name, modelServerName, ejectionTime)
+	metrics.DefaultMetrics.RecordPodEjection(modelServerName.String())
+}
+
+// hasRoutablePeer returns whether another pod than pod can serve the requests pod serves, i.e. another pod of the
+// ModelServer which is not ejected. For PD disaggregated ModelServers, it must have the same role as pod.
+func (s *store) hasRoutablePeer(ms *aiv1alpha1.ModelServer, pod *PodInfo, pods []*PodInfo) bool {
+	if ms.Spec.WorkloadSelector != nil && ms.Spec.WorkloadSelector.PDGroup != nil {
+		modelServerName := types.NamespacedName{Namespace: ms.Namespace, Name: ms.Name}
+		decodePods, err := s.GetDecodePods(modelServerName)
+		if err != nil {
+			return false
+		}
+		if slices.Contains(decodePods, pod) {
+			pods = decodePods
+		} else if pods, err = s.GetPrefillPods(modelServerName); err != nil {
+			return false
+		}
+	}
+	return slices.ContainsFunc(pods, func(p *PodInfo) bool {
+		return p != pod && !p.IsEjected()
+	})
+}
+
+// IsEjected returns whether the pod is ejected from load balancing by the outlier detection.
+func (p *PodInfo) IsEjected() bool {
+	if p.health == nil {
+		return false
+	}
+	return p.health.isEjected(time.Now())
+}
+
+// GetHealthStatus returns the passive health tracking of the pod.
+func (p *PodInfo) GetHealthStatus() PodHealthStatus {
+	if p.health == nil {
+		return PodHealthStatus{}
+	}
+	return p.health.status()
+}
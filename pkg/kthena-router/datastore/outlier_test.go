@@ -0,0 +1,290 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+func TestNewOutlierConfig(t *testing.T) {
+	assert.Equal(t, outlierConfig{
+		consecutiveFailures: defaultConsecutiveFailures,
+		minimumRequests:     defaultMinimumRequests,
+		interval:            defaultOutlierInterval,
+		baseEjectionTime:    defaultBaseEjectionTime,
+		maxEjectionTime:     defaultMaxEjectionTime,
+		maxEjectionPercent:  defaultMaxEjectionPercent,
+	}, newOutlierConfig(&aiv1alpha1.OutlierDetection{}))
+
+	assert.Equal(t, outlierConfig{
+		consecutiveFailures: 0,
+		failurePercentage:   50,
+		minimumRequests:     4,
+		interval:            time.Minute,
+		baseEjectionTime:    time.Minute,
+		// The max ejection time is at least the base ejection time
+		maxEjectionTime:    time.Minute,
+		maxEjectionPercent: 100,
+	}, newOutlierConfig(&aiv1alpha1.OutlierDetection{
+		ConsecutiveFailures: ptr[int32](0),
+		FailurePercentage:   ptr[int32](50),
+		MinimumRequests:     ptr[int32](4),
+		Interval:            &metav1.Duration{Duration: time.Minute},
+		BaseEjectionTime:    &metav1.Duration{Duration: time.Minute},
+		MaxEjectionTime:     &metav1.Duration{Duration: time.Second},
+		MaxEjectionPercent:  ptr[int32](100),
+	}))
+}
+
+func TestPodHealthConsecutiveFailures(t *testing.T) {
+	config := outlierConfig{consecutiveFailures: 3, interval: time.Minute}
+	health := &podHealth{}
+	now := time.Now()
+
+	assert.False(t, health.record(false, now, config))
+	assert.False(t, health.record(false, now, config))
+	// A success resets the consecutive failures
+	assert.False(t, health.record(true, now, config))
+	assert.False(t, health.record(false, now, config))
+	assert.False(t, health.record(false, now, config))
+	assert.True(t, health.record(false, now, config))
+}
+
+func TestPodHealthFailurePercentage(t *testing.T) {
+	config := outlierConfig{failurePercentage: 50, minimumRequests: 4, interval: time.Minute}
+	health := &podHealth{}
+	now := time.Now()
+
+	assert.False(t, health.record(false, now, config))
+	assert.False(t, health.record(true, now, config))
+	// Not enough requests within the interval
+	assert.False(t, health.record(false, now, config))
+	assert.True(t, health.record(false, now, config))
+
+	// The failures of the previous interval are forgotten
+	health = &podHealth{}
+	assert.False(t, health.record(false, now, config))
+	assert.False(t, health.record(false, now, config))
+	later := now.Add(time.Minute)
+	assert.False(t, health.record(false, later, config))
+	assert.False(t, health.record(true, later, config))
+	assert.False(t, health.record(true, later, config))
+	assert.False(t, health.record(true, later, config))
+}
+
+func TestPodHealthEjectionBackoff(t *testing.T) {
+	config := outlierConfig{baseEjectionTime: 10 * time.Second, maxEjectionTime: 30 * time.Second}
+	health := &podHealth{}
+	now := time.Now()
+
+	assert.Equal(t, 10*time.Second, health.eject(now, config))
+	assert.True(t, health.isEjected(now.Add(5*time.Second)))
+	// Results of requests dispatched before the ejection are ignored
+	assert.False(t, health.record(false, now.Add(5*time.Second), outlierConfig{consecutiveFailures: 1}))
+	assert.False(t, health.isEjected(now.Add(10*time.Second)))
+
+	// Ejected again soon after it was brought back
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, 20*time.Second, health.eject(now, config))
+	now = now.Add(20 * time.Second)
+	assert.Equal(t, 30*time.Second, health.eject(now, config))
+	assert.Equal(t, 3, health.status().Ejections)
+
+	// The back-off is reset once the pod stays healthy for the max ejection time
+	now = now.Add(time.Minute + 30*time.Second)
+	assert.Equal(t, 10*time.Second, health.eject(now, config))
+	assert.Equal(t, 1, health.status().Ejections)
+}
+
+func TestStoreReportRequestResult(t *testing.T) {
+	s := New().(*store)
+	ms := createTestModelServer("default", "ms", "")
+	ms.Spec.TrafficPolicy = &aiv1alpha1.TrafficPolicy{
+		OutlierDetection: &aiv1alpha1.OutlierDetection{
+			ConsecutiveFailures: ptr[int32](2),
+			MaxEjectionPercent:  ptr[int32](50),
+		},
+	}
+	assert.NoError(t, s.AddOrUpdateModelServer(ms, nil))
+	var pods []*PodInfo
+	for i := 0; i < 4; i++ {
+		pod := createTestPod("default", fmt.Sprintf("pod%d", i))
+		assert.NoError(t, s.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+		pods = append(pods, s.GetPodInfo(utils.GetNamespaceName(pod)))
+	}
+	msName := utils.GetNamespaceName(ms)
+
+	for _, pod := range pods {
+		s.ReportRequestResult(msName, pod, false)
+		s.ReportRequestResult(msName, pod, false)
+	}
+	// At most half of the pods are ejected
+	assert.True(t, pods[0].IsEjected())
+	assert.True(t, pods[1].IsEjected())
+	assert.False(t, pods[2].IsEjected())
+	assert.False(t, pods[3].IsEjected())
+	assert.Equal(t, 1, pods[0].GetHealthStatus().Ejections)
+
+	// The ejection is kept when the pod is updated
+	assert.NoError(t, s.AddOrUpdatePod(pods[0].Pod, []*aiv1alpha1.ModelServer{ms}))
+	assert.True(t, s.GetPodInfo(utils.GetNamespaceName(pods[0].Pod)).IsEjected())
+
+	// Without outlier detection, pods are never ejected
+	ms.Spec.TrafficPolicy = nil
+	assert.NoError(t, s.AddOrUpdateModelServer(ms, nil))
+	for i := 0; i < 10; i++ {
+		s.ReportRequestResult(msName, pods[3], false)
+	}
+	assert.False(t, pods[3].IsEjected())
+}
+
+func TestStoreReportRequestResultMaxEjectionPercent(t *testing.T) {
+	tests := []struct {
+		name               string
+		pods               int
+		maxEjectionPercent int32
+		expectedEjected    int
+	}{
+		{
+			name:               "the only pod is not ejected",
+			pods:               1,
+			maxEjectionPercent: 100,
+			expectedEjected:    0,
+		},
+		{
+			name:               "one of two pods is ejected",
+			pods:               2,
+			maxEjectionPercent: 10,
+			expectedEjected:    1,
+		},
+		{
+			name:               "the percentage is rounded down after the first ejection",
+			pods:               3,
+			maxEjectionPercent: 50,
+			expectedEjected:    1,
+		},
+		{
+			name:               "all pods but the last one are ejected at 100%",
+			pods:               3,
+			maxEjectionPercent: 100,
+			expectedEjected:    2,
+		},
+		{
+			name:               "no pod is ejected at 0%",
+			pods:               1,
+			maxEjectionPercent: 0,
+			expectedEjected:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New().(*store)
+			ms := createTestModelServer("default", "ms", "")
+			ms.Spec.TrafficPolicy = &aiv1alpha1.TrafficPolicy{
+				OutlierDetection: &aiv1alpha1.OutlierDetection{
+					ConsecutiveFailures: ptr[int32](1),
+					MaxEjectionPercent:  ptr(tt.maxEjectionPercent),
+				},
+			}
+			assert.NoError(t, s.AddOrUpdateModelServer(ms, nil))
+			msName := utils.GetNamespaceName(ms)
+
+			var pods []*PodInfo
+			for i := 0; i < tt.pods; i++ {
+				pod := createTestPod("default", fmt.Sprintf("pod%d", i))
+				assert.NoError(t, s.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+				pods = append(pods, s.GetPodInfo(utils.GetNamespaceName(pod)))
+			}
+
+			ejected := 0
+			for _, pod := range pods {
+				s.ReportRequestResult(msName, pod, false)
+				if pod.IsEjected() {
+					ejected++
+				}
+			}
+			assert.Equal(t, tt.expectedEjected, ejected)
+		})
+	}
+}
+
+func TestStoreReportRequestResultSinglePod(t *testing.T) {
+	s := New().(*store)
+	ms := createTestModelServer("default", "ms", "")
+	ms.Spec.TrafficPolicy = &aiv1alpha1.TrafficPolicy{
+		OutlierDetection: &aiv1alpha1.OutlierDetection{
+			ConsecutiveFailures: ptr[int32](1),
+			MaxEjectionPercent:  ptr[int32](100),
+		},
+	}
+	assert.NoError(t, s.AddOrUpdateModelServer(ms, nil))
+	pod := createTestPod("default", "pod0")
+	assert.NoError(t, s.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+	podInfo := s.GetPodInfo(utils.GetNamespaceName(pod))
+
+	// The only pod keeps serving requests however many of them fail, since no request could be scheduled without it
+	for i := 0; i < 10; i++ {
+		s.ReportRequestResult(utils.GetNamespaceName(ms), podInfo, false)
+		assert.False(t, podInfo.IsEjected())
+	}
+}
+
+func TestStoreReportRequestResultPDGroup(t *testing.T) {
+	s := New().(*store)
+	ms := createTestModelServer("default", "ms", "")
+	ms.Spec.WorkloadSelector = &aiv1alpha1.WorkloadSelector{
+		PDGroup: &aiv1alpha1.PDGroup{
+			GroupKey:      "pd-group",
+			DecodeLabels:  map[string]string{"role": "decode"},
+			PrefillLabels: map[string]string{"role": "prefill"},
+		},
+	}
+	ms.Spec.TrafficPolicy = &aiv1alpha1.TrafficPolicy{
+		OutlierDetection: &aiv1alpha1.OutlierDetection{
+			ConsecutiveFailures: ptr[int32](1),
+			MaxEjectionPercent:  ptr[int32](100),
+		},
+	}
+	assert.NoError(t, s.AddOrUpdateModelServer(ms, nil))
+	msName := utils.GetNamespaceName(ms)
+
+	podInfo := func(name, role string) *PodInfo {
+		pod := createTestPod("default", name)
+		pod.Labels = map[string]string{"pd-group": "group-a", "role": role}
+		assert.NoError(t, s.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+		return s.GetPodInfo(utils.GetNamespaceName(pod))
+	}
+	decode1, decode2 := podInfo("decode-pod-1", "decode"), podInfo("decode-pod-2", "decode")
+	prefill := podInfo("prefill-pod-1", "prefill")
+
+	// The healthy pods of another role can't serve the requests of the last pod of a role
+	s.ReportRequestResult(msName, prefill, false)
+	assert.False(t, prefill.IsEjected())
+	s.ReportRequestResult(msName, decode1, false)
+	assert.True(t, decode1.IsEjected())
+	s.ReportRequestResult(msName, decode2, false)
+	assert.False(t, decode2.IsEjected())
+}
@@ -0,0 +1,292 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/backend"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+)
+
+// Defaults of HealthCheck, they match the defaults in the ModelServer CRD.
+const (
+	defaultProbeInterval      = 10 * time.Second
+	defaultProbeTimeout       = 5 * time.Second
+	defaultUnhealthyThreshold = 3
+	defaultHealthyThreshold   = 1
+)
+
+// healthCheckConfig is the health check of a ModelServer with the defaults applied.
+type healthCheckConfig struct {
+	probe              aiv1alpha1.HealthCheckProbe
+	endpoint           *aiv1alpha1.PodEndpoint
+	interval           time.Duration
+	timeout            time.Duration
+	unhealthyThreshold int
+	healthyThreshold   int
+}
+
+func newHealthCheckConfig(ms *aiv1alpha1.ModelServer) healthCheckConfig {
+	hc := ms.Spec.HealthCheck
+	config := healthCheckConfig{
+		probe: aiv1alpha1.ProbeCompletion,
+		// The probe is an inference request, so it is sent to the port serving them
+		endpoint: &aiv1alpha1.PodEndpoint{
+			Port:   ms.Spec.WorkloadPort.Port,
+			Scheme: ms.Spec.WorkloadPort.Protocol,
+			Path:   hc.Path,
+		},
+		interval:           defaultProbeInterval,
+		timeout:            defaultProbeTimeout,
+		unhealthyThreshold: defaultUnhealthyThreshold,
+		healthyThreshold:   defaultHealthyThreshold,
+	}
+	if hc.Probe != "" {
+		config.probe = hc.Probe
+	}
+	if hc.Interval != nil && hc.Interval.Duration > 0 {
+		config.interval = hc.Interval.Duration
+	}
+	if hc.Timeout != nil && hc.Timeout.Duration > 0 {
+		config.timeout = hc.Timeout.Duration
+	}
+	if hc.UnhealthyThreshold != nil && *hc.UnhealthyThreshold > 0 {
+		config.unhealthyThreshold = int(*hc.UnhealthyThreshold)
+	}
+	if hc.HealthyThreshold != nil && *hc.HealthyThreshold > 0 {
+		config.healthyThreshold = int(*hc.HealthyThreshold)
+	}
+	return config
+}
+
+// PodProbeStatus is a snapshot of the active health check of a pod.
+type PodProbeStatus struct {
+	// Unhealthy is whether the pod has failed enough probes in a row to be removed from load balancing.
+	Unhealthy bool
+	// LastProbe is when the pod was last probed, it is zero if the pod has never been.
+	LastProbe time.Time
+	// LastError is the error of the last probe if it failed.
+	LastError string
+}
+
+// podProbe tracks the active health check of a pod. Like the in-flight requests,
+// it is shared by all the PodInfo objects of the same pod across pod updates.
+type podProbe struct {
+	mutex sync.Mutex
+
+	probing   bool
+	nextProbe time.Time
+
+	consecutiveSuccesses int
+	consecutiveFailures  int
+	// A pod is healthy until it fails its first probes, so that new pods are routed to right away
+	unhealthy bool
+	lastProbe time.Time
+	lastError string
+}
+
+// start marks the pod as being probed if it is due at now and not being probed yet.
+// It returns whether the caller should probe the pod.
+func (p *podProbe) start(now time.Time) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.probing || now.Before(p.nextProbe) {
+		return false
+	}
+	p.probing = true
+	return true
+}
+
+// cancel unmarks a pod marked by start without probing it.
+func (p *podProbe) cancel() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.probing = false
+}
+
+// finish records the result of a probe started by start and schedules the next one.
+// It returns whether the pod has become healthy or unhealthy with this probe.
+func (p *podProbe) finish(err error, now time.Time, config healthCheckConfig) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.probing = false
+	p.nextProbe = now.Add(config.interval)
+	p.lastProbe = now
+
+	if err == nil {
+		p.lastError = ""
+		p.consecutiveFailures = 0
+		p.consecutiveSuccesses++
+		if p.unhealthy && p.consecutiveSuccesses >= config.healthyThreshold {
+			p.unhealthy = false
+			return true
+		}
+		return false
+	}
+
+	p.lastError = err.Error()
+	p.consecutiveSuccesses = 0
+	p.consecutiveFailures++
+	if !p.unhealthy && p.consecutiveFailures >= config.unhealthyThreshold {
+		p.unhealthy = true
+		return true
+	}
+	return false
+}
+
+// reset forgets the probes of the pod, once its ModelServer no longer has a health check.
+func (p *podProbe) reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.probing {
+		return
+	}
+	p.nextProbe = time.Time{}
+	p.consecutiveSuccesses = 0
+	p.consecutiveFailures = 0
+	p.unhealthy = false
+	p.lastProbe = time.Time{}
+	p.lastError = ""
+}
+
+func (p *podProbe) isUnhealthy() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.unhealthy
+}
+
+func (p *podProbe) status() PodProbeStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return PodProbeStatus{
+		Unhealthy: p.unhealthy,
+		LastProbe: p.lastProbe,
+		LastError: p.lastError,
+	}
+}
+
+// runProber probes the pods of the ModelServers with a health check until ctx is done.
+// The probes share the number of workers of the scraper, a pod is skipped until the next
+// dispatch when all of them are busy.
+func (s *store) runProber(ctx context.Context) {
+	workers := make(chan struct{}, s.scraperConfig.workers)
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.pods.Range(func(key, value any) bool {
+				pod, ok := value.(*PodInfo)
+				if !ok || pod.probe == nil {
+					return true
+				}
+				ms := s.getPodHealthCheck(pod)
+				if ms == nil {
+					pod.probe.reset()
+					return true
+				}
+				if !pod.probe.start(now) {
+					return true
+				}
+				select {
+				case workers <- struct{}{}:
+					go func() {
+						defer func() { <-workers }()
+						s.probePod(ctx, pod, ms)
+					}()
+				default:
+					pod.probe.cancel()
+				}
+				return true
+			})
+		}
+	}
+}
+
+// probePod probes a pod which has been marked by start with the health check of ms.
+func (s *store) probePod(ctx context.Context, pod *PodInfo, ms *aiv1alpha1.ModelServer) {
+	config := newHealthCheckConfig(ms)
+	ctx, cancel := context.WithTimeout(ctx, config.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := backend.ProbePod(ctx, pod.engine, pod.Pod, config.endpoint, config.probe, probeModel(ms, pod))
+	modelServerName := types.NamespacedName{Namespace: ms.Namespace, Name: ms.Name}
+	metrics.DefaultMetrics.RecordPodProbe(modelServerName.String(), time.Since(start), err)
+	if err != nil {
+		klog.V(4).Infof("probe of pod %s/%s failed: %v", pod.Pod.Namespace, pod.Pod.Name, err)
+	}
+
+	if pod.probe.finish(err, time.Now(), config) {
+		if err != nil {
+			klog.Infof("pod %s/%s of model server %s is unhealthy: %v", pod.Pod.Namespace, pod.Pod.Name, modelServerName, err)
+		} else {
+			klog.Infof("pod %s/%s of model server %s is healthy again", pod.Pod.Namespace, pod.Pod.Name, modelServerName)
+		}
+	}
+}
+
+// getPodHealthCheck returns the first ModelServer of the pod with a health check, or nil if there is none.
+// Like the inference engine, the health check should be the same in all the ModelServers a pod belongs to.
+func (s *store) getPodHealthCheck(pod *PodInfo) *aiv1alpha1.ModelServer {
+	for name := range pod.GetModelServers() {
+		if ms := s.GetModelServer(name); ms != nil && ms.Spec.HealthCheck != nil {
+			return ms
+		}
+	}
+	return nil
+}
+
+// probeModel returns the model to probe the pod with: the model of the ModelServer, or the first model served
+// by the pod if it is not set. It is empty if the pod has not been scraped yet, the engine then picks the model.
+func probeModel(ms *aiv1alpha1.ModelServer, pod *PodInfo) string {
+	if ms.Spec.Model != nil && *ms.Spec.Model != "" {
+		return *ms.Spec.Model
+	}
+	models := pod.GetModelsList()
+	if len(models) == 0 {
+		return ""
+	}
+	sort.Strings(models)
+	return models[0]
+}
+
+// IsUnhealthy returns whether the pod has failed the active health check of its ModelServer.
+func (p *PodInfo) IsUnhealthy() bool {
+	if p.probe == nil {
+		return false
+	}
+	return p.probe.isUnhealthy()
+}
+
+// GetProbeStatus returns the active health check of the pod.
+func (p *PodInfo) GetProbeStatus() PodProbeStatus {
+	if p.probe == nil {
+		return PodProbeStatus{}
+	}
+	return p.probe.status()
+}
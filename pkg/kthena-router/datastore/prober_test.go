@@ -0,0 +1,168 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+func TestNewHealthCheckConfig(t *testing.T) {
+	ms := createTestModelServer("default", "ms", aiv1alpha1.VLLM)
+	ms.Spec.WorkloadPort = aiv1alpha1.WorkloadPort{Port: 8080, Protocol: "http"}
+	ms.Spec.HealthCheck = &aiv1alpha1.HealthCheck{}
+	assert.Equal(t, healthCheckConfig{
+		probe:              aiv1alpha1.ProbeCompletion,
+		endpoint:           &aiv1alpha1.PodEndpoint{Port: 8080, Scheme: "http"},
+		interval:           defaultProbeInterval,
+		timeout:            defaultProbeTimeout,
+		unhealthyThreshold: defaultUnhealthyThreshold,
+		healthyThreshold:   defaultHealthyThreshold,
+	}, newHealthCheckConfig(ms))
+
+	ms.Spec.HealthCheck = &aiv1alpha1.HealthCheck{
+		Probe:              aiv1alpha1.ProbeTokenize,
+		Path:               "/v1/tokenize",
+		Interval:           &metav1.Duration{Duration: time.Minute},
+		Timeout:            &metav1.Duration{Duration: time.Second},
+		UnhealthyThreshold: ptr[int32](1),
+		HealthyThreshold:   ptr[int32](2),
+	}
+	assert.Equal(t, healthCheckConfig{
+		probe:              aiv1alpha1.ProbeTokenize,
+		endpoint:           &aiv1alpha1.PodEndpoint{Port: 8080, Scheme: "http", Path: "/v1/tokenize"},
+		interval:           time.Minute,
+		timeout:            time.Second,
+		unhealthyThreshold: 1,
+		healthyThreshold:   2,
+	}, newHealthCheckConfig(ms))
+}
+
+func TestPodProbeThresholds(t *testing.T) {
+	config := healthCheckConfig{interval: time.Second, unhealthyThreshold: 2, healthyThreshold: 2}
+	probe := &podProbe{}
+	now := time.Now()
+	failure := errors.New("timeout")
+
+	assert.True(t, probe.start(now))
+	// A pod is probed once at a time
+	assert.False(t, probe.start(now))
+	assert.False(t, probe.finish(failure, now, config))
+	assert.False(t, probe.isUnhealthy())
+	// The next probe is due after the interval
+	assert.False(t, probe.start(now.Add(time.Second/2)))
+	assert.True(t, probe.start(now.Add(time.Second)))
+	assert.True(t, probe.finish(failure, now.Add(time.Second), config))
+	assert.True(t, probe.isUnhealthy())
+	assert.Equal(t, "timeout", probe.status().LastError)
+
+	// A success resets the failures, the pod is healthy again after two of them in a row
+	assert.False(t, probe.finish(nil, now, config))
+	assert.False(t, probe.finish(failure, now, config))
+	assert.False(t, probe.finish(nil, now, config))
+	assert.True(t, probe.isUnhealthy())
+	assert.True(t, probe.finish(nil, now, config))
+	assert.False(t, probe.isUnhealthy())
+	assert.Empty(t, probe.status().LastError)
+
+	// Once the health check is removed, the pod is healthy
+	probe.finish(failure, now, config)
+	probe.finish(failure, now, config)
+	assert.True(t, probe.isUnhealthy())
+	probe.reset()
+	assert.Equal(t, PodProbeStatus{}, probe.status())
+}
+
+func TestStoreProbePod(t *testing.T) {
+	patch := setupMockBackend()
+	defer patch.Reset()
+
+	var healthy atomic.Bool
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/completions", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	host, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	assert.NoError(t, err)
+
+	s := New().(*store)
+	ms := createTestModelServer("default", "ms", aiv1alpha1.VLLM)
+	ms.Spec.Model = ptr("base-model")
+	ms.Spec.WorkloadPort = aiv1alpha1.WorkloadPort{Port: int32(port)}
+	ms.Spec.HealthCheck = &aiv1alpha1.HealthCheck{UnhealthyThreshold: ptr[int32](2)}
+	assert.NoError(t, s.AddOrUpdateModelServer(ms, nil))
+	pod := createTestPod("default", "pod1")
+	pod.Status.PodIP = host
+	assert.NoError(t, s.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+	podInfo := s.GetPodInfo(utils.GetNamespaceName(pod))
+	msName := utils.GetNamespaceName(ms)
+
+	// The pod is unhealthy once it fails enough probes, but it is still a pod of the model server
+	s.probePod(context.Background(), podInfo, ms)
+	assert.False(t, podInfo.IsUnhealthy())
+	s.probePod(context.Background(), podInfo, ms)
+	assert.True(t, podInfo.IsUnhealthy())
+	pods, err := s.GetPodsByModelServer(msName)
+	assert.NoError(t, err)
+	assert.Len(t, pods, 1)
+	assert.Equal(t, map[string]any{"model": "base-model", "prompt": "Hi", "max_tokens": float64(1)}, body)
+
+	// The probe state is kept when the pod is updated
+	assert.NoError(t, s.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+	podInfo = s.GetPodInfo(utils.GetNamespaceName(pod))
+	assert.True(t, podInfo.IsUnhealthy())
+
+	// And the pod is back once it passes the probe
+	healthy.Store(true)
+	s.probePod(context.Background(), podInfo, ms)
+	assert.False(t, podInfo.IsUnhealthy())
+}
+
+func TestProbeModel(t *testing.T) {
+	ms := createTestModelServer("default", "ms", aiv1alpha1.VLLM)
+	podInfo := &PodInfo{models: nil}
+	assert.Empty(t, probeModel(ms, podInfo))
+
+	podInfo.UpdateModels([]string{"model-b", "model-a"})
+	assert.Equal(t, "model-a", probeModel(ms, podInfo))
+
+	ms.Spec.Model = ptr("base-model")
+	assert.Equal(t, "base-model", probeModel(ms, podInfo))
+}
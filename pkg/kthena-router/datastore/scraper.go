@@ -0,0 +1,174 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+)
+
+const (
+	defaultScrapeWorkers  = 16
+	defaultScrapeInterval = 1 * time.Second
+	defaultScrapeTimeout  = 1 * time.Second
+	// scrapeJitter is the fraction of the scrape interval the next scrape of a pod is randomly shifted by,
+	// so that the scrapes of all the pods are spread out instead of firing at the same time.
+	scrapeJitter = 0.2
+	// dispatchInterval is how often the pods due for a scrape are handed to the workers.
+	dispatchInterval = 100 * time.Millisecond
+)
+
+// scraperConfig holds the configuration of the pod metrics and models scraper.
+type scraperConfig struct {
+	// workers is the number of pods scraped concurrently.
+	workers int
+	// interval is the average interval between two scrapes of the same pod.
+	interval time.Duration
+	// timeout is the deadline of a single scrape of a pod, including both its metrics and models.
+	timeout time.Duration
+}
+
+// newScraperConfig creates a scraper config with configuration from environment variables
+func newScraperConfig() scraperConfig {
+	config := scraperConfig{
+		workers:  defaultScrapeWorkers,
+		interval: defaultScrapeInterval,
+		timeout:  defaultScrapeTimeout,
+	}
+
+	if workersStr := os.Getenv("METRICS_SCRAPE_WORKERS"); workersStr != "" {
+		if workers, err := strconv.Atoi(workersStr); err == nil && workers > 0 {
+			config.workers = workers
+		} else {
+			klog.Warningf("Invalid METRICS_SCRAPE_WORKERS: %q, using default", workersStr)
+		}
+	}
+
+	if intervalStr := os.Getenv("METRICS_SCRAPE_INTERVAL"); intervalStr != "" {
+		if interval, err := time.ParseDuration(intervalStr); err == nil && interval > 0 {
+			config.interval = interval
+		} else {
+			klog.Warningf("Invalid METRICS_SCRAPE_INTERVAL: %q, using default", intervalStr)
+		}
+	}
+
+	if timeoutStr := os.Getenv("METRICS_SCRAPE_TIMEOUT"); timeoutStr != "" {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil && timeout > 0 {
+			config.timeout = timeout
+		} else {
+			klog.Warningf("Invalid METRICS_SCRAPE_TIMEOUT: %q, using default", timeoutStr)
+		}
+	}
+
+	return config
+}
+
+// jitteredInterval returns the scrape interval randomly shifted by up to scrapeJitter of it.
+func (c scraperConfig) jitteredInterval() time.Duration {
+	jitter := (rand.Float64()*2 - 1) * scrapeJitter * float64(c.interval)
+	return c.interval + time.Duration(jitter)
+}
+
+// scrapeAllPods scrapes all the pods once in a pool of workers and waits for them to finish.
+func (s *store) scrapeAllPods(ctx context.Context) {
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, s.scraperConfig.workers)
+	s.pods.Range(func(key, value any) bool {
+		pod, ok := value.(*PodInfo)
+		if !ok || !pod.startScrape(time.Now()) {
+			return true
+		}
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer wg.Done()
+			s.scrapePod(ctx, pod)
+			<-workers
+		}()
+		return true
+	})
+	wg.Wait()
+}
+
+// runScraper scrapes the metrics and models of the pods in a pool of workers until ctx is done.
+// Every pod is scraped on its own jittered interval and within its own deadline,
+// so a slow or unreachable pod never delays the scrapes of the others.
+func (s *store) runScraper(ctx context.Context) {
+	pods := make(chan *PodInfo, s.scraperConfig.workers)
+	for i := 0; i < s.scraperConfig.workers; i++ {
+		go func() {
+			for pod := range pods {
+				s.scrapePod(ctx, pod)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			close(pods)
+			return
+		case now := <-ticker.C:
+			s.pods.Range(func(key, value any) bool {
+				pod, ok := value.(*PodInfo)
+				if !ok || !pod.startScrape(now) {
+					return true
+				}
+				select {
+				case pods <- pod:
+				default:
+					// All the workers are busy, the pod is retried on the next tick.
+					pod.cancelScrape()
+				}
+				return true
+			})
+		}
+	}
+}
+
+// scrapePod scrapes the metrics and models of a pod which has been marked by startScrape,
+// and schedules its next scrape.
+func (s *store) scrapePod(ctx context.Context, pod *PodInfo) {
+	if pod.engine == "" {
+		klog.V(2).Infof("failed to find backend of pod %s/%s", pod.Pod.GetNamespace(), pod.Pod.GetName())
+		pod.finishScrape(false, time.Now(), s.scraperConfig.jitteredInterval())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.scraperConfig.timeout)
+	defer cancel()
+
+	start := time.Now()
+	metricsErr := s.updatePodMetrics(ctx, pod)
+	metrics.DefaultMetrics.RecordPodScrape(pod.engine, metrics.ScrapeTypeMetrics, time.Since(start), metricsErr)
+
+	start = time.Now()
+	modelsErr := s.updatePodModels(ctx, pod)
+	metrics.DefaultMetrics.RecordPodScrape(pod.engine, metrics.ScrapeTypeModels, time.Since(start), modelsErr)
+
+	pod.finishScrape(metricsErr == nil && modelsErr == nil, time.Now(), s.scraperConfig.jitteredInterval())
+}
@@ -0,0 +1,192 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"istio.io/istio/pkg/util/sets"
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/backend"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+func TestNewScraperConfig(t *testing.T) {
+	config := newScraperConfig()
+	assert.Equal(t, defaultScrapeWorkers, config.workers)
+	assert.Equal(t, defaultScrapeInterval, config.interval)
+	assert.Equal(t, defaultScrapeTimeout, config.timeout)
+
+	t.Setenv("METRICS_SCRAPE_WORKERS", "64")
+	t.Setenv("METRICS_SCRAPE_INTERVAL", "5s")
+	t.Setenv("METRICS_SCRAPE_TIMEOUT", "invalid")
+	config = newScraperConfig()
+	assert.Equal(t, 64, config.workers)
+	assert.Equal(t, 5*time.Second, config.interval)
+	assert.Equal(t, defaultScrapeTimeout, config.timeout)
+}
+
+func TestScraperConfigJitteredInterval(t *testing.T) {
+	config := scraperConfig{interval: time.Second}
+	for i := 0; i < 100; i++ {
+		interval := config.jitteredInterval()
+		assert.GreaterOrEqual(t, interval, 800*time.Millisecond)
+		assert.LessOrEqual(t, interval, 1200*time.Millisecond)
+	}
+}
+
+func TestPodInfoScrape(t *testing.T) {
+	now := time.Now()
+	pod := &PodInfo{}
+
+	assert.True(t, pod.startScrape(now))
+	// A pod is scraped by one worker at a time
+	assert.False(t, pod.startScrape(now))
+	pod.cancelScrape()
+	assert.True(t, pod.startScrape(now))
+
+	pod.finishScrape(false, now, time.Second)
+	assert.True(t, pod.GetLastScrapeSuccess().IsZero())
+	// The pod is not due before the interval elapses
+	assert.False(t, pod.startScrape(now.Add(500*time.Millisecond)))
+	assert.True(t, pod.startScrape(now.Add(time.Second)))
+
+	pod.finishScrape(true, now.Add(time.Second), time.Second)
+	assert.Equal(t, now.Add(time.Second), pod.GetLastScrapeSuccess())
+}
+
+func TestScrapeAllPodsWithSlowPod(t *testing.T) {
+	s := New().(*store)
+	s.scraperConfig = scraperConfig{workers: 2, interval: time.Second, timeout: 100 * time.Millisecond}
+
+	ms := createTestModelServer("default", "ms", aiv1alpha1.VLLM)
+	assert.NoError(t, s.AddOrUpdateModelServer(ms, nil))
+
+	patch := gomonkey.NewPatches()
+	patch.ApplyFunc(backend.GetPodMetrics, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram, error) {
+		if pod.Name == "slow" {
+			// The slow pod only returns once the scrape deadline is exceeded
+			<-ctx.Done()
+			return nil, nil, ctx.Err()
+		}
+		return map[string]float64{utils.GPUCacheUsage: 0.5}, nil, nil
+	})
+	patch.ApplyFunc(backend.GetPodModels, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint) ([]string, error) {
+		return []string{"test-model"}, nil
+	})
+	defer patch.Reset()
+
+	pods := []*corev1.Pod{createTestPod("default", "slow"), createTestPod("default", "fast1"), createTestPod("default", "fast2")}
+	for _, pod := range pods {
+		s.pods.Store(utils.GetNamespaceName(pod), &PodInfo{
+			Pod:         pod,
+			engine:      string(aiv1alpha1.VLLM),
+			modelServer: sets.New(utils.GetNamespaceName(ms)),
+			models:      sets.New[string](),
+		})
+	}
+
+	start := time.Now()
+	s.scrapeAllPods(context.Background())
+	// The fast pods are scraped by the other worker while the slow pod is timing out
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	slow := s.GetPodInfo(utils.GetNamespaceName(pods[0]))
+	assert.True(t, slow.GetLastScrapeSuccess().IsZero())
+	assert.True(t, slow.Contains("test-model"))
+	for _, pod := range pods[1:] {
+		podInfo := s.GetPodInfo(utils.GetNamespaceName(pod))
+		assert.False(t, podInfo.GetLastScrapeSuccess().IsZero())
+		assert.Equal(t, 0.5, podInfo.GPUCacheUsage)
+	}
+}
+
+func TestStoreRunScrapesPeriodically(t *testing.T) {
+	s := New().(*store)
+	s.scraperConfig = scraperConfig{workers: 1, interval: 200 * time.Millisecond, timeout: 100 * time.Millisecond}
+
+	var scrapes atomic.Int32
+	patch := gomonkey.NewPatches()
+	patch.ApplyFunc(backend.GetPodMetrics, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram, error) {
+		scrapes.Add(1)
+		return map[string]float64{}, nil, nil
+	})
+	patch.ApplyFunc(backend.GetPodModels, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint) ([]string, error) {
+		return nil, nil
+	})
+	defer patch.Reset()
+
+	ms := createTestModelServer("default", "ms", aiv1alpha1.VLLM)
+	assert.NoError(t, s.AddOrUpdateModelServer(ms, nil))
+	pod := createTestPod("default", "pod1")
+	assert.NoError(t, s.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+	// A new pod is scraped when it is added
+	assert.Equal(t, int32(1), scrapes.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Run(ctx)
+
+	assert.Eventually(t, s.HasSynced, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return scrapes.Load() >= 3
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.False(t, s.GetPodInfo(utils.GetNamespaceName(pod)).GetLastScrapeSuccess().IsZero())
+}
+
+func TestAddOrUpdatePodKeepsScrape(t *testing.T) {
+	s := New().(*store)
+	s.scraperConfig = scraperConfig{workers: 1, interval: time.Hour, timeout: 100 * time.Millisecond}
+
+	var scrapes atomic.Int32
+	patch := gomonkey.NewPatches()
+	patch.ApplyFunc(backend.GetPodMetrics, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram, error) {
+		scrapes.Add(1)
+		return map[string]float64{utils.GPUCacheUsage: 0.5}, nil, nil
+	})
+	patch.ApplyFunc(backend.GetPodModels, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint) ([]string, error) {
+		return []string{"test-model"}, nil
+	})
+	defer patch.Reset()
+
+	ms := createTestModelServer("default", "ms", aiv1alpha1.VLLM)
+	assert.NoError(t, s.AddOrUpdateModelServer(ms, nil))
+	pod := createTestPod("default", "pod1")
+	assert.NoError(t, s.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+	podInfo := s.GetPodInfo(utils.GetNamespaceName(pod))
+	lastScrapeSuccess := podInfo.GetLastScrapeSuccess()
+	assert.False(t, lastScrapeSuccess.IsZero())
+
+	// An update of the pod keeps its scraped metrics and models, and does not make it due for a scrape
+	pod = pod.DeepCopy()
+	pod.Labels = map[string]string{"app": "updated"}
+	assert.NoError(t, s.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+	podInfo = s.GetPodInfo(utils.GetNamespaceName(pod))
+	assert.Equal(t, lastScrapeSuccess, podInfo.GetLastScrapeSuccess())
+	assert.Equal(t, 0.5, podInfo.GPUCacheUsage)
+	assert.True(t, podInfo.Contains("test-model"))
+	assert.False(t, podInfo.startScrape(time.Now()))
+	assert.Equal(t, int32(1), scrapes.Load())
+}
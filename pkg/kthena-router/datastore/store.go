@@ -59,7 +59,6 @@ var (
 const (
 	// Configuration constants for fairness scheduling
 	defaultQueueQPS = 100
-	uppdateInterval = 1 * time.Second
 )
 
 // createTokenTracker creates a token tracker with configuration from environment variables
@@ -144,6 +143,8 @@ type Store interface {
 
 	// New methods for routing functionality
 	MatchModelServer(modelName string, request *http.Request, gatewayKey string) (types.NamespacedName, bool, *aiv1alpha1.ModelRoute, error)
+	// MatchRule returns the rule of a ModelRoute returned by MatchModelServer which matched the request.
+	MatchRule(modelName string, request *http.Request, modelRoute *aiv1alpha1.ModelRoute) (*aiv1alpha1.Rule, error)
 
 	// Model routing methods
 	AddOrUpdateModelRoute(mr *aiv1alpha1.ModelRoute) error
@@ -159,6 +160,8 @@ type Store interface {
 	RegisterCallback(kind string, callback CallbackFunc)
 	// Run to update pod info periodically
 	Run(context.Context)
+	// ReportRequestResult records the result of a request to a pod for the outlier detection of the ModelServer
+	ReportRequestResult(modelServerName types.NamespacedName, pod *PodInfo, success bool)
 
 	// HasSynced checks if the store has been initialized and synced
 	HasSynced() bool
@@ -213,6 +216,18 @@ type PodInfo struct {
 	// Protected fields - use accessor methods for thread-safe access
 	models      sets.Set[string]               // running models. Including base model and lora adapters.
 	modelServer sets.Set[types.NamespacedName] // The modelservers this pod belongs to
+
+	scraping          bool      // Whether the metrics and models of the pod are being scraped
+	nextScrape        time.Time // When the pod is due for the next scrape
+	lastScrapeSuccess time.Time // When the metrics and models of the pod were last scraped successfully
+
+	// Number of requests in flight from the router to the pod. Unlike the scraped metrics, it is updated as soon as
+	// a request is dispatched. It is shared by all the PodInfo objects of the same pod across pod updates.
+	inFlightRequests *atomic.Int64
+	// Passive health tracking of the pod for the outlier detection, shared like inFlightRequests.
+	health *podHealth
+	// Active health check of the pod, shared like inFlightRequests.
+	probe *podProbe
 }
 
 // modelRouteInfo stores the mapping between a ModelRoute resource and its associated models.
@@ -249,6 +264,9 @@ type store struct {
 	// model -> RequestPriorityQueue
 	requestWaitingQueue sync.Map
 	tokenTracker        TokenTracker
+	scraperConfig       scraperConfig
+	// ejectionMutex serializes the ejections of pods by the outlier detection
+	ejectionMutex sync.Mutex
 }
 
 func New() Store {
@@ -263,30 +281,20 @@ func New() Store {
 		initialSynced:       &atomic.Bool{},
 		requestWaitingQueue: sync.Map{},
 		// Create token tracker with environment-based configuration
-		tokenTracker: createTokenTracker(),
+		tokenTracker:  createTokenTracker(),
+		scraperConfig: newScraperConfig(),
 	}
 }
 
 func (s *store) Run(ctx context.Context) {
 	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				s.pods.Range(func(key, value any) bool {
-					if p, ok := value.(*PodInfo); ok {
-						s.updatePodMetrics(p)
-						s.updatePodModels(p)
-					}
-					return true
-				})
-				s.initialSynced.Store(true)
-				time.Sleep(uppdateInterval)
-			}
-		}
+		s.scrapeAllPods(ctx)
+		s.initialSynced.Store(true)
+		s.runScraper(ctx)
 	}()
+	go s.runProber(ctx)
 }
+
 func (s *store) GetTokenCount(userID, model string) (float64, error) {
 	return s.tokenTracker.GetTokenCount(userID, model)
 }
@@ -513,11 +521,26 @@ func (s *store) AddOrUpdatePod(pod *corev1.Pod, modelServers []*aiv1alpha1.Model
 		}
 	}
 
+	if oldPodInfo != nil && oldPodInfo.inFlightRequests != nil {
+		// Requests dispatched to the old PodInfo are released from the new one
+		newPodInfo.inFlightRequests = oldPodInfo.inFlightRequests
+		newPodInfo.health = oldPodInfo.health
+		newPodInfo.probe = oldPodInfo.probe
+	} else {
+		newPodInfo.inFlightRequests = &atomic.Int64{}
+		newPodInfo.health = &podHealth{}
+		newPodInfo.probe = &podProbe{}
+	}
+	if oldPodInfo != nil {
+		newPodInfo.inheritScrape(oldPodInfo)
+	} else {
+		// Mark a new pod as being scraped before storing it, so that it is scraped here and not by the scraper.
+		newPodInfo.scraping = true
+	}
 	s.pods.Store(podName, newPodInfo)
 
 	if oldPodInfo == nil {
-		s.updatePodMetrics(newPodInfo)
-		s.updatePodModels(newPodInfo)
+		s.scrapePod(context.Background(), newPodInfo)
 	}
 
 	return nil
@@ -724,6 +747,10 @@ func (s *store) MatchModelServer(model string, req *http.Request, gatewayKey str
 	return types.NamespacedName{}, false, nil, fmt.Errorf("no matching ModelRoute found for model %s", model)
 }
 
+func (s *store) MatchRule(model string, req *http.Request, mr *aiv1alpha1.ModelRoute) (*aiv1alpha1.Rule, error) {
+	return s.selectRule(model, req, mr.Spec.Rules)
+}
+
 // matchesSpecificGateway checks if the ModelRoute matches a specific gateway
 func (s *store) matchesSpecificGateway(mr *aiv1alpha1.ModelRoute, gatewayKey string) bool {
 	s.gatewayMutex.RLock()
@@ -877,30 +904,35 @@ func selectFromWeightedSlice(weights []uint32) int {
 	return 0
 }
 
-func (s *store) updatePodMetrics(pod *PodInfo) {
-	if pod.engine == "" {
-		klog.V(2).Info("failed to find backend in pod")
-		return
-	}
-
+func (s *store) updatePodMetrics(ctx context.Context, pod *PodInfo) error {
+	metricsEndpoint, _ := s.getPodEndpoints(pod)
 	previousHistogram := getPreviousHistogram(pod)
-	gaugeMetrics, histogramMetrics := backend.GetPodMetrics(pod.engine, pod.Pod, previousHistogram)
+	gaugeMetrics, histogramMetrics, err := backend.GetPodMetrics(ctx, pod.engine, pod.Pod, metricsEndpoint, previousHistogram)
 	updateGaugeMetricsInfo(pod, gaugeMetrics)
 	updateHistogramMetrics(pod, histogramMetrics)
+	return err
 }
 
-func (s *store) updatePodModels(podInfo *PodInfo) {
-	if podInfo.engine == "" {
-		klog.V(2).Info("failed to find backend in pod")
-		return
-	}
-
-	models, err := backend.GetPodModels(podInfo.engine, podInfo.Pod)
+func (s *store) updatePodModels(ctx context.Context, podInfo *PodInfo) error {
+	_, modelsEndpoint := s.getPodEndpoints(podInfo)
+	models, err := backend.GetPodModels(ctx, podInfo.engine, podInfo.Pod, modelsEndpoint)
 	if err != nil {
 		klog.V(4).Infof("failed to get models of pod %s/%s", podInfo.Pod.GetNamespace(), podInfo.Pod.GetName())
 	}
 
 	podInfo.UpdateModels(models)
+	return err
+}
+
+// getPodEndpoints returns the metrics and models endpoints configured in the ModelServer of the pod.
+// Like the inference engine, they should be the same in all the ModelServers a pod belongs to.
+func (s *store) getPodEndpoints(podInfo *PodInfo) (*aiv1alpha1.PodEndpoint, *aiv1alpha1.PodEndpoint) {
+	for name := range podInfo.GetModelServers() {
+		if ms := s.GetModelServer(name); ms != nil {
+			return ms.Spec.MetricsEndpoint, ms.Spec.ModelsEndpoint
+		}
+	}
+	return nil, nil
 }
 
 func getPreviousHistogram(podinfo *PodInfo) map[string]*dto.Histogram {
@@ -1102,6 +1134,95 @@ func (p *PodInfo) GetEngine() string {
 	return p.engine
 }
 
+// GetInFlightRequests returns the number of requests in flight from the router to the pod.
+func (p *PodInfo) GetInFlightRequests() int64 {
+	if p.inFlightRequests == nil {
+		return 0
+	}
+	return p.inFlightRequests.Load()
+}
+
+// AcquireRequest counts a request in flight to the pod, if the pod has less than limit requests in flight.
+// A non-positive limit means no limit. It returns whether the request is counted,
+// in which case ReleaseRequest must be called once the request completes.
+func (p *PodInfo) AcquireRequest(limit int64) bool {
+	if p.inFlightRequests == nil {
+		return true
+	}
+	for {
+		current := p.inFlightRequests.Load()
+		if limit > 0 && current >= limit {
+			return false
+		}
+		if p.inFlightRequests.CompareAndSwap(current, current+1) {
+			return true
+		}
+	}
+}
+
+// ReleaseRequest uncounts a request counted by AcquireRequest.
+func (p *PodInfo) ReleaseRequest() {
+	if p.inFlightRequests != nil {
+		p.inFlightRequests.Add(-1)
+	}
+}
+
+// GetLastScrapeSuccess returns when the metrics and models of the pod were last scraped successfully,
+// it is zero if they have never been.
+func (p *PodInfo) GetLastScrapeSuccess() time.Time {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.lastScrapeSuccess
+}
+
+// startScrape marks the pod as being scraped if it is due at now and not being scraped yet.
+// It returns whether the caller should scrape the pod.
+func (p *PodInfo) startScrape(now time.Time) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.scraping || now.Before(p.nextScrape) {
+		return false
+	}
+	p.scraping = true
+	return true
+}
+
+// cancelScrape unmarks a pod marked by startScrape without scraping it.
+func (p *PodInfo) cancelScrape() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.scraping = false
+}
+
+// finishScrape unmarks a pod marked by startScrape and schedules its next scrape after interval.
+func (p *PodInfo) finishScrape(success bool, now time.Time, interval time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.scraping = false
+	p.nextScrape = now.Add(interval)
+	if success {
+		p.lastScrapeSuccess = now
+	}
+}
+
+// inheritScrape copies the scraped metrics and models of the pod and the schedule of its scrapes from old,
+// the PodInfo it replaces. A scrape of old which is still running is not inherited, the pod is scraped again
+// at the next dispatch instead.
+func (p *PodInfo) inheritScrape(old *PodInfo) {
+	old.mutex.RLock()
+	defer old.mutex.RUnlock()
+	p.GPUCacheUsage = old.GPUCacheUsage
+	p.RequestWaitingNum = old.RequestWaitingNum
+	p.RequestRunningNum = old.RequestRunningNum
+	p.TimeToFirstToken = old.TimeToFirstToken
+	p.TimePerOutputToken = old.TimePerOutputToken
+	p.TPOT = old.TPOT
+	p.TTFT = old.TTFT
+	p.models = old.models.Copy()
+	p.nextScrape = old.nextScrape
+	p.lastScrapeSuccess = old.lastScrapeSuccess
+}
+
 // Debug interface implementations
 
 // GetAllModelRoutes returns all ModelRoutes in the store
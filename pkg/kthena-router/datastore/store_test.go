@@ -17,6 +17,7 @@ limitations under the License.
 package datastore
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -183,7 +184,7 @@ func TestStoreUpdatePodMetrics(t *testing.T) {
 	})
 
 	patch := gomonkey.NewPatches()
-	patch.ApplyFunc(backend.GetPodMetrics, func(backend string, pod *corev1.Pod, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram) {
+	patch.ApplyFunc(backend.GetPodMetrics, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram, error) {
 		return map[string]float64{
 				utils.GPUCacheUsage:     0.8,
 				utils.RequestWaitingNum: 15,
@@ -199,11 +200,11 @@ func TestStoreUpdatePodMetrics(t *testing.T) {
 					SampleSum:   &sum2,
 					SampleCount: &count2,
 				},
-			}
+			}, nil
 	})
 	defer patch.Reset()
 
-	s.updatePodMetrics(&podinfo)
+	s.updatePodMetrics(context.Background(), &podinfo)
 
 	name := types.NamespacedName{
 		Namespace: "default",
@@ -227,6 +228,47 @@ func TestStoreUpdatePodMetrics(t *testing.T) {
 	}
 }
 
+func TestStoreUpdatePodEndpoints(t *testing.T) {
+	metricsEndpoint := &aiv1alpha1.PodEndpoint{Port: 9400, Path: "/exporter/metrics"}
+	modelsEndpoint := &aiv1alpha1.PodEndpoint{Port: 30000}
+	modelServerName := types.NamespacedName{Namespace: "default", Name: "model1"}
+	podinfo := &PodInfo{
+		engine:      "SGLang",
+		modelServer: sets.New[types.NamespacedName](modelServerName),
+		models:      sets.New[string](),
+	}
+	s := &store{}
+	s.modelServer.Store(modelServerName, newModelServer(&aiv1alpha1.ModelServer{
+		Spec: aiv1alpha1.ModelServerSpec{
+			MetricsEndpoint: metricsEndpoint,
+			ModelsEndpoint:  modelsEndpoint,
+		},
+	}))
+
+	var gotMetricsEndpoint, gotModelsEndpoint *aiv1alpha1.PodEndpoint
+	patch := gomonkey.NewPatches()
+	patch.ApplyFunc(backend.GetPodMetrics, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint, previousHistogram map[string]*dto.Histogram) (map[string]float64, map[string]*dto.Histogram, error) {
+		gotMetricsEndpoint = endpoint
+		return nil, nil, nil
+	})
+	patch.ApplyFunc(backend.GetPodModels, func(ctx context.Context, backend string, pod *corev1.Pod, endpoint *aiv1alpha1.PodEndpoint) ([]string, error) {
+		gotModelsEndpoint = endpoint
+		return []string{"model1"}, nil
+	})
+	defer patch.Reset()
+
+	s.updatePodMetrics(context.Background(), podinfo)
+	s.updatePodModels(context.Background(), podinfo)
+	assert.Equal(t, metricsEndpoint, gotMetricsEndpoint)
+	assert.Equal(t, modelsEndpoint, gotModelsEndpoint)
+	assert.True(t, podinfo.Contains("model1"))
+
+	// The engine defaults are used without ModelServer
+	podinfo.RemoveModelServer(modelServerName)
+	s.updatePodMetrics(context.Background(), podinfo)
+	assert.Nil(t, gotMetricsEndpoint)
+}
+
 func TestStoreAddOrUpdatePod(t *testing.T) {
 	s := &store{
 		modelServer: sync.Map{},
@@ -294,6 +336,40 @@ func TestStoreAddOrUpdatePod(t *testing.T) {
 	}
 }
 
+func TestPodInfoAcquireRequest(t *testing.T) {
+	s := &store{
+		modelServer: sync.Map{},
+		pods:        sync.Map{},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "pod1",
+		},
+	}
+	podName := utils.GetNamespaceName(pod)
+	assert.NoError(t, s.AddOrUpdatePod(pod, nil))
+	podInfo := s.GetPodInfo(podName)
+
+	assert.True(t, podInfo.AcquireRequest(2))
+	assert.True(t, podInfo.AcquireRequest(2))
+	assert.False(t, podInfo.AcquireRequest(2))
+	assert.Equal(t, int64(2), podInfo.GetInFlightRequests())
+	// No limit
+	assert.True(t, podInfo.AcquireRequest(0))
+	assert.Equal(t, int64(3), podInfo.GetInFlightRequests())
+
+	// The requests in flight are kept when the pod is updated
+	assert.NoError(t, s.AddOrUpdatePod(pod, nil))
+	updatedPodInfo := s.GetPodInfo(podName)
+	assert.NotSame(t, podInfo, updatedPodInfo)
+	assert.Equal(t, int64(3), updatedPodInfo.GetInFlightRequests())
+	podInfo.ReleaseRequest()
+	podInfo.ReleaseRequest()
+	assert.Equal(t, int64(1), updatedPodInfo.GetInFlightRequests())
+	assert.True(t, updatedPodInfo.AcquireRequest(2))
+}
+
 func TestStoreDeletePod(t *testing.T) {
 	podName := types.NamespacedName{Namespace: "default", Name: "pod1"}
 	modelServerName := types.NamespacedName{Namespace: "default", Name: "model1"}
@@ -66,6 +66,7 @@ type PodResponse struct {
 	PodInfo      *PodInfo `json:"podInfo,omitempty"`
 	Engine       string   `json:"engine"`
 	Metrics      *Metrics `json:"metrics,omitempty"`
+	Health       *Health  `json:"health,omitempty"`
 	Models       []string `json:"models"`
 	ModelServers []string `json:"modelServers"`
 }
@@ -84,6 +85,18 @@ type Metrics struct {
 	RequestRunningNum float64 `json:"requestRunningNum"`
 	TPOT              float64 `json:"tpot"`
 	TTFT              float64 `json:"ttft"`
+	InFlightRequests  int64   `json:"inFlightRequests"`
+	LastScrapeSuccess string  `json:"lastScrapeSuccess,omitempty"`
+}
+
+type Health struct {
+	Ejected             bool   `json:"ejected"`
+	EjectedUntil        string `json:"ejectedUntil,omitempty"`
+	Ejections           int    `json:"ejections"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	Unhealthy           bool   `json:"unhealthy"`
+	LastProbe           string `json:"lastProbe,omitempty"`
+	LastProbeError      string `json:"lastProbeError,omitempty"`
 }
 
 // List endpoints
@@ -273,6 +286,29 @@ func (h *DebugHandler) convertPodInfoToResponse(namespacedName types.NamespacedN
 		RequestRunningNum: podInfo.RequestRunningNum,
 		TPOT:              podInfo.TPOT,
 		TTFT:              podInfo.TTFT,
+		InFlightRequests:  podInfo.GetInFlightRequests(),
+	}
+	if lastScrapeSuccess := podInfo.GetLastScrapeSuccess(); !lastScrapeSuccess.IsZero() {
+		response.Metrics.LastScrapeSuccess = lastScrapeSuccess.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	// Add outlier detection state
+	health := podInfo.GetHealthStatus()
+	response.Health = &Health{
+		Ejected:             podInfo.IsEjected(),
+		Ejections:           health.Ejections,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+	}
+	if !health.EjectedUntil.IsZero() {
+		response.Health.EjectedUntil = health.EjectedUntil.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	// Add active health check state
+	probe := podInfo.GetProbeStatus()
+	response.Health.Unhealthy = probe.Unhealthy
+	response.Health.LastProbeError = probe.LastError
+	if !probe.LastProbe.IsZero() {
+		response.Health.LastProbe = probe.LastProbe.UTC().Format("2006-01-02T15:04:05Z")
 	}
 
 	// Add pod info if details are requested
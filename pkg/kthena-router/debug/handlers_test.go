@@ -86,6 +86,15 @@ func (m *MockStore) MatchModelServer(modelName string, request *http.Request, ga
 	return args.Get(0).(types.NamespacedName), args.Bool(1), modelRoute, args.Error(3)
 }
 
+func (m *MockStore) MatchRule(modelName string, request *http.Request, modelRoute *aiv1alpha1.ModelRoute) (*aiv1alpha1.Rule, error) {
+	args := m.Called(modelName, request, modelRoute)
+	var rule *aiv1alpha1.Rule
+	if args.Get(0) != nil {
+		rule = args.Get(0).(*aiv1alpha1.Rule)
+	}
+	return rule, args.Error(1)
+}
+
 func (m *MockStore) AddOrUpdateModelRoute(mr *aiv1alpha1.ModelRoute) error {
 	args := m.Called(mr)
 	return args.Error(0)
@@ -128,6 +137,10 @@ func (m *MockStore) Run(ctx context.Context) {
 	m.Called(ctx)
 }
 
+func (m *MockStore) ReportRequestResult(modelServerName types.NamespacedName, pod *datastore.PodInfo, success bool) {
+	m.Called(modelServerName, pod, success)
+}
+
 func (m *MockStore) HasSynced() bool {
 	args := m.Called()
 	return args.Bool(0)
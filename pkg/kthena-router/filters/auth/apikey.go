@@ -0,0 +1,246 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+)
+
+const (
+	// APIKeyLabel is the label of the Secrets holding API keys
+	APIKeyLabel = "networking.serving.volcano.sh/api-key"
+	// APIKeyPrefix is the prefix of API keys, which tells them apart from JWTs in the Authorization header
+	APIKeyPrefix = "sk-"
+)
+
+// Data keys of API key Secrets
+const (
+	// apiKeyHashData is the hex encoded SHA-256 digest of the API key. Only the digest is stored, not the key.
+	apiKeyHashData = "keyHash"
+	// apiKeyOwnerData is the user the API key authenticates, the name of the Secret if it is not set
+	apiKeyOwnerData = "owner"
+	// apiKeyTenantData is the optional tenant of the owner
+	apiKeyTenantData = "tenant"
+	// apiKeyExpiresAtData is the optional RFC 3339 time the API key expires at
+	apiKeyExpiresAtData = "expiresAt"
+)
+
+var (
+	errInvalidAPIKey = errors.New("invalid API key")
+	errExpiredAPIKey = errors.New("API key has expired")
+)
+
+// APIKey is an API key read from a Secret
+type APIKey struct {
+	Owner  string
+	Tenant string
+	// ExpiresAt is the time the key expires at, zero if it does not expire
+	ExpiresAt time.Time
+
+	// secret is the namespace/name key of the Secret of the API key
+	secret string
+	// digest is the digest of the API key
+	digest string
+}
+
+// APIKeyAuthenticator authenticates requests with the API keys of labeled Secrets, which are kept up to date by a controller
+type APIKeyAuthenticator struct {
+	enabled bool
+	// namespace is the namespace of the API key Secrets, all namespaces if it is empty
+	namespace string
+
+	mutex sync.RWMutex
+	// keys are the API keys by the digest of the key
+	keys map[string]*APIKey
+	// secrets are the API keys by Secret key, so that keys can be removed when their Secret changes. They include
+	// the duplicate API keys, which are not in keys as another Secret holds the same key.
+	secrets map[string]*APIKey
+}
+
+// NewAPIKeyAuthenticator creates a new APIKeyAuthenticator, which is disabled unless API keys are enabled in routerConfig
+func NewAPIKeyAuthenticator(routerConfig *conf.RouterConfiguration) *APIKeyAuthenticator {
+	a := &APIKeyAuthenticator{
+		keys:    make(map[string]*APIKey),
+		secrets: make(map[string]*APIKey),
+	}
+	if routerConfig != nil && routerConfig.Auth.APIKey.Enabled {
+		a.enabled = true
+		a.namespace = routerConfig.Auth.APIKey.Namespace
+	}
+	return a
+}
+
+// IsEnabled returns whether API key authentication is enabled
+func (a *APIKeyAuthenticator) IsEnabled() bool {
+	return a.enabled
+}
+
+// Namespace returns the namespace of the API key Secrets, all namespaces if it is empty
+func (a *APIKeyAuthenticator) Namespace() string {
+	return a.namespace
+}
+
+// AddOrUpdateSecret adds or updates the API key of a Secret, identified by its namespace/name key.
+// The previous API key of the Secret is removed even if the Secret is invalid.
+// An API key already held by another Secret is rejected, as it would authenticate two owners. It is loaded once
+// the other Secret is deleted or holds another key.
+func (a *APIKeyAuthenticator) AddOrUpdateSecret(key string, secret *corev1.Secret) error {
+	digest, apiKey, err := parseAPIKeySecret(secret)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if err != nil {
+		a.deleteLocked(key)
+		return fmt.Errorf("invalid API key secret %s: %w", key, err)
+	}
+	apiKey.secret = key
+	apiKey.digest = digest
+	// A Secret keeps holding its API key when it is updated, e.g. when its owner changes
+	if prev := a.secrets[key]; prev == nil || prev.digest != digest || a.keys[digest] != prev {
+		a.deleteLocked(key)
+		if holder := a.keys[digest]; holder != nil {
+			a.secrets[key] = apiKey
+			return fmt.Errorf("API key of secret %s is already held by secret %s", key, holder.secret)
+		}
+	}
+	a.secrets[key] = apiKey
+	a.keys[digest] = apiKey
+	klog.V(4).Infof("loaded API key of secret %s for owner %s", key, apiKey.Owner)
+	return nil
+}
+
+// DeleteSecret removes the API key of a Secret
+func (a *APIKeyAuthenticator) DeleteSecret(key string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.deleteLocked(key)
+}
+
+func (a *APIKeyAuthenticator) deleteLocked(key string) {
+	apiKey, ok := a.secrets[key]
+	if !ok {
+		return
+	}
+	delete(a.secrets, key)
+	if a.keys[apiKey.digest] != apiKey {
+		return
+	}
+	delete(a.keys, apiKey.digest)
+	// The first of the Secrets rejected for holding the same API key takes it over
+	for _, k := range slices.Sorted(maps.Keys(a.secrets)) {
+		if duplicate := a.secrets[k]; duplicate.digest == apiKey.digest {
+			a.keys[apiKey.digest] = duplicate
+			klog.V(4).Infof("loaded API key of secret %s for owner %s after secret %s was removed", k, duplicate.Owner, key)
+			return
+		}
+	}
+}
+
+// authenticate returns the API key matching token
+func (a *APIKeyAuthenticator) authenticate(token string, now time.Time) (*APIKey, error) {
+	if !strings.HasPrefix(token, APIKeyPrefix) {
+		return nil, errInvalidAPIKey
+	}
+	digest := sha256.Sum256([]byte(token))
+
+	a.mutex.RLock()
+	apiKey, ok := a.keys[hex.EncodeToString(digest[:])]
+	a.mutex.RUnlock()
+
+	if !ok {
+		return nil, errInvalidAPIKey
+	}
+	if !apiKey.ExpiresAt.IsZero() && now.After(apiKey.ExpiresAt) {
+		return nil, errExpiredAPIKey
+	}
+	return apiKey, nil
+}
+
+// Authenticate returns a Gin middleware authenticating requests with API keys, alongside jwtAuthenticator.
+// Bearer tokens with the API key prefix are authenticated as API keys, the others as JWTs if JWT authentication is enabled.
+func (a *APIKeyAuthenticator) Authenticate(jwtAuthenticator *JWTAuthenticator) gin.HandlerFunc {
+	authenticateJWT := jwtAuthenticator.Authenticate()
+	if !a.enabled {
+		return authenticateJWT
+	}
+	return func(c *gin.Context) {
+		token := extractTokenFromHeader(c.Request)
+		if !strings.HasPrefix(token, APIKeyPrefix) && jwtAuthenticator.IsEnabled() {
+			authenticateJWT(c)
+			return
+		}
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing or invalid"})
+			return
+		}
+
+		apiKey, err := a.authenticate(token, time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Unauthorized: %v", err)})
+			return
+		}
+		c.Set(common.UserIdKey, apiKey.Owner)
+		if apiKey.Tenant != "" {
+			c.Set(common.TenantKey, apiKey.Tenant)
+		}
+		c.Next()
+	}
+}
+
+// parseAPIKeySecret returns the digest and the API key of a Secret
+func parseAPIKeySecret(secret *corev1.Secret) (string, *APIKey, error) {
+	digest := strings.ToLower(strings.TrimSpace(string(secret.Data[apiKeyHashData])))
+	if digest == "" {
+		return "", nil, fmt.Errorf("%s is missing", apiKeyHashData)
+	}
+	if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != sha256.Size {
+		return "", nil, fmt.Errorf("%s is not a hex encoded SHA-256 digest", apiKeyHashData)
+	}
+
+	apiKey := &APIKey{
+		Owner:  strings.TrimSpace(string(secret.Data[apiKeyOwnerData])),
+		Tenant: strings.TrimSpace(string(secret.Data[apiKeyTenantData])),
+	}
+	if apiKey.Owner == "" {
+		apiKey.Owner = secret.Name
+	}
+	if expiresAt := strings.TrimSpace(string(secret.Data[apiKeyExpiresAtData])); expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid %s: %w", apiKeyExpiresAtData, err)
+		}
+		apiKey.ExpiresAt = t
+	}
+	return digest, apiKey, nil
+}
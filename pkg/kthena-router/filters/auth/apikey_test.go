@@ -0,0 +1,256 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+)
+
+func newAPIKeySecret(name, apiKey string, data map[string]string) *corev1.Secret {
+	digest := sha256.Sum256([]byte(apiKey))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data:       map[string][]byte{"keyHash": []byte(hex.EncodeToString(digest[:]))},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func newAPIKeyAuthenticator() *APIKeyAuthenticator {
+	return NewAPIKeyAuthenticator(&conf.RouterConfiguration{
+		Auth: conf.AuthenticationConfig{APIKey: conf.APIKeyConfig{Enabled: true}},
+	})
+}
+
+func TestNewAPIKeyAuthenticator(t *testing.T) {
+	assert.False(t, NewAPIKeyAuthenticator(nil).IsEnabled())
+	assert.False(t, NewAPIKeyAuthenticator(&conf.RouterConfiguration{}).IsEnabled())
+
+	a := NewAPIKeyAuthenticator(&conf.RouterConfiguration{
+		Auth: conf.AuthenticationConfig{APIKey: conf.APIKeyConfig{Enabled: true, Namespace: "kthena-system"}},
+	})
+	assert.True(t, a.IsEnabled())
+	assert.Equal(t, "kthena-system", a.Namespace())
+}
+
+func TestAPIKeyAuthenticator_Authenticate(t *testing.T) {
+	a := newAPIKeyAuthenticator()
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, a.AddOrUpdateSecret("default/batch", newAPIKeySecret("batch", "sk-batch", map[string]string{
+		"owner":     "batch-job",
+		"tenant":    "team-a",
+		"expiresAt": "2026-12-31T00:00:00Z",
+	})))
+	require.NoError(t, a.AddOrUpdateSecret("default/ci", newAPIKeySecret("ci", "sk-ci", nil)))
+
+	apiKey, err := a.authenticate("sk-batch", now)
+	require.NoError(t, err)
+	assert.Equal(t, "batch-job", apiKey.Owner)
+	assert.Equal(t, "team-a", apiKey.Tenant)
+
+	// The owner defaults to the name of the Secret
+	apiKey, err = a.authenticate("sk-ci", now)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", apiKey.Owner)
+	assert.Empty(t, apiKey.Tenant)
+
+	_, err = a.authenticate("sk-unknown", now)
+	assert.ErrorIs(t, err, errInvalidAPIKey)
+	_, err = a.authenticate("batch", now)
+	assert.ErrorIs(t, err, errInvalidAPIKey)
+
+	_, err = a.authenticate("sk-batch", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, errExpiredAPIKey)
+}
+
+func TestAPIKeyAuthenticator_UpdateSecret(t *testing.T) {
+	a := newAPIKeyAuthenticator()
+	now := time.Now()
+
+	require.NoError(t, a.AddOrUpdateSecret("default/batch", newAPIKeySecret("batch", "sk-old", nil)))
+	require.NoError(t, a.AddOrUpdateSecret("default/batch", newAPIKeySecret("batch", "sk-new", nil)))
+
+	// The rotated key is no longer valid
+	_, err := a.authenticate("sk-old", now)
+	assert.ErrorIs(t, err, errInvalidAPIKey)
+	_, err = a.authenticate("sk-new", now)
+	assert.NoError(t, err)
+
+	// An invalid update removes the key
+	err = a.AddOrUpdateSecret("default/batch", newAPIKeySecret("batch", "sk-new", map[string]string{"expiresAt": "tomorrow"}))
+	assert.ErrorContains(t, err, "invalid expiresAt")
+	_, err = a.authenticate("sk-new", now)
+	assert.ErrorIs(t, err, errInvalidAPIKey)
+
+	// An update of the owner keeps the key
+	require.NoError(t, a.AddOrUpdateSecret("default/batch", newAPIKeySecret("batch", "sk-new", map[string]string{"owner": "batch-job"})))
+	apiKey, err := a.authenticate("sk-new", now)
+	require.NoError(t, err)
+	assert.Equal(t, "batch-job", apiKey.Owner)
+}
+
+func TestAPIKeyAuthenticator_DuplicateSecret(t *testing.T) {
+	a := newAPIKeyAuthenticator()
+	now := time.Now()
+
+	// The same key in another Secret is rejected, the first Secret keeps holding it, even when it is updated
+	require.NoError(t, a.AddOrUpdateSecret("default/b", newAPIKeySecret("b", "sk-shared", nil)))
+	err := a.AddOrUpdateSecret("default/a", newAPIKeySecret("a", "sk-shared", nil))
+	assert.EqualError(t, err, "API key of secret default/a is already held by secret default/b")
+	require.NoError(t, a.AddOrUpdateSecret("default/b", newAPIKeySecret("b", "sk-shared", map[string]string{"tenant": "team-b"})))
+	err = a.AddOrUpdateSecret("default/c", newAPIKeySecret("c", "sk-shared", nil))
+	assert.EqualError(t, err, "API key of secret default/c is already held by secret default/b")
+	apiKey, err := a.authenticate("sk-shared", now)
+	require.NoError(t, err)
+	assert.Equal(t, "b", apiKey.Owner)
+
+	// Deleting the Secret holding the key hands it over to a remaining Secret with the same key
+	a.DeleteSecret("default/b")
+	apiKey, err = a.authenticate("sk-shared", now)
+	require.NoError(t, err)
+	assert.Equal(t, "a", apiKey.Owner)
+
+	// So does rotating the key of the Secret holding it
+	require.NoError(t, a.AddOrUpdateSecret("default/a", newAPIKeySecret("a", "sk-rotated", nil)))
+	apiKey, err = a.authenticate("sk-shared", now)
+	require.NoError(t, err)
+	assert.Equal(t, "c", apiKey.Owner)
+
+	// Deleting a rejected Secret keeps the key of the Secret holding it
+	assert.Error(t, a.AddOrUpdateSecret("default/d", newAPIKeySecret("d", "sk-rotated", nil)))
+	a.DeleteSecret("default/d")
+	apiKey, err = a.authenticate("sk-rotated", now)
+	require.NoError(t, err)
+	assert.Equal(t, "a", apiKey.Owner)
+
+	a.DeleteSecret("default/c")
+	_, err = a.authenticate("sk-shared", now)
+	assert.ErrorIs(t, err, errInvalidAPIKey)
+}
+
+func TestParseAPIKeySecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret *corev1.Secret
+		err    string
+	}{
+		{
+			name:   "missing hash",
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+			err:    "keyHash is missing",
+		},
+		{
+			name:   "plain text key",
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Data: map[string][]byte{"keyHash": []byte("sk-plain")}},
+			err:    "keyHash is not a hex encoded SHA-256 digest",
+		},
+		{
+			name:   "invalid expiry",
+			secret: newAPIKeySecret("a", "sk-a", map[string]string{"expiresAt": "2026-12-31"}),
+			err:    "invalid expiresAt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseAPIKeySecret(tt.secret)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	// Digests are case insensitive, and the trailing newline of `sha256sum` output is ignored
+	secret := newAPIKeySecret("a", "sk-a", nil)
+	digest := sha256.Sum256([]byte("sk-a"))
+	secret.Data["keyHash"] = []byte(strings.ToUpper(hex.EncodeToString(digest[:])) + "\n")
+	parsed, _, err := parseAPIKeySecret(secret)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(digest[:]), parsed)
+}
+
+func TestAPIKeyAuthenticatorMiddleware(t *testing.T) {
+	serve := func(middleware gin.HandlerFunc, authorization string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		if authorization != "" {
+			c.Request.Header.Set("Authorization", authorization)
+		}
+		middleware(c)
+		return w, c
+	}
+
+	a := newAPIKeyAuthenticator()
+	require.NoError(t, a.AddOrUpdateSecret("default/batch", newAPIKeySecret("batch", "sk-batch", map[string]string{
+		"owner":  "batch-job",
+		"tenant": "team-a",
+	})))
+
+	t.Run("disabled authenticator", func(t *testing.T) {
+		middleware := NewAPIKeyAuthenticator(nil).Authenticate(&JWTAuthenticator{enabled: false})
+		w, _ := serve(middleware, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("valid API key", func(t *testing.T) {
+		w, c := serve(a.Authenticate(&JWTAuthenticator{enabled: false}), "Bearer sk-batch")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "batch-job", c.GetString(common.UserIdKey))
+		assert.Equal(t, "team-a", c.GetString(common.TenantKey))
+	})
+
+	t.Run("invalid API key", func(t *testing.T) {
+		w, _ := serve(a.Authenticate(&JWTAuthenticator{enabled: true}), "Bearer sk-unknown")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid API key")
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		w, _ := serve(a.Authenticate(&JWTAuthenticator{enabled: false}), "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("JWT authentication disabled", func(t *testing.T) {
+		// Tokens which are not API keys are rejected
+		w, _ := serve(a.Authenticate(&JWTAuthenticator{enabled: false}), "Bearer eyJhbGciOiJSUzI1NiJ9")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid API key")
+	})
+
+	t.Run("JWT authentication enabled", func(t *testing.T) {
+		// Tokens which are not API keys are authenticated as JWTs
+		w, _ := serve(a.Authenticate(&JWTAuthenticator{enabled: true, rotator: &JWKSRotator{jwks: &Jwks{}}}), "Bearer eyJhbGciOiJSUzI1NiJ9")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "no JWKS available")
+	})
+}
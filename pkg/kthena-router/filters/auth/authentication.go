@@ -14,8 +14,9 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// Package auth provides JWT authentication and authorization functionality for the Kthena router.
-// This package handles JWT token validation, JWKS rotation, and provides middleware for Gin HTTP framework.
+// Package auth provides JWT and API key authentication and authorization functionality for the Kthena router.
+// This package handles JWT token validation, JWKS rotation, API keys stored in Secrets, and provides middleware
+// for Gin HTTP framework.
 package auth
 
 import (
@@ -41,6 +42,9 @@ const (
 	prefix = "Bearer "
 )
 
+// tokenKey is the key of the authenticated JWT in the gin context.
+const tokenKey = "jwt_token"
+
 // extractTokenFromHeader extracts the Bearer token from the Authorization header
 func extractTokenFromHeader(req *http.Request) string {
 	value := req.Header.Get(header)
@@ -79,26 +83,53 @@ func (j *JWTAuthenticator) Close() {
 	}
 }
 
-// authenticate validates the token and returns the subject
-func (j *JWTAuthenticator) authenticate(tokenStr string) (string, error) {
+// authenticate validates the token and returns it
+func (j *JWTAuthenticator) authenticate(tokenStr string) (jwt.Token, error) {
 	// Get current JWKS from rotator
 	jwksValue := j.rotator.GetJwks()
 	if jwksValue.Jwks == nil {
-		return "", fmt.Errorf("no JWKS available for token validation")
+		return nil, fmt.Errorf("no JWKS available for token validation")
 	}
 
 	token, err := jwt.Parse([]byte(tokenStr), jwt.WithKeySet(jwksValue.Jwks, jws.WithInferAlgorithmFromKey(true)))
 	if err != nil {
-		return "", fmt.Errorf("failed to parse jwt: %w", err)
+		return nil, fmt.Errorf("failed to parse jwt: %w", err)
 	}
 
 	// Validate the claims in the token
 	if err := j.validateClaims(token, jwksValue); err != nil {
-		return "", fmt.Errorf("failed to validate claims: %w", err)
+		return nil, fmt.Errorf("failed to validate claims: %w", err)
 	}
 
+	return token, nil
+}
+
+// setToken sets the user information of an authenticated token in the context
+func setToken(c *gin.Context, token jwt.Token) {
 	sub, _ := token.Subject()
-	return sub, nil
+	c.Set(common.UserIdKey, sub)
+	c.Set(tokenKey, token)
+}
+
+// GetClaim returns the claim of the JWT authenticated for the request as a string.
+// It returns false if the request has no authenticated JWT or the token has no such claim.
+func GetClaim(c *gin.Context, name string) (string, bool) {
+	value, ok := c.Get(tokenKey)
+	if !ok {
+		return "", false
+	}
+	token, ok := value.(jwt.Token)
+	if !ok {
+		return "", false
+	}
+	var claim any
+	if err := token.Get(name, &claim); err != nil || claim == nil {
+		return "", false
+	}
+	if s, ok := claim.(string); ok {
+		return s, true
+	}
+	return fmt.Sprint(claim), true
 }
 
 func (j *JWTAuthenticator) validateClaims(token jwt.Token, jwks *Jwks) error {
@@ -293,12 +324,12 @@ func (j *JWTAuthenticator) ValidateToken(ctx context.Context, c *gin.Context, to
 		return fmt.Errorf("authorization header missing or empty")
 	}
 
-	sub, err := j.authenticate(token)
+	jwtToken, err := j.authenticate(token)
 	if err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
-	c.Set(common.UserIdKey, sub)
+	setToken(c, jwtToken)
 	return nil
 }
 
@@ -318,12 +349,12 @@ func (j *JWTAuthenticator) Authenticate() gin.HandlerFunc {
 				return
 			}
 
-			sub, err := j.authenticate(token)
+			jwtToken, err := j.authenticate(token)
 			if err != nil {
 				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Unauthorized: %v", err)})
 				return
 			}
-			c.Set(common.UserIdKey, sub)
+			setToken(c, jwtToken)
 		}
 		c.Next()
 	}
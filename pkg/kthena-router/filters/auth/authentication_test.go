@@ -26,6 +26,7 @@ import (
 	"github.com/lestrrat-go/jwx/v3/jwt"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
 )
 
@@ -179,6 +180,26 @@ func TestJWTAuthenticatorMiddleware(t *testing.T) {
 	})
 }
 
+func TestGetClaim(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	_, ok := GetClaim(c, "sub")
+	assert.False(t, ok)
+
+	token, err := jwt.NewBuilder().Subject("user-1").Claim("session_id", "session-1").Claim("tier", 2).Build()
+	assert.NoError(t, err)
+	setToken(c, token)
+
+	assert.Equal(t, "user-1", c.GetString(common.UserIdKey))
+	claim, ok := GetClaim(c, "session_id")
+	assert.True(t, ok)
+	assert.Equal(t, "session-1", claim)
+	claim, ok = GetClaim(c, "tier")
+	assert.True(t, ok)
+	assert.Equal(t, "2", claim)
+	_, ok = GetClaim(c, "missing")
+	assert.False(t, ok)
+}
+
 func TestValidateAudiences(t *testing.T) {
 	authenticator := &JWTAuthenticator{}
 	token := jwt.New()
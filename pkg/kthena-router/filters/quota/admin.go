@@ -0,0 +1,119 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+// AdminHandler provides the admin endpoints to query and reset the usage of quotas
+type AdminHandler struct {
+	manager *Manager
+	// token is the bearer token of the admin endpoints, which are disabled if it is empty
+	token string
+}
+
+// NewAdminHandler creates a new admin handler authenticating requests with the bearer token
+func NewAdminHandler(manager *Manager, token string) *AdminHandler {
+	return &AdminHandler{
+		manager: manager,
+		token:   token,
+	}
+}
+
+// UsageResponse is the usage of the quota of a key
+type UsageResponse struct {
+	Model               string                         `json:"model"`
+	Key                 string                         `json:"key,omitempty"`
+	Period              networkingv1alpha1.QuotaPeriod `json:"period"`
+	Used                int64                          `json:"used"`
+	TokensPerPeriod     int64                          `json:"tokensPerPeriod,omitempty"`
+	SoftTokensPerPeriod int64                          `json:"softTokensPerPeriod,omitempty"`
+	Reset               string                         `json:"reset"`
+}
+
+// GetUsage returns the usage of the quota of a model in the current period, e.g. GET /admin/quota/models/<model>?key=<key>.
+// The rate limit key is given by the key query parameter for keyed quotas.
+func (h *AdminHandler) GetUsage(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+	model, key := modelParam(c), c.Query("key")
+	status, err := h.manager.Usage(c.Request.Context(), model, key)
+	if err != nil {
+		h.abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, UsageResponse{
+		Model:               model,
+		Key:                 key,
+		Period:              status.Period,
+		Used:                status.Used,
+		TokensPerPeriod:     status.Limit,
+		SoftTokensPerPeriod: status.SoftLimit,
+		Reset:               status.Reset.Round(time.Second).String(),
+	})
+}
+
+// ResetUsage resets the usage of the quota of a model in the current period, e.g. DELETE /admin/quota/models/<model>?key=<key>.
+// The rate limit key is given by the key query parameter for keyed quotas.
+func (h *AdminHandler) ResetUsage(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+	model, key := modelParam(c), c.Query("key")
+	if err := h.manager.Reset(c.Request.Context(), model, key); err != nil {
+		h.abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// modelParam returns the model of the request path. It is matched by a wildcard, as model names may contain slashes.
+func modelParam(c *gin.Context) string {
+	return strings.TrimPrefix(c.Param("model"), "/")
+}
+
+// authorize checks the bearer token of the request, aborting it if it is not the admin token
+func (h *AdminHandler) authorize(c *gin.Context) bool {
+	if h.token == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "quota admin endpoints are disabled"})
+		return false
+	}
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return false
+	}
+	return true
+}
+
+func (h *AdminHandler) abortWithError(c *gin.Context, err error) {
+	if errors.Is(err, ErrNoQuota) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
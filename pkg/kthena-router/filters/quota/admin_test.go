@@ -0,0 +1,90 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+func TestAdminHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	m := newTestManager(t, &now)
+	hard, soft := int64(1000), int64(800)
+	m.AddOrUpdateQuota("org/test-model", &networkingv1alpha1.RateLimit{
+		Key: &networkingv1alpha1.RateLimitKey{Source: networkingv1alpha1.RateLimitKeyUser},
+		Quota: &networkingv1alpha1.TokenQuota{
+			Period:              networkingv1alpha1.QuotaDay,
+			TokensPerPeriod:     &hard,
+			SoftTokensPerPeriod: &soft,
+		},
+	}, nil)
+	record(t, m, "org/test-model", "user-a", 900)
+
+	engine := gin.New()
+	handler := NewAdminHandler(m, "admin-token")
+	engine.GET("/admin/quota/models/*model", handler.GetUsage)
+	engine.DELETE("/admin/quota/models/*model", handler.ResetUsage)
+
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodGet, "/admin/quota/models/org/test-model?key=user-a", "admin-token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var usage UsageResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, UsageResponse{
+		Model:               "org/test-model",
+		Key:                 "user-a",
+		Period:              networkingv1alpha1.QuotaDay,
+		Used:                900,
+		TokensPerPeriod:     1000,
+		SoftTokensPerPeriod: 800,
+		Reset:               "12h0m0s",
+	}, usage)
+
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/admin/quota/models/org/test-model?key=user-a", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodDelete, "/admin/quota/models/org/test-model?key=user-a", "wrong").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/admin/quota/models/unknown-model", "admin-token").Code)
+
+	assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/admin/quota/models/org/test-model?key=user-a", "admin-token").Code)
+	status, err := m.Usage(context.Background(), "org/test-model", "user-a")
+	assert.NoError(t, err)
+	assert.Zero(t, status.Used)
+
+	// The admin endpoints are disabled without a token
+	engine = gin.New()
+	engine.GET("/admin/quota/models/*model", NewAdminHandler(m, "").GetUsage)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodGet, "/admin/quota/models/org/test-model", "").Code)
+}
@@ -0,0 +1,261 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"k8s.io/klog/v2"
+)
+
+// defaultFlushInterval is how often the usage of a FileLedger is written to its file
+const defaultFlushInterval = 10 * time.Second
+
+// Ledger persists the token usage of quotas
+type Ledger interface {
+	// Add adds tokens to the usage recorded under key, which expires at expireAt, and returns the new usage
+	Add(ctx context.Context, key string, tokens int64, expireAt time.Time) (int64, error)
+	// Get returns the usage recorded under key, 0 if there is none
+	Get(ctx context.Context, key string) (int64, error)
+	// Reset deletes the usage recorded under key
+	Reset(ctx context.Context, key string) error
+}
+
+// RedisLedger keeps the usage in Redis, so that it is shared by all the router instances
+type RedisLedger struct {
+	client *redis.Client
+}
+
+// NewRedisLedger creates a new RedisLedger
+func NewRedisLedger(client *redis.Client) *RedisLedger {
+	return &RedisLedger{client: client}
+}
+
+// Add implements Ledger interface
+func (l *RedisLedger) Add(ctx context.Context, key string, tokens int64, expireAt time.Time) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.IncrBy(ctx, key, tokens)
+		pipe.ExpireAt(ctx, key, expireAt)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to add usage of %s: %w", key, err)
+	}
+	return incr.Val(), nil
+}
+
+// Get implements Ledger interface
+func (l *RedisLedger) Get(ctx context.Context, key string) (int64, error) {
+	usage, err := l.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get usage of %s: %w", key, err)
+	}
+	return usage, nil
+}
+
+// Reset implements Ledger interface
+func (l *RedisLedger) Reset(ctx context.Context, key string) error {
+	if err := l.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to reset usage of %s: %w", key, err)
+	}
+	return nil
+}
+
+// FileLedger keeps the usage in memory, and writes it periodically and on shutdown to a JSON file of the router,
+// so that it survives the restarts of the router. The usage is only kept in memory if the ledger has no file.
+type FileLedger struct {
+	mutex sync.Mutex
+	path  string
+	// entries are the usage by key
+	entries map[string]*ledgerEntry
+	// dirty tells whether the usage changed since it was last written to the file
+	dirty bool
+	// flushMutex serializes the writes of the file, so that an older usage can't replace a newer one
+	flushMutex    sync.Mutex
+	flushInterval time.Duration
+	now           func() time.Time
+}
+
+type ledgerEntry struct {
+	Usage    int64     `json:"usage"`
+	ExpireAt time.Time `json:"expireAt"`
+}
+
+// NewFileLedger creates a new FileLedger keeping the usage in the file at path, loading the usage it already holds.
+// The usage is only kept in memory if path is empty.
+func NewFileLedger(path string) (*FileLedger, error) {
+	l := &FileLedger{
+		path:          path,
+		entries:       make(map[string]*ledgerEntry),
+		flushInterval: defaultFlushInterval,
+		now:           time.Now,
+	}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &l.entries); err != nil {
+			return nil, fmt.Errorf("failed to parse usage ledger %s: %w", path, err)
+		}
+	}
+	return l, nil
+}
+
+// Add implements Ledger interface
+func (l *FileLedger) Add(_ context.Context, key string, tokens int64, expireAt time.Time) (int64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// Like in Redis, usage which already expired is not kept, e.g. the usage settled after the end of its period
+	now := l.now()
+	if !now.Before(expireAt) {
+		return tokens, nil
+	}
+	// Expired usage is pruned on flush, until then it is replaced rather than added to
+	entry, ok := l.entries[key]
+	if !ok || !now.Before(entry.ExpireAt) {
+		entry = &ledgerEntry{}
+		l.entries[key] = entry
+	}
+	entry.Usage += tokens
+	entry.ExpireAt = expireAt
+	l.dirty = true
+	return entry.Usage, nil
+}
+
+// Get implements Ledger interface
+func (l *FileLedger) Get(_ context.Context, key string) (int64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok || !l.now().Before(entry.ExpireAt) {
+		return 0, nil
+	}
+	return entry.Usage, nil
+}
+
+// Reset implements Ledger interface
+func (l *FileLedger) Reset(_ context.Context, key string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.entries, key)
+	l.dirty = true
+	return nil
+}
+
+// Run writes the usage to the file of the ledger periodically until ctx is done, and once more then.
+// The expired usage is pruned on every flush, even if the ledger has no file.
+func (l *FileLedger) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.Flush(); err != nil {
+				klog.Errorf("failed to flush quota ledger: %v", err)
+			}
+		case <-ctx.Done():
+			if err := l.Flush(); err != nil {
+				klog.Errorf("failed to flush quota ledger on shutdown: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// Flush prunes the expired usage, and writes the usage to the file of the ledger if it changed since it was
+// last written
+func (l *FileLedger) Flush() error {
+	l.flushMutex.Lock()
+	defer l.flushMutex.Unlock()
+
+	l.mutex.Lock()
+	l.prune()
+	if l.path == "" || !l.dirty {
+		l.mutex.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(l.entries)
+	l.dirty = false
+	l.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := l.save(data); err != nil {
+		// Write the usage again on the next flush
+		l.mutex.Lock()
+		l.dirty = true
+		l.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// prune deletes the expired usage
+func (l *FileLedger) prune() {
+	now := l.now()
+	for key, entry := range l.entries {
+		if !now.Before(entry.ExpireAt) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// save writes data to the file of the ledger. The file is replaced atomically, so that a crash of the
+// router can't leave it half written.
+func (l *FileLedger) save(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	return nil
+}
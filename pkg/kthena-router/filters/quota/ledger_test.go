@@ -0,0 +1,168 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLedger(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "quota.json")
+	expireAt := time.Now().Add(time.Hour)
+
+	ledger, err := NewFileLedger(path)
+	require.NoError(t, err)
+	usage, err := ledger.Add(ctx, "kthena:quota:model-a:2026-10", 100, expireAt)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), usage)
+	usage, err = ledger.Add(ctx, "kthena:quota:model-a:2026-10", 50, expireAt)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), usage)
+	_, err = ledger.Add(ctx, "kthena:quota:model-b:2026-10", 10, expireAt)
+	assert.NoError(t, err)
+
+	// The usage is only written to the file when the ledger is flushed
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	require.NoError(t, ledger.Flush())
+
+	// The usage survives the restarts of the router
+	ledger, err = NewFileLedger(path)
+	require.NoError(t, err)
+	usage, err = ledger.Get(ctx, "kthena:quota:model-a:2026-10")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), usage)
+
+	assert.NoError(t, ledger.Reset(ctx, "kthena:quota:model-a:2026-10"))
+	require.NoError(t, ledger.Flush())
+	ledger, err = NewFileLedger(path)
+	require.NoError(t, err)
+	usage, err = ledger.Get(ctx, "kthena:quota:model-a:2026-10")
+	assert.NoError(t, err)
+	assert.Zero(t, usage)
+	usage, err = ledger.Get(ctx, "kthena:quota:model-b:2026-10")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), usage)
+
+	// No temporary file is left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFileLedger_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	ledger, err := NewFileLedger(path)
+	require.NoError(t, err)
+	ledger.flushInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ledger.Run(ctx)
+	}()
+
+	// The usage is written periodically
+	_, err = ledger.Add(ctx, "kthena:quota:model-a:2026-10", 100, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// And once more on shutdown
+	_, err = ledger.Add(context.Background(), "kthena:quota:model-a:2026-10", 50, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	cancel()
+	<-done
+
+	ledger, err = NewFileLedger(path)
+	require.NoError(t, err)
+	usage, err := ledger.Get(context.Background(), "kthena:quota:model-a:2026-10")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), usage)
+}
+
+func TestFileLedger_Expire(t *testing.T) {
+	ctx := context.Background()
+	ledger, err := NewFileLedger("")
+	require.NoError(t, err)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	ledger.now = func() time.Time { return now }
+
+	_, err = ledger.Add(ctx, "old", 100, now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	usage, err := ledger.Get(ctx, "old")
+	assert.NoError(t, err)
+	assert.Zero(t, usage)
+	// Expired usage is not added to
+	usage, err = ledger.Add(ctx, "old", 10, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), usage)
+
+	// Expired usage is pruned on flush
+	_, err = ledger.Add(ctx, "new", 1, now.Add(time.Hour))
+	assert.NoError(t, err)
+	now = now.Add(time.Hour)
+	assert.Contains(t, ledger.entries, "new")
+	assert.NoError(t, ledger.Flush())
+	assert.NotContains(t, ledger.entries, "new")
+	assert.NotContains(t, ledger.entries, "old")
+}
+
+func TestFileLedger_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+
+	_, err := NewFileLedger(path)
+	assert.Error(t, err)
+}
+
+func TestRedisLedger(t *testing.T) {
+	ctx := context.Background()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ledger := NewRedisLedger(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	usage, err := ledger.Get(ctx, "kthena:quota:model-a:2026-10")
+	assert.NoError(t, err)
+	assert.Zero(t, usage)
+
+	_, err = ledger.Add(ctx, "kthena:quota:model-a:2026-10", 100, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	usage, err = ledger.Add(ctx, "kthena:quota:model-a:2026-10", 50, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), usage)
+	assert.InDelta(t, time.Hour.Seconds(), mr.TTL("kthena:quota:model-a:2026-10").Seconds(), 5)
+
+	assert.NoError(t, ledger.Reset(ctx, "kthena:quota:model-a:2026-10"))
+	assert.False(t, mr.Exists("kthena:quota:model-a:2026-10"))
+}
@@ -0,0 +1,243 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"k8s.io/klog/v2"
+
+	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+// keyPrefix is the prefix of the ledger keys of quotas
+const keyPrefix = "kthena:quota"
+
+// ErrNoQuota is returned when the model has no quota
+var ErrNoQuota = errors.New("model has no quota")
+
+// QuotaExceededError is returned when the hard budget of a quota is consumed
+type QuotaExceededError struct {
+	// RetryAfter is how long it takes for the quota to be reset
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return "token quota exceeded"
+}
+
+// Status is the state of the quota of a key
+type Status struct {
+	Period networkingv1alpha1.QuotaPeriod
+	// Used is the number of tokens consumed during the current period
+	Used int64
+	// Limit is the hard budget of tokens per period, 0 if there is none
+	Limit int64
+	// SoftLimit is the soft budget of tokens per period, 0 if there is none
+	SoftLimit int64
+	// Reset is how long it takes for the current period to end
+	Reset time.Duration
+}
+
+// Exceeded reports whether the hard budget is consumed
+func (s *Status) Exceeded() bool {
+	return s.Limit > 0 && s.Used >= s.Limit
+}
+
+// SoftExceeded reports whether the soft budget is consumed
+func (s *Status) SoftExceeded() bool {
+	return s.SoftLimit > 0 && s.Used >= s.SoftLimit
+}
+
+// Manager enforces the token quotas of models, persisting their usage in ledgers
+type Manager struct {
+	mutex sync.RWMutex
+
+	// models are the quotas of each model
+	models map[string]*modelQuota
+
+	// fileLedger keeps the usage of the quotas without global rate limiting
+	fileLedger Ledger
+
+	now func() time.Time
+}
+
+type modelQuota struct {
+	quota  *networkingv1alpha1.TokenQuota
+	ledger Ledger
+}
+
+// NewManager creates a new Manager keeping the usage of the quotas without global rate limiting in fileLedger
+func NewManager(fileLedger Ledger) *Manager {
+	return &Manager{
+		models:     make(map[string]*modelQuota),
+		fileLedger: fileLedger,
+		now:        time.Now,
+	}
+}
+
+// AddOrUpdateQuota adds or updates the quota of a model from its rate limit, or deletes it if the rate limit has none.
+// redisClient is the client of the global rate limit of the model, which the usage is kept in, or nil without
+// global rate limiting. It is owned by the rate limiter.
+func (m *Manager) AddOrUpdateQuota(model string, rateLimit *networkingv1alpha1.RateLimit, redisClient *redis.Client) {
+	if rateLimit == nil || rateLimit.Quota == nil {
+		m.DeleteQuota(model)
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ledger := m.fileLedger
+	if redisClient != nil {
+		ledger = NewRedisLedger(redisClient)
+	}
+	m.models[model] = &modelQuota{quota: rateLimit.Quota, ledger: ledger}
+}
+
+// DeleteQuota deletes the quota of a model. The usage already recorded is kept until the end of its period.
+func (m *Manager) DeleteQuota(model string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.models, model)
+}
+
+// Reservation is the tokens reserved for a request in the usage of a quota, until it is settled
+type Reservation struct {
+	ledger    Ledger
+	ledgerKey string
+	expireAt  time.Time
+	tokens    int64
+}
+
+// Reserve adds the tokens a request of key is expected to consume to the usage of the quota of model, and returns
+// the reservation with the state of the quota, or a nil reservation if model has no quota. Reserving the tokens
+// at admission makes concurrent requests count against the budget before they complete, so that they can't
+// exceed it together. It returns a QuotaExceededError and reserves nothing if the hard budget of the quota can't
+// hold the tokens. Requests are admitted if the ledger is unavailable, so that it does not stop serving.
+func (m *Manager) Reserve(ctx context.Context, model, key string, tokens int64) (*Reservation, *Status, error) {
+	q := m.getQuota(model)
+	if q == nil {
+		return nil, nil, nil
+	}
+	tokens = max(tokens, 0)
+	now := m.now()
+	ledgerKey, end := q.ledgerKey(model, key, now)
+	used, err := q.ledger.Add(ctx, ledgerKey, tokens, end)
+	if err != nil {
+		klog.Errorf("failed to reserve usage of model %s: %v", model, err)
+		return nil, nil, nil
+	}
+
+	status := q.status(used, end.Sub(now))
+	// The budget is consumed, or can't hold the tokens of the request
+	if status.Limit > 0 && (used > status.Limit || used-tokens >= status.Limit) {
+		if tokens > 0 {
+			if _, err := q.ledger.Add(ctx, ledgerKey, -tokens, end); err != nil {
+				klog.Errorf("failed to release usage of model %s: %v", model, err)
+			}
+		}
+		status.Used -= tokens
+		return nil, status, &QuotaExceededError{RetryAfter: status.Reset}
+	}
+	return &Reservation{ledger: q.ledger, ledgerKey: ledgerKey, expireAt: end, tokens: tokens}, status, nil
+}
+
+// Settle replaces the tokens reserved for a request with the ones it consumed once it completes, 0 if it failed.
+// The usage is settled in the period the tokens were reserved in. It does nothing on a nil reservation.
+func (r *Reservation) Settle(ctx context.Context, used int64) {
+	if r == nil || used == r.tokens {
+		return
+	}
+	if _, err := r.ledger.Add(ctx, r.ledgerKey, used-r.tokens, r.expireAt); err != nil {
+		klog.Errorf("failed to settle usage of %s: %v", r.ledgerKey, err)
+	}
+}
+
+// Usage returns the state of the quota of key for model, or ErrNoQuota if model has no quota
+func (m *Manager) Usage(ctx context.Context, model, key string) (*Status, error) {
+	q := m.getQuota(model)
+	if q == nil {
+		return nil, ErrNoQuota
+	}
+	now := m.now()
+	ledgerKey, end := q.ledgerKey(model, key, now)
+	used, err := q.ledger.Get(ctx, ledgerKey)
+	if err != nil {
+		return nil, err
+	}
+	return q.status(used, end.Sub(now)), nil
+}
+
+// Reset resets the usage of the quota of key for model in the current period, or returns ErrNoQuota if model has no quota
+func (m *Manager) Reset(ctx context.Context, model, key string) error {
+	q := m.getQuota(model)
+	if q == nil {
+		return ErrNoQuota
+	}
+	ledgerKey, _ := q.ledgerKey(model, key, m.now())
+	return q.ledger.Reset(ctx, ledgerKey)
+}
+
+func (m *Manager) getQuota(model string) *modelQuota {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.models[model]
+}
+
+// status returns the state of the quota with the given usage, whose period ends in reset
+func (q *modelQuota) status(used int64, reset time.Duration) *Status {
+	status := &Status{
+		Period: q.quota.Period,
+		Used:   used,
+		Reset:  reset,
+	}
+	if q.quota.TokensPerPeriod != nil {
+		status.Limit = *q.quota.TokensPerPeriod
+	}
+	if q.quota.SoftTokensPerPeriod != nil {
+		status.SoftLimit = *q.quota.SoftTokensPerPeriod
+	}
+	return status
+}
+
+// ledgerKey returns the ledger key of the usage of key for model in the period of now, and the end of the period.
+// Each period has its own ledger key, so that the usage is reset at the start of each period.
+func (q *modelQuota) ledgerKey(model, key string, now time.Time) (string, time.Time) {
+	now = now.UTC()
+	var period string
+	var end time.Time
+	switch q.quota.Period {
+	case networkingv1alpha1.QuotaDay:
+		period = now.Format(time.DateOnly)
+		end = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	default:
+		period = now.Format("2006-01")
+		end = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if key == "" {
+		return fmt.Sprintf("%s:%s:%s", keyPrefix, model, period), end
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", keyPrefix, model, key, period), end
+}
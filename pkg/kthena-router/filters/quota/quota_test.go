@@ -0,0 +1,241 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+func newTestManager(t *testing.T, now *time.Time) *Manager {
+	ledger, err := NewFileLedger("")
+	require.NoError(t, err)
+	ledger.now = func() time.Time { return *now }
+	m := NewManager(ledger)
+	m.now = func() time.Time { return *now }
+	return m
+}
+
+// record reserves tokens for a request of key and settles them, the way the router does for a request which
+// consumes them
+func record(t *testing.T, m *Manager, model, key string, tokens int64) {
+	reservation, _, err := m.Reserve(context.Background(), model, key, tokens)
+	require.NoError(t, err)
+	reservation.Settle(context.Background(), tokens)
+}
+
+func TestManager_Reserve(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	m := newTestManager(t, &now)
+	hard, soft := int64(1000), int64(800)
+	m.AddOrUpdateQuota("test-model", &networkingv1alpha1.RateLimit{
+		Quota: &networkingv1alpha1.TokenQuota{
+			Period:              networkingv1alpha1.QuotaMonth,
+			TokensPerPeriod:     &hard,
+			SoftTokensPerPeriod: &soft,
+		},
+	}, nil)
+
+	reservation, status, err := m.Reserve(ctx, "test-model", "", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, &Status{
+		Period:    networkingv1alpha1.QuotaMonth,
+		Used:      100,
+		Limit:     1000,
+		SoftLimit: 800,
+		Reset:     time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC).Sub(now),
+	}, status)
+
+	// The reserved tokens count against the budget until the request completes
+	_, _, err = m.Reserve(ctx, "test-model", "", 901)
+	assert.IsType(t, &QuotaExceededError{}, err)
+	// The difference is settled once it completes
+	reservation.Settle(ctx, 20)
+	status, err = m.Usage(ctx, "test-model", "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), status.Used)
+
+	// Requests are still admitted beyond the soft budget
+	record(t, m, "test-model", "", 880)
+	_, status, err = m.Reserve(ctx, "test-model", "", 0)
+	assert.NoError(t, err)
+	assert.True(t, status.SoftExceeded())
+	assert.False(t, status.Exceeded())
+
+	// Requests are rejected if the hard budget can't hold their tokens, and reserve nothing
+	_, status, err = m.Reserve(ctx, "test-model", "", 101)
+	assert.Equal(t, &QuotaExceededError{RetryAfter: status.Reset}, err)
+	assert.Equal(t, int64(900), status.Used)
+
+	// Requests are rejected once the hard budget is consumed
+	record(t, m, "test-model", "", 100)
+	_, status, err = m.Reserve(ctx, "test-model", "", 0)
+	assert.Equal(t, &QuotaExceededError{RetryAfter: status.Reset}, err)
+	assert.Equal(t, int64(1000), status.Used)
+
+	// The usage is reset at the start of the next period
+	now = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	_, status, err = m.Reserve(ctx, "test-model", "", 0)
+	assert.NoError(t, err)
+	assert.Zero(t, status.Used)
+}
+
+func TestManager_ReserveConcurrent(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	m := newTestManager(t, &now)
+	hard := int64(1000)
+	m.AddOrUpdateQuota("test-model", &networkingv1alpha1.RateLimit{
+		Quota: &networkingv1alpha1.TokenQuota{Period: networkingv1alpha1.QuotaMonth, TokensPerPeriod: &hard},
+	}, nil)
+
+	// Concurrent requests can't exceed the budget together
+	var admitted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := m.Reserve(context.Background(), "test-model", "", 100); err == nil {
+				admitted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(10), admitted.Load())
+}
+
+func TestManager_SettleFailedRequest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 31, 23, 59, 0, 0, time.UTC)
+	m := newTestManager(t, &now)
+	hard := int64(1000)
+	m.AddOrUpdateQuota("test-model", &networkingv1alpha1.RateLimit{
+		Quota: &networkingv1alpha1.TokenQuota{Period: networkingv1alpha1.QuotaMonth, TokensPerPeriod: &hard},
+	}, nil)
+
+	// A failed request completing in the next period does not refund its reservation to the next period
+	reservation, _, err := m.Reserve(ctx, "test-model", "", 100)
+	assert.NoError(t, err)
+	now = time.Date(2026, 11, 1, 0, 1, 0, 0, time.UTC)
+	reservation.Settle(ctx, 0)
+	status, err := m.Usage(ctx, "test-model", "")
+	assert.NoError(t, err)
+	assert.Zero(t, status.Used)
+
+	// A failed request consumes nothing
+	reservation, _, err = m.Reserve(ctx, "test-model", "", 100)
+	assert.NoError(t, err)
+	reservation.Settle(ctx, 0)
+	status, err = m.Usage(ctx, "test-model", "")
+	assert.NoError(t, err)
+	assert.Zero(t, status.Used)
+
+	// Settling a nil reservation, of a model without quota, does nothing
+	var none *Reservation
+	none.Settle(ctx, 100)
+}
+
+func TestManager_DailyKeyed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC)
+	m := newTestManager(t, &now)
+	hard := int64(100)
+	m.AddOrUpdateQuota("test-model", &networkingv1alpha1.RateLimit{
+		Key:   &networkingv1alpha1.RateLimitKey{Source: networkingv1alpha1.RateLimitKeyUser},
+		Quota: &networkingv1alpha1.TokenQuota{Period: networkingv1alpha1.QuotaDay, TokensPerPeriod: &hard},
+	}, nil)
+
+	record(t, m, "test-model", "user-a", 100)
+	_, _, err := m.Reserve(ctx, "test-model", "user-a", 1)
+	assert.IsType(t, &QuotaExceededError{}, err)
+	assert.Equal(t, time.Hour, err.(*QuotaExceededError).RetryAfter)
+	// Other keys are not affected by the usage of user-a
+	_, _, err = m.Reserve(ctx, "test-model", "user-b", 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Reset(ctx, "test-model", "user-a"))
+	_, _, err = m.Reserve(ctx, "test-model", "user-a", 1)
+	assert.NoError(t, err)
+}
+
+func TestManager_NoQuota(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	m := newTestManager(t, &now)
+
+	reservation, status, err := m.Reserve(ctx, "test-model", "", 100)
+	assert.NoError(t, err)
+	assert.Nil(t, reservation)
+	assert.Nil(t, status)
+	_, err = m.Usage(ctx, "test-model", "")
+	assert.ErrorIs(t, err, ErrNoQuota)
+	assert.ErrorIs(t, m.Reset(ctx, "test-model", ""), ErrNoQuota)
+
+	// Removing the quota from the rate limit deletes it
+	hard := int64(100)
+	m.AddOrUpdateQuota("test-model", &networkingv1alpha1.RateLimit{
+		Quota: &networkingv1alpha1.TokenQuota{Period: networkingv1alpha1.QuotaDay, TokensPerPeriod: &hard},
+	}, nil)
+	m.AddOrUpdateQuota("test-model", &networkingv1alpha1.RateLimit{}, nil)
+	_, err = m.Usage(ctx, "test-model", "")
+	assert.ErrorIs(t, err, ErrNoQuota)
+}
+
+func TestManager_Global(t *testing.T) {
+	ctx := context.Background()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	hard := int64(100)
+	rateLimit := &networkingv1alpha1.RateLimit{
+		Global: &networkingv1alpha1.GlobalRateLimit{
+			Redis: &networkingv1alpha1.RedisConfig{Address: mr.Addr()},
+		},
+		Quota: &networkingv1alpha1.TokenQuota{Period: networkingv1alpha1.QuotaMonth, TokensPerPeriod: &hard},
+	}
+
+	// The usage recorded by a router instance counts against the quota of the others
+	now := time.Now()
+	m := newTestManager(t, &now)
+	m.AddOrUpdateQuota("test-model", rateLimit, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	other := newTestManager(t, &now)
+	other.AddOrUpdateQuota("test-model", rateLimit, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	record(t, m, "test-model", "", 100)
+	_, _, err = other.Reserve(ctx, "test-model", "", 1)
+	assert.IsType(t, &QuotaExceededError{}, err)
+	assert.True(t, mr.Exists("kthena:quota:test-model:"+now.UTC().Format("2006-01")))
+
+	// Requests are admitted when the ledger is unavailable
+	mr.Close()
+	reservation, status, err := other.Reserve(ctx, "test-model", "", 1)
+	assert.NoError(t, err)
+	assert.Nil(t, reservation)
+	assert.Nil(t, status)
+}
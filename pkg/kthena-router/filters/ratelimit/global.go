@@ -19,9 +19,12 @@ package ratelimit
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"golang.org/x/time/rate"
 	"k8s.io/klog/v2"
 
 	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
@@ -76,18 +79,27 @@ type GlobalRateLimiter struct {
 	client    *redis.Client
 	keyPrefix string
 	modelName string
+	// key is the rate limit key of the bucket, empty when the rate limit of the model is not keyed
+	key       string
 	tokenType string
 	limit     uint32
 	unit      networkingv1alpha1.RateLimitUnit
 	burst     int
+
+	// mutex guards the state of the token bucket last returned by Redis
+	mutex sync.Mutex
+	// tokens is the number of tokens of the token bucket last returned by Redis, at observedAt
+	tokens     float64
+	observedAt time.Time
 }
 
 // NewGlobalRateLimiter creates a new GlobalRateLimiter instance
-func NewGlobalRateLimiter(client *redis.Client, keyPrefix, modelName, tokenType string, limit uint32, unit networkingv1alpha1.RateLimitUnit) *GlobalRateLimiter {
+func NewGlobalRateLimiter(client *redis.Client, keyPrefix, modelName, key, tokenType string, limit uint32, unit networkingv1alpha1.RateLimitUnit) *GlobalRateLimiter {
 	return &GlobalRateLimiter{
 		client:    client,
 		keyPrefix: keyPrefix,
 		modelName: modelName,
+		key:       key,
 		tokenType: tokenType,
 		limit:     limit,
 		unit:      unit,
@@ -97,7 +109,7 @@ func NewGlobalRateLimiter(client *redis.Client, keyPrefix, modelName, tokenType
 
 // AllowN implements Limiter interface using token bucket algorithm
 func (g *GlobalRateLimiter) AllowN(now time.Time, n int) bool {
-	key := fmt.Sprintf("%s:%s:%s", g.keyPrefix, g.modelName, g.tokenType)
+	key := g.bucketKey()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -146,13 +158,15 @@ func (g *GlobalRateLimiter) AllowN(now time.Time, n int) bool {
 			redis.call('hset', key, 'tokens', current_tokens, 'last_update', current_time)
 			redis.call('expire', key, expire_seconds)
 			
-			return 1 -- Return 1 to indicate request is allowed
+			-- Return 1 to indicate request is allowed, and the tokens left as a string to keep their fraction
+			return {1, tostring(current_tokens)}
 		else
 			-- Insufficient tokens: reject request, but still update bucket state for time synchronization
 			redis.call('hset', key, 'tokens', current_tokens, 'last_update', current_time)
 			redis.call('expire', key, expire_seconds)
 			
-			return 0 -- Return 0 to indicate request is rate limited
+			-- Return 0 to indicate request is rate limited, and the tokens left
+			return {0, tostring(current_tokens)}
 		end
 	`
 
@@ -167,15 +181,73 @@ func (g *GlobalRateLimiter) AllowN(now time.Time, n int) bool {
 		return false
 	}
 
-	allowed, ok := result.Val().(int64)
+	values, ok := result.Val().([]interface{})
+	if !ok || len(values) != 2 {
+		klog.Errorf("unexpected result from lua script: %v", result.Val())
+		return false
+	}
+	allowed, ok := values[0].(int64)
 	if !ok {
-		klog.Errorf("unexpected result type from lua script: %T", result.Val())
+		klog.Errorf("unexpected result type from lua script: %T", values[0])
 		return false
 	}
+	g.observe(values[1])
 
 	return allowed == 1
 }
 
+// Remaining implements Limiter interface, it returns the number of tokens of the token bucket the last time
+// Redis returned them, refilled since then. The tokens consumed by other routers since then are not accounted
+// for. The bucket is assumed to be full if this router never used it.
+func (g *GlobalRateLimiter) Remaining(now time.Time) float64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.observedAt.IsZero() {
+		return float64(g.burst)
+	}
+	elapsed := max(now.Sub(g.observedAt).Seconds(), 0)
+	return min(float64(g.burst), g.tokens+elapsed*g.getRefillRate())
+}
+
+// observe records the number of tokens of the token bucket returned by a lua script, and returns it
+func (g *GlobalRateLimiter) observe(value interface{}) (float64, bool) {
+	s, ok := value.(string)
+	if !ok {
+		klog.Errorf("unexpected tokens type from lua script: %T", value)
+		return 0, false
+	}
+	tokens, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		klog.Errorf("unexpected tokens from lua script: %v", err)
+		return 0, false
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.tokens = tokens
+	g.observedAt = time.Now()
+	return tokens, true
+}
+
+// bucketKey returns the Redis key of the token bucket
+func (g *GlobalRateLimiter) bucketKey() string {
+	if g.key == "" {
+		return fmt.Sprintf("%s:%s:%s", g.keyPrefix, g.modelName, g.tokenType)
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", g.keyPrefix, g.modelName, g.key, g.tokenType)
+}
+
+// Limit implements Limiter interface, it returns the token refill rate per second
+func (g *GlobalRateLimiter) Limit() rate.Limit {
+	return rate.Limit(g.getRefillRate())
+}
+
+// Burst implements Limiter interface, it returns the capacity of the token bucket
+func (g *GlobalRateLimiter) Burst() int {
+	return g.burst
+}
+
 // getRefillRate calculates the token refill rate per second
 func (g *GlobalRateLimiter) getRefillRate() float64 {
 	duration := getTimeUnitDuration(g.unit)
@@ -222,7 +294,7 @@ func (g *GlobalRateLimiter) getExpireSeconds() int {
 
 // Tokens returns the estimated number of tokens currently available
 func (g *GlobalRateLimiter) Tokens() float64 {
-	key := fmt.Sprintf("%s:%s:%s", g.keyPrefix, g.modelName, g.tokenType)
+	key := g.bucketKey()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -263,30 +335,125 @@ func (g *GlobalRateLimiter) Tokens() float64 {
 		redis.call('hset', key, 'tokens', available_tokens, 'last_update', current_time)
 		redis.call('expire', key, expire_seconds)
 		
-		-- Return the number of tokens currently available in the bucket
-		return available_tokens
+		-- Return the number of tokens currently available in the bucket, as a string to keep their fraction
+		return tostring(available_tokens)
 	`
 
 	refillRate := g.getRefillRate()
 	expireSeconds := g.getExpireSeconds()
-	currentTime := float64(time.Now().Unix()) + float64(time.Now().Nanosecond())/1e9
 
-	result := g.client.Eval(ctx, luaScript, []string{key}, g.burst, refillRate, currentTime, expireSeconds)
+	result := g.client.Eval(ctx, luaScript, []string{key}, g.burst, refillRate, expireSeconds)
 
 	if result.Err() != nil {
 		klog.Errorf("failed to execute tokens check lua script: %v", result.Err())
 		return 0
 	}
 
-	tokens, ok := result.Val().(float64)
+	tokens, ok := g.observe(result.Val())
 	if !ok {
-		// Try to convert from int64 to float64
-		if tokensInt, ok := result.Val().(int64); ok {
-			return float64(tokensInt)
-		}
-		klog.Errorf("unexpected result type from tokens lua script: %T", result.Val())
 		return 0
 	}
-
 	return tokens
 }
+
+// ConsumeN implements Limiter interface, it consumes n tokens from the token bucket even if they are
+// not available, in which case the bucket goes negative until it is refilled
+func (g *GlobalRateLimiter) ConsumeN(now time.Time, n int) {
+	if n <= 0 {
+		return
+	}
+	key := g.bucketKey()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Lua script to debit tokens unconditionally, e.g. the output tokens a request generated beyond the
+	// ones reserved for it. Unlike AllowN, the tokens are consumed even if the bucket does not hold them.
+	luaScript := `
+		-- Input parameters
+		local key = KEYS[1]                           -- Redis key name for the token bucket
+		local consumed_tokens = tonumber(ARGV[1])     -- Number of tokens taken from the bucket
+		local capacity = tonumber(ARGV[2])            -- Maximum capacity of the token bucket
+		local refill_rate = tonumber(ARGV[3])         -- Token refill rate (tokens per second)
+		local expire_seconds = tonumber(ARGV[4])      -- Expiration time for Redis key (seconds)
+		
+		-- Get current time from Redis for consistency across distributed systems
+		local time_result = redis.call('time')
+		local current_time = tonumber(time_result[1]) + tonumber(time_result[2]) / 1000000
+		
+		-- Get current token bucket state from Redis
+		-- If first access, use default values: tokens=capacity, last_update=current_time
+		local current_tokens = tonumber(redis.call('hget', key, 'tokens')) or capacity
+		local last_update = tonumber(redis.call('hget', key, 'last_update')) or current_time
+		
+		-- Refill the bucket based on elapsed time, then take the tokens, even below zero
+		local time_passed = math.max(0, current_time - last_update)
+		current_tokens = math.min(capacity, current_tokens + time_passed * refill_rate) - consumed_tokens
+		
+		redis.call('hset', key, 'tokens', current_tokens, 'last_update', current_time)
+		redis.call('expire', key, expire_seconds)
+		
+		return tostring(current_tokens)
+	`
+
+	refillRate := g.getRefillRate()
+	expireSeconds := g.getExpireSeconds()
+
+	result := g.client.Eval(ctx, luaScript, []string{key}, n, g.burst, refillRate, expireSeconds)
+	if result.Err() != nil {
+		klog.Errorf("failed to execute token consume lua script: %v", result.Err())
+		return
+	}
+	g.observe(result.Val())
+}
+
+// ReturnN implements Limiter interface, it gives n previously consumed tokens back to the token bucket
+func (g *GlobalRateLimiter) ReturnN(now time.Time, n int) {
+	if n <= 0 {
+		return
+	}
+	key := g.bucketKey()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Lua script to refund tokens to the token bucket, e.g. the output tokens reserved for a request
+	// which it did not use. The bucket is refilled first, so that the refunded tokens can't push it
+	// beyond its capacity.
+	luaScript := `
+		-- Input parameters
+		local key = KEYS[1]                           -- Redis key name for the token bucket
+		local returned_tokens = tonumber(ARGV[1])     -- Number of tokens given back to the bucket
+		local capacity = tonumber(ARGV[2])            -- Maximum capacity of the token bucket
+		local refill_rate = tonumber(ARGV[3])         -- Token refill rate (tokens per second)
+		local expire_seconds = tonumber(ARGV[4])      -- Expiration time for Redis key (seconds)
+		
+		-- Get current time from Redis for consistency across distributed systems
+		local time_result = redis.call('time')
+		local current_time = tonumber(time_result[1]) + tonumber(time_result[2]) / 1000000
+		
+		-- Get current token bucket state from Redis
+		-- If bucket doesn't exist (e.g. it expired), it is full and there is nothing to give back
+		local current_tokens = tonumber(redis.call('hget', key, 'tokens')) or capacity
+		local last_update = tonumber(redis.call('hget', key, 'last_update')) or current_time
+		
+		-- Refill the bucket based on elapsed time, then give the tokens back, without exceeding its capacity
+		local time_passed = math.max(0, current_time - last_update)
+		current_tokens = math.min(capacity, current_tokens + time_passed * refill_rate + returned_tokens)
+		
+		redis.call('hset', key, 'tokens', current_tokens, 'last_update', current_time)
+		redis.call('expire', key, expire_seconds)
+		
+		return tostring(current_tokens)
+	`
+
+	refillRate := g.getRefillRate()
+	expireSeconds := g.getExpireSeconds()
+
+	result := g.client.Eval(ctx, luaScript, []string{key}, n, g.burst, refillRate, expireSeconds)
+	if result.Err() != nil {
+		klog.Errorf("failed to execute token return lua script: %v", result.Err())
+		return
+	}
+	g.observe(result.Val())
+}
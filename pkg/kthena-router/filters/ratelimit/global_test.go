@@ -62,12 +62,12 @@ func TestTokenRateLimiter_Global(t *testing.T) {
 
 	// Should allow multiple requests within limit
 	for i := 0; i < 3; i++ {
-		err := rl.RateLimit(model, prompt)
+		err := admit(rl, model, "", prompt)
 		assert.NoError(t, err, "Request %d should be allowed", i)
 	}
 
 	// Should be rate limited after exceeding limit
-	err = rl.RateLimit(model, prompt)
+	err = admit(rl, model, "", prompt)
 	assert.Error(t, err, "Should be rate limited after exceeding limit")
 	assert.IsType(t, &InputRateLimitExceededError{}, err)
 }
@@ -106,18 +106,18 @@ func TestTokenRateLimiter_LocalVsGlobal(t *testing.T) {
 	require.NoError(t, err)
 
 	// Both should allow initial requests
-	err = rl.RateLimit(localModel, prompt)
+	err = admit(rl, localModel, "", prompt)
 	assert.NoError(t, err)
 
-	err = rl.RateLimit(globalModel, prompt)
+	err = admit(rl, globalModel, "", prompt)
 	assert.NoError(t, err)
 
 	// Use up local tokens
-	err = rl.RateLimit(localModel, prompt)
+	err = admit(rl, localModel, "", prompt)
 	assert.Error(t, err, "Local model should be rate limited")
 
 	// Use up global tokens
-	err = rl.RateLimit(globalModel, prompt)
+	err = admit(rl, globalModel, "", prompt)
 	assert.Error(t, err, "Global model should be rate limited")
 }
 
@@ -143,8 +143,8 @@ func TestTokenRateLimiter_OutputTokens(t *testing.T) {
 	require.NoError(t, err)
 
 	// Record output tokens (should not block since it's async)
-	rl.RecordOutputTokens(model, 25)
-	rl.RecordOutputTokens(model, 30) // Total: 55, over limit
+	chargeOutputTokens(rl, model, "", 25)
+	chargeOutputTokens(rl, model, "", 30) // Total: 55, over limit
 
 	// Give some time for async recording
 	time.Sleep(100 * time.Millisecond)
@@ -177,7 +177,7 @@ func TestTokenRateLimiter_GlobalDeleteLimiter(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify it works
-	err = rl.RateLimit(model, "test")
+	err = admit(rl, model, "", "test")
 	assert.NoError(t, err)
 
 	// Delete the limiter
@@ -185,7 +185,7 @@ func TestTokenRateLimiter_GlobalDeleteLimiter(t *testing.T) {
 
 	// Should now allow unlimited requests (no limiter configured)
 	for i := 0; i < 10; i++ {
-		err = rl.RateLimit(model, "test")
+		err = admit(rl, model, "", "test")
 		assert.NoError(t, err, "Request %d should be allowed after deletion", i)
 	}
 }
@@ -211,3 +211,110 @@ func TestTokenRateLimiter_RedisConnectionFailure(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to connect to redis")
 }
+
+func TestTokenRateLimiter_GlobalKeyed(t *testing.T) {
+	mr, redisConfig := setupMiniRedis(t)
+	defer mr.Close()
+
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	tokens := uint32(100)
+	premiumTokens := uint32(300)
+
+	config := &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit: &tokens,
+		Unit:               networkingv1alpha1.Minute,
+		Global: &networkingv1alpha1.GlobalRateLimit{
+			Redis: redisConfig,
+		},
+		Key: &networkingv1alpha1.RateLimitKey{Source: networkingv1alpha1.RateLimitKeyUser},
+		Overrides: []networkingv1alpha1.RateLimitOverride{
+			{Key: "user-premium", InputTokensPerUnit: &premiumTokens},
+		},
+	}
+	require.NoError(t, rl.AddOrUpdateLimiter(model, config))
+
+	assert.NoError(t, reserveInput(rl, model, "user-a", 100))
+	assert.IsType(t, &InputRateLimitExceededError{}, reserveInput(rl, model, "user-a", 1))
+	assert.NoError(t, reserveInput(rl, model, "user-b", 100))
+	assert.NoError(t, reserveInput(rl, model, "user-premium", 300))
+	assert.IsType(t, &InputRateLimitExceededError{}, reserveInput(rl, model, "user-premium", 1))
+
+	// Each key has its own bucket in Redis
+	assert.True(t, mr.Exists("kthena:ratelimit:test-model:user-a:input"))
+	assert.True(t, mr.Exists("kthena:ratelimit:test-model:user-b:input"))
+	assert.False(t, mr.Exists("kthena:ratelimit:test-model:input"))
+
+	// The buckets are shared with the other router instances
+	other := NewTokenRateLimiter()
+	require.NoError(t, other.AddOrUpdateLimiter(model, config))
+	assert.IsType(t, &InputRateLimitExceededError{}, reserveInput(other, model, "user-b", 1))
+}
+
+func TestTokenRateLimiter_GlobalRequests(t *testing.T) {
+	mr, redisConfig := setupMiniRedis(t)
+	defer mr.Close()
+
+	model := "test-model"
+	requests := uint32(2)
+	config := &networkingv1alpha1.RateLimit{
+		RequestsPerUnit: &requests,
+		Unit:            networkingv1alpha1.Minute,
+		Global: &networkingv1alpha1.GlobalRateLimit{
+			Redis: redisConfig,
+		},
+	}
+
+	// The requests of all the router instances count against the same limit
+	rl := NewTokenRateLimiter()
+	require.NoError(t, rl.AddOrUpdateLimiter(model, config))
+	other := NewTokenRateLimiter()
+	require.NoError(t, other.AddOrUpdateLimiter(model, config))
+
+	assert.NoError(t, reserveInput(rl, model, "", 1000))
+	assert.NoError(t, reserveInput(other, model, "", 1000))
+	assert.IsType(t, &RateLimitExceededError{}, reserveInput(rl, model, "", 1))
+	assert.True(t, mr.Exists("kthena:ratelimit:test-model:requests"))
+}
+
+func TestTokenRateLimiter_GlobalReserveOutputTokens(t *testing.T) {
+	mr, redisConfig := setupMiniRedis(t)
+	defer mr.Close()
+
+	model := "test-model"
+	outputTokens := uint32(2000)
+	config := &networkingv1alpha1.RateLimit{
+		OutputTokensPerUnit:   &outputTokens,
+		OutputTokensAdmission: networkingv1alpha1.OutputTokensReserve,
+		Unit:                  networkingv1alpha1.Hour,
+		Global: &networkingv1alpha1.GlobalRateLimit{
+			Redis: redisConfig,
+		},
+	}
+
+	// The reservations of all the router instances count against the same limit
+	rl := NewTokenRateLimiter()
+	require.NoError(t, rl.AddOrUpdateLimiter(model, config))
+	other := NewTokenRateLimiter()
+	require.NoError(t, other.AddOrUpdateLimiter(model, config))
+
+	reservation, err := rl.ReserveTokens(model, "", 10, 1500)
+	assert.NoError(t, err)
+	assert.Equal(t, 1500, reservation.Tokens())
+	// The default max tokens is reserved for requests without max_tokens
+	_, err = other.ReserveTokens(model, "", 10, 0)
+	assert.IsType(t, &OutputRateLimitExceededError{}, err)
+
+	// The refund of a router instance is available to the others
+	reservation.Settle(500)
+	otherReservation, err := other.ReserveTokens(model, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1024, otherReservation.Tokens())
+	assert.InDelta(t, 476, other.getLimiter(model, "").output.Tokens(), 1)
+
+	// The tokens used beyond the reservation are charged even if the limit is exhausted
+	otherReservation.Settle(2000)
+	assert.InDelta(t, -500, rl.getLimiter(model, "").output.Tokens(), 1)
+	_, err = rl.ReserveTokens(model, "", 10, 1)
+	assert.IsType(t, &OutputRateLimitExceededError{}, err)
+}
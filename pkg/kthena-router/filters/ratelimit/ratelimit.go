@@ -19,30 +19,40 @@ package ratelimit
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"golang.org/x/time/rate"
-	"k8s.io/klog/v2"
 
 	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
-	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/tokenizer"
 )
 
-type RateLimitExceededError struct{}
+// RateLimitExceededError is returned when the request rate limit is exceeded
+type RateLimitExceededError struct {
+	// RetryAfter is how long it takes for the limit to allow the request
+	RetryAfter time.Duration
+}
 
 func (e *RateLimitExceededError) Error() string {
-	return "rate limit exceeded"
+	return "request rate limit exceeded"
 }
 
-type InputRateLimitExceededError struct{}
+type InputRateLimitExceededError struct {
+	// RetryAfter is how long it takes for the limit to allow the input tokens of the request
+	RetryAfter time.Duration
+}
 
 func (e *InputRateLimitExceededError) Error() string {
 	return "input token rate limit exceeded"
 }
 
-type OutputRateLimitExceededError struct{}
+type OutputRateLimitExceededError struct {
+	// RetryAfter is how long it takes for the limit to allow a request
+	RetryAfter time.Duration
+}
 
 func (e *OutputRateLimitExceededError) Error() string {
 	return "output token rate limit exceeded"
@@ -55,20 +65,59 @@ type Limiter interface {
 	AllowN(now time.Time, n int) bool
 	// Tokens returns the number of tokens currently available
 	Tokens() float64
+	// Remaining returns the number of tokens available at now, as last known by the limiter. Unlike Tokens,
+	// it does not read any shared state, so it may not account for the tokens consumed by other routers.
+	Remaining(now time.Time) float64
+	// Limit returns the number of tokens refilled per second
+	Limit() rate.Limit
+	// Burst returns the maximum number of tokens available
+	Burst() int
+	// ReturnN gives n previously consumed tokens back, up to the maximum number of tokens available
+	ReturnN(now time.Time, n int)
+	// ConsumeN consumes n tokens even if they are not available, in which case the tokens available go negative
+	// and the limiter does not allow any token until they are refilled
+	ConsumeN(now time.Time, n int)
 }
 
-// TokenRateLimiter provides rate limiting functionality for both input and output tokens
+// TokenRateLimiter provides rate limiting functionality for input tokens, output tokens and requests
 type TokenRateLimiter struct {
 	mutex sync.RWMutex
 
-	// Unified rate limiters using Limiter interface
-	inputLimiter  map[string]Limiter
-	outputLimiter map[string]Limiter
+	// models are the rate limiters of each model
+	models map[string]*modelLimiter
 
 	// Redis client for global rate limiting
 	redisClient *redis.Client
+}
+
+// maxTrackedKeys bounds the number of keys whose limiters are kept for a model. The limiters of the least
+// recently used keys are dropped beyond it, which resets the budget of these keys for local rate limiting.
+const maxTrackedKeys = 10000
+
+// defaultMaxTokens is the number of output tokens reserved for the requests which do not set max_tokens,
+// when the rate limit does not configure it
+const defaultMaxTokens = 1024
 
-	tokenizer tokenizer.Tokenizer
+// modelLimiter holds the rate limiters of a model
+type modelLimiter struct {
+	model       string
+	config      *networkingv1alpha1.RateLimit
+	redisClient *redis.Client
+	// overrides are the overrides of the config by key
+	overrides map[string]*networkingv1alpha1.RateLimitOverride
+	// keys are the limiters of each key. The limiters shared by all the requests of a model whose rate limit
+	// is not keyed are the ones of the empty key.
+	keys *lru.Cache[string, *keyLimiter]
+}
+
+// keyLimiter holds the rate limiters of a key, a nil limiter means there is no limit
+type keyLimiter struct {
+	input    Limiter
+	output   Limiter
+	requests Limiter
+	// maxTokens is the number of output tokens reserved for the requests which do not set max_tokens,
+	// 0 if the output tokens of requests are not reserved at admission
+	maxTokens int
 }
 
 // LocalLimiter wraps golang.org/x/time/rate.Limiter to implement our Limiter interface
@@ -88,52 +137,229 @@ func (l *LocalLimiter) Tokens() float64 {
 	return l.Limiter.Tokens()
 }
 
+// Remaining returns the number of tokens available at now
+func (l *LocalLimiter) Remaining(now time.Time) float64 {
+	return l.Limiter.TokensAt(now)
+}
+
+// ReturnN gives n previously consumed tokens back. The tokens available are capped at the burst
+// the next time the limiter is used.
+func (l *LocalLimiter) ReturnN(now time.Time, n int) {
+	if n > 0 {
+		l.Limiter.AllowN(now, -n)
+	}
+}
+
+// ConsumeN consumes n tokens even if they are not available. The reservations are never cancelled,
+// and are made by chunks since the limiter can't reserve more than its burst at once.
+func (l *LocalLimiter) ConsumeN(now time.Time, n int) {
+	burst := l.Burst()
+	for n > 0 && burst > 0 {
+		chunk := min(n, burst)
+		l.Limiter.ReserveN(now, chunk)
+		n -= chunk
+	}
+}
+
 // NewTokenRateLimiter creates a new TokenRateLimiter instance
 func NewTokenRateLimiter() *TokenRateLimiter {
 	return &TokenRateLimiter{
-		inputLimiter:  make(map[string]Limiter),
-		outputLimiter: make(map[string]Limiter),
-		tokenizer:     tokenizer.NewSimpleEstimateTokenizer(),
+		models: make(map[string]*modelLimiter),
 	}
 }
 
-// RateLimit checks if the request is within rate limits for both input and output tokens
-func (r *TokenRateLimiter) RateLimit(model, prompt string) error {
-	// Estimate input tokens
-	tokens, err := r.tokenizer.CalculateTokenNum(prompt)
-	if err != nil {
-		klog.Errorf("failed to calculate token number: %v", err)
-		tokens = len(prompt) / 4 // fallback estimation
+// OutputReservation is the output tokens reserved for a request at admission, until they are settled.
+// It keeps the output limiter the request was admitted by, so that the request is settled against it even if
+// the limits of the model change in the meantime.
+type OutputReservation struct {
+	output Limiter
+	tokens int
+}
+
+// ReserveTokens checks if a request of key with the given number of input tokens is within rate limits, and
+// returns the output tokens reserved for it, or a nil reservation if the model has no output token limit.
+// Output tokens are only reserved when the rate limit of the model reserves them at admission: maxOutputTokens
+// are reserved, or the default max tokens if it is 0, up to the output token limit. The reservation must be
+// settled once the request completes.
+func (r *TokenRateLimiter) ReserveTokens(model, key string, inputTokens, maxOutputTokens int) (*OutputReservation, error) {
+	limiter := r.getLimiter(model, key)
+	if limiter == nil {
+		return nil, nil
 	}
 
-	r.mutex.RLock()
-	inputLimiter, hasInputLimit := r.inputLimiter[model]
-	outputLimiter, hasOutputLimit := r.outputLimiter[model]
-	r.mutex.RUnlock()
+	now := time.Now()
+	// A rejected request costs nothing: the requests and tokens taken by the limits checked before the one
+	// rejecting it are given back
+	rollback := func(requests, input bool) {
+		if requests && limiter.requests != nil {
+			limiter.requests.ReturnN(now, 1)
+		}
+		if input && limiter.input != nil {
+			limiter.input.ReturnN(now, inputTokens)
+		}
+	}
+
+	// Check request rate limit first
+	if limiter.requests != nil && !limiter.requests.AllowN(now, 1) {
+		return nil, &RateLimitExceededError{RetryAfter: retryAfter(limiter.requests, 1)}
+	}
 
 	// Check input token rate limit
-	if hasInputLimit && !inputLimiter.AllowN(time.Now(), tokens) {
-		return &InputRateLimitExceededError{}
+	if limiter.input != nil && !limiter.input.AllowN(now, inputTokens) {
+		rollback(true, false)
+		return nil, &InputRateLimitExceededError{RetryAfter: retryAfter(limiter.input, inputTokens)}
 	}
 
-	// Check output token rate limit - we conservatively check if there's at least 1 token available
-	// This prevents starting requests that likely won't be able to complete
-	if hasOutputLimit && outputLimiter.Tokens() < 1.0 {
-		return &OutputRateLimitExceededError{}
+	if limiter.output == nil {
+		return nil, nil
 	}
+	if limiter.maxTokens == 0 {
+		// Check output token rate limit - we conservatively check if there's at least 1 token available
+		// This prevents starting requests that likely won't be able to complete
+		if available := limiter.output.Tokens(); available < 1.0 {
+			rollback(true, true)
+			return nil, &OutputRateLimitExceededError{RetryAfter: refillDuration(limiter.output, 1-available)}
+		}
+		return &OutputReservation{output: limiter.output}, nil
+	}
+
+	// Reserve the output tokens the request may generate, capped by the limit so that large max_tokens
+	// can still be admitted once the limit is fully available
+	reserved := maxOutputTokens
+	if reserved <= 0 {
+		reserved = limiter.maxTokens
+	}
+	reserved = min(reserved, limiter.output.Burst())
+	if !limiter.output.AllowN(now, reserved) {
+		rollback(true, true)
+		return nil, &OutputRateLimitExceededError{RetryAfter: retryAfter(limiter.output, reserved)}
+	}
+	return &OutputReservation{output: limiter.output, tokens: reserved}, nil
+}
 
+// Tokens returns the number of output tokens reserved, 0 on a nil reservation.
+func (r *OutputReservation) Tokens() int {
+	if r == nil {
+		return 0
+	}
+	return r.tokens
+}
+
+// Settle charges the output tokens used by a request once it completes against the output tokens reserved
+// for it at admission: the unused tokens are refunded, and the tokens used beyond the reservation are consumed
+// even if they are not available, so that the next requests wait for them. It does nothing on a nil reservation.
+func (r *OutputReservation) Settle(used int) {
+	if r == nil {
+		return
+	}
+	switch {
+	case used < r.tokens:
+		r.output.ReturnN(time.Now(), r.tokens-used)
+	case used > r.tokens:
+		r.output.ConsumeN(time.Now(), used-r.tokens)
+	}
+}
+
+// RedisClient returns the Redis client of the global rate limit of model, or nil if it has no global rate limit.
+func (r *TokenRateLimiter) RedisClient(model string) *redis.Client {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if m, ok := r.models[model]; ok {
+		return m.redisClient
+	}
 	return nil
 }
 
-// RecordOutputTokens records the actual output tokens consumed after response generation
-func (r *TokenRateLimiter) RecordOutputTokens(model string, tokenCount int) {
+// Key returns the rate limit key of model, or nil if its rate limit is not keyed.
+func (r *TokenRateLimiter) Key(model string) *networkingv1alpha1.RateLimitKey {
 	r.mutex.RLock()
-	outputLimiter, exists := r.outputLimiter[model]
+	defer r.mutex.RUnlock()
+
+	if m, ok := r.models[model]; ok {
+		return m.config.Key
+	}
+	return nil
+}
+
+// KeyLabel returns the metric label of key for model. The cardinality of the label is bounded by the overrides
+// of the model: it is the key if it has an override, "default" for the other keys, or "" if the rate limit of
+// the model is not keyed.
+func (r *TokenRateLimiter) KeyLabel(model, key string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	m, ok := r.models[model]
+	if !ok || m.config.Key == nil {
+		return ""
+	}
+	if _, ok := m.overrides[key]; ok {
+		return key
+	}
+	return "default"
+}
+
+// getLimiter returns the limiters of key for model, creating them on first use, or nil if model has no rate limit.
+func (r *TokenRateLimiter) getLimiter(model, key string) *keyLimiter {
+	r.mutex.RLock()
+	m, ok := r.models[model]
 	r.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
 
-	if exists {
-		outputLimiter.AllowN(time.Now(), tokenCount)
+	if m.config.Key == nil {
+		key = ""
+	}
+	if limiter, ok := m.keys.Get(key); ok {
+		return limiter
 	}
+	limiter := m.newKeyLimiter(key)
+	// Another request of the key may have created its limiters in the meantime
+	if previous, ok, _ := m.keys.PeekOrAdd(key, limiter); ok {
+		return previous
+	}
+	return limiter
+}
+
+// newKeyLimiter creates the limiters of key, with the limits of its override if it has one.
+func (m *modelLimiter) newKeyLimiter(key string) *keyLimiter {
+	inputTokens, outputTokens, requests := m.config.InputTokensPerUnit, m.config.OutputTokensPerUnit, m.config.RequestsPerUnit
+	if override, ok := m.overrides[key]; ok {
+		if override.InputTokensPerUnit != nil {
+			inputTokens = override.InputTokensPerUnit
+		}
+		if override.OutputTokensPerUnit != nil {
+			outputTokens = override.OutputTokensPerUnit
+		}
+		if override.RequestsPerUnit != nil {
+			requests = override.RequestsPerUnit
+		}
+	}
+	limiter := &keyLimiter{
+		input:    m.newLimiter(key, "input", inputTokens),
+		output:   m.newLimiter(key, "output", outputTokens),
+		requests: m.newLimiter(key, "requests", requests),
+	}
+	if m.config.OutputTokensAdmission == networkingv1alpha1.OutputTokensReserve {
+		limiter.maxTokens = defaultMaxTokens
+		if m.config.DefaultMaxTokens != nil {
+			limiter.maxTokens = int(*m.config.DefaultMaxTokens)
+		}
+	}
+	return limiter
+}
+
+// newLimiter creates a global limiter if Redis is configured, or a local one otherwise. It returns nil if there is no limit.
+func (m *modelLimiter) newLimiter(key, tokenType string, limit *uint32) Limiter {
+	if limit == nil {
+		return nil
+	}
+	if m.redisClient != nil {
+		return NewGlobalRateLimiter(m.redisClient, "kthena:ratelimit", m.model, key, tokenType, *limit, m.config.Unit)
+	}
+	duration := getTimeUnitDuration(m.config.Unit)
+	return NewLocalLimiter(rate.Limit(float64(*limit)/duration.Seconds()), int(*limit))
 }
 
 // AddOrUpdateLimiter adds or updates rate limiter for a model
@@ -144,72 +370,62 @@ func (r *TokenRateLimiter) AddOrUpdateLimiter(model string, ratelimit *networkin
 	// Determine if we should use global or local rate limiting
 	useGlobal := ratelimit.Global != nil && ratelimit.Global.Redis != nil
 
-	if useGlobal {
-		// Initialize Redis client if not already done
-		if r.redisClient == nil {
-			r.redisClient = redis.NewClient(&redis.Options{
-				Addr: ratelimit.Global.Redis.Address,
-			})
-
-			// Test connection
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if err := r.redisClient.Ping(ctx).Err(); err != nil {
-				return fmt.Errorf("failed to connect to redis: %w", err)
-			}
-		}
-
-		// Create global rate limiters
-		if ratelimit.InputTokensPerUnit != nil {
-			r.inputLimiter[model] = NewGlobalRateLimiter(
-				r.redisClient,
-				"kthena:ratelimit",
-				model,
-				"input",
-				*ratelimit.InputTokensPerUnit,
-				ratelimit.Unit,
-			)
+	// Initialize Redis client if not already done
+	if useGlobal && r.redisClient == nil {
+		r.redisClient = redis.NewClient(&redis.Options{
+			Addr: ratelimit.Global.Redis.Address,
+		})
+
+		// Test connection
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.redisClient.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("failed to connect to redis: %w", err)
 		}
+	}
 
-		if ratelimit.OutputTokensPerUnit != nil {
-			r.outputLimiter[model] = NewGlobalRateLimiter(
-				r.redisClient,
-				"kthena:ratelimit",
-				model,
-				"output",
-				*ratelimit.OutputTokensPerUnit,
-				ratelimit.Unit,
-			)
-		}
+	// The limiters of the keys are kept when the limits did not change, e.g. when another field of the
+	// ModelRoute was updated, otherwise every update would give all the keys their full budget again.
+	// The modelLimiter is replaced rather than updated, as requests read it without holding the mutex.
+	var keys *lru.Cache[string, *keyLimiter]
+	if m, ok := r.models[model]; ok && sameLimits(m.config, ratelimit) {
+		keys = m.keys
 	} else {
-		// Create local rate limiters
-		duration := getTimeUnitDuration(ratelimit.Unit)
-
-		if ratelimit.InputTokensPerUnit != nil {
-			r.inputLimiter[model] = NewLocalLimiter(
-				rate.Limit(float64(*ratelimit.InputTokensPerUnit)/duration.Seconds()),
-				int(*ratelimit.InputTokensPerUnit),
-			)
-		}
-
-		if ratelimit.OutputTokensPerUnit != nil {
-			r.outputLimiter[model] = NewLocalLimiter(
-				rate.Limit(float64(*ratelimit.OutputTokensPerUnit)/duration.Seconds()),
-				int(*ratelimit.OutputTokensPerUnit),
-			)
+		var err error
+		if keys, err = lru.New[string, *keyLimiter](maxTrackedKeys); err != nil {
+			return err
 		}
 	}
+	m := &modelLimiter{
+		model:     model,
+		config:    ratelimit,
+		overrides: make(map[string]*networkingv1alpha1.RateLimitOverride, len(ratelimit.Overrides)),
+		keys:      keys,
+	}
+	if useGlobal {
+		m.redisClient = r.redisClient
+	}
+	for i := range ratelimit.Overrides {
+		m.overrides[ratelimit.Overrides[i].Key] = &ratelimit.Overrides[i]
+	}
+	r.models[model] = m
 
 	return nil
 }
 
+// sameLimits returns whether a and b configure the same limiters. The quota is not enforced by the limiters.
+func sameLimits(a, b *networkingv1alpha1.RateLimit) bool {
+	x, y := *a, *b
+	x.Quota, y.Quota = nil, nil
+	return reflect.DeepEqual(x, y)
+}
+
 // DeleteLimiter deletes rate limiter for a model
 func (r *TokenRateLimiter) DeleteLimiter(model string) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	delete(r.inputLimiter, model)
-	delete(r.outputLimiter, model)
+	delete(r.models, model)
 }
 
 func getTimeUnitDuration(unit networkingv1alpha1.RateLimitUnit) time.Duration {
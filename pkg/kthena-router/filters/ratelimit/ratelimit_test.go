@@ -17,12 +17,39 @@ limitations under the License.
 package ratelimit
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+
 	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/tokenizer"
 )
 
+// admit checks a request of key with prompt against the rate limits, the way the router does
+func admit(rl *TokenRateLimiter, model, key, prompt string) error {
+	tokens, err := tokenizer.NewSimpleEstimateTokenizer().CalculateTokenNum(prompt)
+	if err != nil {
+		return err
+	}
+	return reserveInput(rl, model, key, tokens)
+}
+
+// chargeOutputTokens charges the output tokens used by a request of key admitted without reserving any
+func chargeOutputTokens(rl *TokenRateLimiter, model, key string, used int) {
+	if limiter := rl.getLimiter(model, key); limiter != nil && limiter.output != nil {
+		(&OutputReservation{output: limiter.output}).Settle(used)
+	}
+}
+
+// reserveInput checks a request of key with the given number of input tokens against the rate limits
+func reserveInput(rl *TokenRateLimiter, model, key string, tokens int) error {
+	_, err := rl.ReserveTokens(model, key, tokens, 0)
+	return err
+}
+
 func TestTokenRateLimiter_Basic(t *testing.T) {
 	rl := NewTokenRateLimiter()
 	model := "test-model"
@@ -37,14 +64,14 @@ func TestTokenRateLimiter_Basic(t *testing.T) {
 
 	// Should allow up to 10 tokens immediately
 	for i := 0; i < 3; i++ {
-		err := rl.RateLimit(model, prompt)
+		err := admit(rl, model, "", prompt)
 		if err != nil {
 			t.Fatalf("unexpected error on allowed request: %v, %d", err, i)
 		}
 	}
 
 	// 4th request should be rate limited
-	err := rl.RateLimit(model, prompt)
+	err := admit(rl, model, "", prompt)
 	if err == nil {
 		t.Fatalf("expected rate limit error, got nil")
 	}
@@ -56,7 +83,7 @@ func TestTokenRateLimiter_Basic(t *testing.T) {
 func TestTokenRateLimiter_NoLimiter(t *testing.T) {
 	rl := NewTokenRateLimiter()
 	// No limiter added, should always allow
-	err := rl.RateLimit("unknown-model", "test")
+	err := admit(rl, "unknown-model", "", "test")
 	if err != nil {
 		t.Fatalf("expected nil error for unknown model, got %v", err)
 	}
@@ -76,13 +103,13 @@ func TestTokenRateLimiter_ResetAfterTime(t *testing.T) {
 
 	// Use up tokens
 	for i := 0; i < 3; i++ {
-		err := rl.RateLimit(model, prompt)
+		err := admit(rl, model, "", prompt)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	}
 	// Should be rate limited now
-	err := rl.RateLimit(model, prompt)
+	err := admit(rl, model, "", prompt)
 	if err == nil {
 		t.Fatalf("expected rate limit error, got nil")
 	}
@@ -92,7 +119,7 @@ func TestTokenRateLimiter_ResetAfterTime(t *testing.T) {
 
 	// Wait for refill
 	time.Sleep(1100 * time.Millisecond)
-	err = rl.RateLimit(model, prompt)
+	err = admit(rl, model, "", prompt)
 	if err != nil {
 		t.Fatalf("expected nil after refill, got %v", err)
 	}
@@ -110,12 +137,12 @@ func TestTokenRateLimiter_OutputTokenRecording(t *testing.T) {
 	})
 
 	// Record output tokens - this should not block/error
-	rl.RecordOutputTokens(model, 5)
-	rl.RecordOutputTokens(model, 3)
-	rl.RecordOutputTokens(model, 2) // Total: 10 tokens consumed
+	chargeOutputTokens(rl, model, "", 5)
+	chargeOutputTokens(rl, model, "", 3)
+	chargeOutputTokens(rl, model, "", 2) // Total: 10 tokens consumed
 
 	// Recording more tokens should still work (just consumes from the bucket)
-	rl.RecordOutputTokens(model, 1)
+	chargeOutputTokens(rl, model, "", 1)
 }
 
 func TestTokenRateLimiter_CombinedInputOutput(t *testing.T) {
@@ -133,15 +160,15 @@ func TestTokenRateLimiter_CombinedInputOutput(t *testing.T) {
 	})
 
 	// First request should be allowed
-	err := rl.RateLimit(model, prompt)
+	err := admit(rl, model, "", prompt)
 	if err != nil {
 		t.Fatalf("unexpected error on first request: %v", err)
 	}
 	// Record output tokens used
-	rl.RecordOutputTokens(model, 2)
+	chargeOutputTokens(rl, model, "", 2)
 
 	// Second request should be rate limited due to input token exhaustion
-	err = rl.RateLimit(model, prompt)
+	err = admit(rl, model, "", prompt)
 	if err == nil {
 		t.Fatalf("expected rate limit error after exhausting input tokens")
 	}
@@ -152,9 +179,11 @@ func TestTokenRateLimiter_CombinedInputOutput(t *testing.T) {
 
 func TestTokenRateLimiter_OutputNoLimiter(t *testing.T) {
 	rl := NewTokenRateLimiter()
-	// No limiter added, should not error when recording output tokens
-	rl.RecordOutputTokens("unknown-model", 100)
-	// RecordOutputTokens doesn't return error, just silently does nothing
+	// No limiter added, nothing is reserved and settling does nothing
+	reservation, err := rl.ReserveTokens("unknown-model", "", 10, 100)
+	assert.NoError(t, err)
+	assert.Nil(t, reservation)
+	reservation.Settle(100)
 }
 
 func TestTokenRateLimiter_DeleteLimiter(t *testing.T) {
@@ -171,12 +200,12 @@ func TestTokenRateLimiter_DeleteLimiter(t *testing.T) {
 	})
 
 	// Verify limiter exists and restricts
-	err := rl.RateLimit(model, "hello world") // ~3 tokens
+	err := admit(rl, model, "", "hello world") // ~3 tokens
 	if err != nil {
 		t.Fatalf("first request should be allowed: %v", err)
 	}
 
-	err = rl.RateLimit(model, "hello world") // Should be rate limited
+	err = admit(rl, model, "", "hello world") // Should be rate limited
 	if err == nil {
 		t.Fatalf("expected rate limit error")
 	}
@@ -186,14 +215,80 @@ func TestTokenRateLimiter_DeleteLimiter(t *testing.T) {
 
 	// Should now be unrestricted
 	for i := 0; i < 10; i++ {
-		err = rl.RateLimit(model, "hello world")
+		err = admit(rl, model, "", "hello world")
 		if err != nil {
 			t.Fatalf("expected nil after deletion, got %v", err)
 		}
 	}
 
 	// Recording output tokens should work without error
-	rl.RecordOutputTokens(model, 100)
+	chargeOutputTokens(rl, model, "", 100)
+}
+
+func TestTokenRateLimiter_UpdateLimiter(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	newRateLimit := func(inputTokens uint32, quota *networkingv1alpha1.TokenQuota) *networkingv1alpha1.RateLimit {
+		return &networkingv1alpha1.RateLimit{
+			InputTokensPerUnit: &inputTokens,
+			Unit:               networkingv1alpha1.Hour,
+			Key:                &networkingv1alpha1.RateLimitKey{Source: networkingv1alpha1.RateLimitKeyHeader, Header: "x-user-id"},
+			Overrides:          []networkingv1alpha1.RateLimitOverride{{Key: "user-premium", InputTokensPerUnit: &inputTokens}},
+			Quota:              quota,
+		}
+	}
+
+	assert.NoError(t, rl.AddOrUpdateLimiter(model, newRateLimit(100, nil)))
+	assert.NoError(t, reserveInput(rl, model, "user-a", 100))
+	assert.NoError(t, reserveInput(rl, model, "user-premium", 100))
+
+	// Updates which do not change the limits keep the budgets of the keys, in a new modelLimiter as
+	// requests read the current one without holding the mutex
+	tokens := int64(1000)
+	previous := rl.models[model]
+	assert.NoError(t, rl.AddOrUpdateLimiter(model, newRateLimit(100, nil)))
+	assert.NotSame(t, previous, rl.models[model])
+	assert.Same(t, previous.keys, rl.models[model].keys)
+	assert.NoError(t, rl.AddOrUpdateLimiter(model, newRateLimit(100, &networkingv1alpha1.TokenQuota{
+		Period:          networkingv1alpha1.QuotaDay,
+		TokensPerPeriod: &tokens,
+	})))
+	assert.IsType(t, &InputRateLimitExceededError{}, reserveInput(rl, model, "user-a", 1))
+	assert.IsType(t, &InputRateLimitExceededError{}, reserveInput(rl, model, "user-premium", 1))
+
+	// Changing the limits applies them
+	assert.NoError(t, rl.AddOrUpdateLimiter(model, newRateLimit(200, nil)))
+	assert.NoError(t, reserveInput(rl, model, "user-a", 200))
+}
+
+func TestTokenRateLimiter_UpdateLimiterConcurrent(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	tokens := uint32(1000000)
+	newRateLimit := func() *networkingv1alpha1.RateLimit {
+		return &networkingv1alpha1.RateLimit{
+			InputTokensPerUnit: &tokens,
+			Unit:               networkingv1alpha1.Hour,
+			Key:                &networkingv1alpha1.RateLimitKey{Source: networkingv1alpha1.RateLimitKeyHeader, Header: "x-user-id"},
+			Overrides:          []networkingv1alpha1.RateLimitOverride{{Key: "user-premium", InputTokensPerUnit: &tokens}},
+		}
+	}
+	assert.NoError(t, rl.AddOrUpdateLimiter(model, newRateLimit()))
+
+	// Requests of new keys read the limits of the model while the ModelRoute is updated
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			assert.NoError(t, rl.AddOrUpdateLimiter(model, newRateLimit()))
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		assert.NoError(t, reserveInput(rl, model, fmt.Sprintf("user-%d", i), 1))
+		rl.KeyLabel(model, "user-premium")
+	}
+	wg.Wait()
 }
 
 func TestTokenRateLimiter_OutputRateLimit(t *testing.T) {
@@ -209,16 +304,16 @@ func TestTokenRateLimiter_OutputRateLimit(t *testing.T) {
 	})
 
 	// First request should be allowed (has 5 tokens available)
-	err := rl.RateLimit(model, prompt)
+	err := admit(rl, model, "", prompt)
 	if err != nil {
 		t.Fatalf("first request should be allowed: %v", err)
 	}
 
 	// Consume most tokens
-	rl.RecordOutputTokens(model, 5)
+	chargeOutputTokens(rl, model, "", 5)
 
 	// Next request should be blocked due to insufficient output tokens
-	err = rl.RateLimit(model, prompt)
+	err = admit(rl, model, "", prompt)
 	if err == nil {
 		t.Fatalf("expected output rate limit error")
 	}
@@ -241,7 +336,7 @@ func TestTokenRateLimiter_InputAndOutputErrors(t *testing.T) {
 		Unit:               unit,
 	})
 
-	err := rl.RateLimit(model+"-input", longPrompt)
+	err := admit(rl, model+"-input", "", longPrompt)
 	if err == nil {
 		t.Fatalf("expected input rate limit error")
 	}
@@ -256,19 +351,19 @@ func TestTokenRateLimiter_InputAndOutputErrors(t *testing.T) {
 	})
 
 	// First make a successful request to establish the limiter
-	err = rl.RateLimit(model+"-output", "short")
+	err = admit(rl, model+"-output", "", "short")
 	if err != nil {
 		t.Fatalf("first request should succeed: %v", err)
 	}
 
 	// Consume all available output tokens
-	rl.RecordOutputTokens(model+"-output", 10) // Consume all 10 tokens
+	chargeOutputTokens(rl, model+"-output", "", 10) // Consume all 10 tokens
 
 	// Wait a bit for the tokens to be recorded
 	time.Sleep(10 * time.Millisecond)
 
 	// Next request should be blocked due to insufficient output tokens (< 1 token available)
-	err = rl.RateLimit(model+"-output", "short") // Short prompt to avoid input limit
+	err = admit(rl, model+"-output", "", "short") // Short prompt to avoid input limit
 	if err == nil {
 		t.Fatalf("expected output rate limit error")
 	}
@@ -276,3 +371,282 @@ func TestTokenRateLimiter_InputAndOutputErrors(t *testing.T) {
 		t.Fatalf("expected OutputRateLimitExceededError, got %T: %v", err, err)
 	}
 }
+
+func TestTokenRateLimiter_ReserveInputTokens(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	tokens := uint32(1000)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit: &tokens,
+		Unit:               networkingv1alpha1.Minute,
+	})
+
+	// e.g. a short prompt with an image
+	if err := reserveInput(rl, model, "", 580); err != nil {
+		t.Fatalf("unexpected error on allowed request: %v", err)
+	}
+	err := reserveInput(rl, model, "", 580)
+	if _, ok := err.(*InputRateLimitExceededError); !ok {
+		t.Fatalf("expected InputRateLimitExceededError, got %T: %v", err, err)
+	}
+}
+
+func TestTokenRateLimiter_Keyed(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	tokens := uint32(100)
+	premiumTokens := uint32(300)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit: &tokens,
+		Unit:               networkingv1alpha1.Minute,
+		Key:                &networkingv1alpha1.RateLimitKey{Source: networkingv1alpha1.RateLimitKeyJWTClaim, JWTClaim: "tenant"},
+		Overrides: []networkingv1alpha1.RateLimitOverride{
+			{Key: "tenant-premium", InputTokensPerUnit: &premiumTokens},
+		},
+	})
+
+	// Each key has its own budget
+	for _, key := range []string{"tenant-a", "tenant-b", ""} {
+		if err := reserveInput(rl, model, key, 100); err != nil {
+			t.Fatalf("unexpected error on allowed request of %q: %v", key, err)
+		}
+		err := reserveInput(rl, model, key, 1)
+		if _, ok := err.(*InputRateLimitExceededError); !ok {
+			t.Fatalf("expected InputRateLimitExceededError for %q, got %T: %v", key, err, err)
+		}
+	}
+
+	// Overridden keys have their own limits
+	if err := reserveInput(rl, model, "tenant-premium", 300); err != nil {
+		t.Fatalf("unexpected error on allowed request: %v", err)
+	}
+	err := reserveInput(rl, model, "tenant-premium", 1)
+	if _, ok := err.(*InputRateLimitExceededError); !ok {
+		t.Fatalf("expected InputRateLimitExceededError, got %T: %v", err, err)
+	}
+
+	if key := rl.Key(model); key == nil || key.JWTClaim != "tenant" {
+		t.Fatalf("unexpected key of the model: %v", key)
+	}
+	for key, expected := range map[string]string{"tenant-premium": "tenant-premium", "tenant-a": "default", "": "default"} {
+		if label := rl.KeyLabel(model, key); label != expected {
+			t.Fatalf("expected label %q for %q, got %q", expected, key, label)
+		}
+	}
+}
+
+func TestTokenRateLimiter_NotKeyed(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	tokens := uint32(100)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit: &tokens,
+		Unit:               networkingv1alpha1.Minute,
+	})
+
+	// The budget is shared by all the keys
+	if err := reserveInput(rl, model, "user-a", 100); err != nil {
+		t.Fatalf("unexpected error on allowed request: %v", err)
+	}
+	err := reserveInput(rl, model, "user-b", 1)
+	if _, ok := err.(*InputRateLimitExceededError); !ok {
+		t.Fatalf("expected InputRateLimitExceededError, got %T: %v", err, err)
+	}
+	if rl.Key(model) != nil {
+		t.Fatalf("expected no key")
+	}
+	if label := rl.KeyLabel(model, "user-a"); label != "" {
+		t.Fatalf("expected no label, got %q", label)
+	}
+}
+
+func TestTokenRateLimiter_Requests(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	requests := uint32(2)
+	premiumRequests := uint32(3)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		RequestsPerUnit: &requests,
+		Unit:            networkingv1alpha1.Minute,
+		Key:             &networkingv1alpha1.RateLimitKey{Source: networkingv1alpha1.RateLimitKeyUser},
+		Overrides: []networkingv1alpha1.RateLimitOverride{
+			{Key: "user-premium", RequestsPerUnit: &premiumRequests},
+		},
+	})
+
+	for key, limit := range map[string]int{"user-a": 2, "user-premium": 3} {
+		for i := 0; i < limit; i++ {
+			// The number of tokens does not matter
+			if err := reserveInput(rl, model, key, 100000); err != nil {
+				t.Fatalf("unexpected error on allowed request %d of %q: %v", i, key, err)
+			}
+		}
+		err := reserveInput(rl, model, key, 1)
+		if _, ok := err.(*RateLimitExceededError); !ok {
+			t.Fatalf("expected RateLimitExceededError for %q, got %T: %v", key, err, err)
+		}
+	}
+}
+
+func TestTokenRateLimiter_RequestsAndTokens(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	requests := uint32(1)
+	tokens := uint32(100)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit: &tokens,
+		RequestsPerUnit:    &requests,
+		Unit:               networkingv1alpha1.Minute,
+	})
+
+	if err := reserveInput(rl, model, "", 10); err != nil {
+		t.Fatalf("unexpected error on allowed request: %v", err)
+	}
+	// The request limit is exceeded before the input token one
+	err := reserveInput(rl, model, "", 10)
+	if _, ok := err.(*RateLimitExceededError); !ok {
+		t.Fatalf("expected RateLimitExceededError, got %T: %v", err, err)
+	}
+}
+
+func TestTokenRateLimiter_ReserveOutputTokens(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	outputTokens := uint32(1000)
+	maxTokens := uint32(300)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		OutputTokensPerUnit:   &outputTokens,
+		OutputTokensAdmission: networkingv1alpha1.OutputTokensReserve,
+		DefaultMaxTokens:      &maxTokens,
+		Unit:                  networkingv1alpha1.Hour,
+	})
+
+	// max_tokens of the request is reserved
+	first, err := rl.ReserveTokens(model, "", 10, 500)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, first.Tokens())
+	// The default max tokens is reserved for requests without max_tokens
+	second, err := rl.ReserveTokens(model, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 300, second.Tokens())
+	// Concurrent long generations can't exceed the limit
+	_, err = rl.ReserveTokens(model, "", 10, 500)
+	assert.IsType(t, &OutputRateLimitExceededError{}, err)
+
+	// The unused part of the reservation is refunded once the request completes
+	first.Settle(100)
+	third, err := rl.ReserveTokens(model, "", 10, 500)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, third.Tokens())
+	assert.InDelta(t, 100, rl.getLimiter(model, "").output.Tokens(), 1)
+
+	// Refunds can't exceed the limit
+	second.Settle(0)
+	third.Settle(0)
+	third.Settle(0)
+	assert.InDelta(t, 1000, rl.getLimiter(model, "").output.Tokens(), 1)
+
+	// The reservation is capped by the limit
+	capped, err := rl.ReserveTokens(model, "", 10, 5000)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, capped.Tokens())
+
+	// The tokens used beyond the reservation are charged even if the limit is exhausted, and beyond its burst
+	capped.Settle(3500)
+	assert.InDelta(t, -2500, rl.getLimiter(model, "").output.Tokens(), 1)
+	_, err = rl.ReserveTokens(model, "", 10, 1)
+	assert.IsType(t, &OutputRateLimitExceededError{}, err)
+}
+
+func TestTokenRateLimiter_CheckOutputTokens(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	outputTokens := uint32(1000)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		OutputTokensPerUnit: &outputTokens,
+		Unit:                networkingv1alpha1.Hour,
+	})
+
+	// Nothing is reserved, the output tokens are charged once the requests complete
+	var reservation *OutputReservation
+	for i := 0; i < 3; i++ {
+		var err error
+		reservation, err = rl.ReserveTokens(model, "", 10, 500)
+		assert.NoError(t, err)
+		assert.Zero(t, reservation.Tokens())
+	}
+	reservation.Settle(1000)
+	_, err := rl.ReserveTokens(model, "", 10, 500)
+	assert.IsType(t, &OutputRateLimitExceededError{}, err)
+}
+
+func TestTokenRateLimiter_SettleAfterUpdate(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	outputTokens := uint32(1000)
+	maxTokens := uint32(300)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		OutputTokensPerUnit:   &outputTokens,
+		OutputTokensAdmission: networkingv1alpha1.OutputTokensReserve,
+		DefaultMaxTokens:      &maxTokens,
+		Unit:                  networkingv1alpha1.Hour,
+	})
+	reservation, err := rl.ReserveTokens(model, "", 10, 500)
+	assert.NoError(t, err)
+	admitted := rl.getLimiter(model, "").output
+
+	// The limits change while the request is served
+	updatedTokens := uint32(2000)
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		OutputTokensPerUnit:   &updatedTokens,
+		OutputTokensAdmission: networkingv1alpha1.OutputTokensReserve,
+		DefaultMaxTokens:      &maxTokens,
+		Unit:                  networkingv1alpha1.Hour,
+	})
+	updated := rl.getLimiter(model, "").output
+	assert.NotSame(t, admitted, updated)
+
+	// The request is settled against the limiter it reserved its output tokens from
+	reservation.Settle(800)
+	assert.InDelta(t, 200, admitted.Tokens(), 1)
+	assert.InDelta(t, 2000, updated.Tokens(), 1)
+}
+
+func TestTokenRateLimiter_RejectedRequestRollback(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	requests := uint32(10)
+	inputTokens := uint32(100)
+	outputTokens := uint32(1000)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		RequestsPerUnit:       &requests,
+		InputTokensPerUnit:    &inputTokens,
+		OutputTokensPerUnit:   &outputTokens,
+		OutputTokensAdmission: networkingv1alpha1.OutputTokensReserve,
+		Unit:                  networkingv1alpha1.Hour,
+	})
+	limiter := rl.getLimiter(model, "")
+
+	// A request rejected by the input limit gives its request back
+	_, err := rl.ReserveTokens(model, "", 200, 100)
+	assert.IsType(t, &InputRateLimitExceededError{}, err)
+	assert.InDelta(t, 10, limiter.requests.Tokens(), 0.01)
+	assert.InDelta(t, 1000, limiter.output.Tokens(), 0.01)
+
+	// A request rejected by the output limit gives its request and input tokens back
+	_, err = rl.ReserveTokens(model, "", 50, 1000)
+	assert.NoError(t, err)
+	_, err = rl.ReserveTokens(model, "", 30, 100)
+	assert.IsType(t, &OutputRateLimitExceededError{}, err)
+	assert.InDelta(t, 9, limiter.requests.Tokens(), 0.01)
+	assert.InDelta(t, 50, limiter.input.Tokens(), 0.01)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"time"
+)
+
+// Status is the state of the rate limits of a key, as reported to clients
+type Status struct {
+	// Requests is the state of the request limit, nil if there is none
+	Requests *LimitStatus
+	// Tokens is the state of the token limit which is the closest to being exhausted, nil if there is none
+	Tokens *LimitStatus
+}
+
+// LimitStatus is the state of a limit
+type LimitStatus struct {
+	// Limit is the maximum number of requests or tokens available
+	Limit int
+	// Remaining is the number of requests or tokens currently available
+	Remaining int
+	// Reset is how long it takes for the limit to be fully available again
+	Reset time.Duration
+}
+
+// Status returns the state of the rate limits of key for model, or nil if model has no rate limit.
+// For global rate limiting, the state of each limit is the one Redis returned the last time the limit was
+// used by this router, so that reporting it does not cost any round trip to Redis.
+func (r *TokenRateLimiter) Status(model, key string) *Status {
+	limiter := r.getLimiter(model, key)
+	if limiter == nil {
+		return nil
+	}
+
+	now := time.Now()
+	status := &Status{}
+	if limiter.requests != nil {
+		status.Requests = limitStatus(limiter.requests, now)
+	}
+	for _, l := range []Limiter{limiter.input, limiter.output} {
+		if l == nil {
+			continue
+		}
+		tokens := limitStatus(l, now)
+		if status.Tokens == nil || tokens.Remaining*status.Tokens.Limit < status.Tokens.Remaining*tokens.Limit {
+			status.Tokens = tokens
+		}
+	}
+	return status
+}
+
+func limitStatus(l Limiter, now time.Time) *LimitStatus {
+	available := max(l.Remaining(now), 0)
+	return &LimitStatus{
+		Limit:     l.Burst(),
+		Remaining: int(available),
+		Reset:     refillDuration(l, float64(l.Burst())-available),
+	}
+}
+
+// retryAfter returns how long it takes for l to allow n tokens, or its whole burst if n is larger.
+func retryAfter(l Limiter, n int) time.Duration {
+	return refillDuration(l, float64(min(n, l.Burst()))-l.Remaining(time.Now()))
+}
+
+// refillDuration returns how long it takes for l to refill the given number of tokens.
+func refillDuration(l Limiter, tokens float64) time.Duration {
+	if tokens <= 0 || l.Limit() <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / float64(l.Limit()) * float64(time.Second))
+}
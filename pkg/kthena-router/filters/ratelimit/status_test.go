@@ -0,0 +1,139 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+func TestTokenRateLimiter_Status(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	inputTokens := uint32(600)
+	outputTokens := uint32(60)
+	requests := uint32(60)
+
+	assert.Nil(t, rl.Status(model, ""))
+
+	require.NoError(t, rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit:  &inputTokens,
+		OutputTokensPerUnit: &outputTokens,
+		RequestsPerUnit:     &requests,
+		Unit:                networkingv1alpha1.Minute,
+	}))
+	require.NoError(t, reserveInput(rl, model, "", 300))
+
+	status := rl.Status(model, "")
+	require.NotNil(t, status)
+	assert.Equal(t, 60, status.Requests.Limit)
+	assert.Equal(t, 59, status.Requests.Remaining)
+	assert.InDelta(t, float64(time.Second), float64(status.Requests.Reset), float64(100*time.Millisecond))
+	// The input token limit is the closest to being exhausted
+	assert.Equal(t, 600, status.Tokens.Limit)
+	assert.Equal(t, 300, status.Tokens.Remaining)
+	assert.InDelta(t, float64(30*time.Second), float64(status.Tokens.Reset), float64(100*time.Millisecond))
+
+	// Then the output token one
+	chargeOutputTokens(rl, model, "", 50)
+	status = rl.Status(model, "")
+	assert.Equal(t, 60, status.Tokens.Limit)
+	assert.Equal(t, 10, status.Tokens.Remaining)
+}
+
+func TestTokenRateLimiter_RetryAfter(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	inputTokens := uint32(60)
+	requests := uint32(2)
+
+	require.NoError(t, rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit: &inputTokens,
+		RequestsPerUnit:    &requests,
+		Unit:               networkingv1alpha1.Minute,
+	}))
+	require.NoError(t, reserveInput(rl, model, "", 50))
+
+	// 20 more tokens are allowed once 10 tokens are refilled, in 10 seconds
+	err := reserveInput(rl, model, "", 20)
+	require.IsType(t, &InputRateLimitExceededError{}, err)
+	assert.InDelta(t, float64(10*time.Second), float64(err.(*InputRateLimitExceededError).RetryAfter), float64(100*time.Millisecond))
+
+	// The rejected request is not counted
+	require.NoError(t, reserveInput(rl, model, "", 1))
+
+	// Both requests have been counted, the next one is allowed in 30 seconds
+	err = reserveInput(rl, model, "", 1)
+	require.IsType(t, &RateLimitExceededError{}, err)
+	assert.InDelta(t, float64(30*time.Second), float64(err.(*RateLimitExceededError).RetryAfter), float64(100*time.Millisecond))
+
+	// Requests larger than the limit wait for the whole limit
+	require.NoError(t, rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit: &inputTokens,
+		Unit:               networkingv1alpha1.Minute,
+	}))
+	require.NoError(t, reserveInput(rl, model, "", 60))
+	err = reserveInput(rl, model, "", 1000)
+	require.IsType(t, &InputRateLimitExceededError{}, err)
+	assert.InDelta(t, float64(time.Minute), float64(err.(*InputRateLimitExceededError).RetryAfter), float64(100*time.Millisecond))
+}
+
+func TestTokenRateLimiter_GlobalStatus(t *testing.T) {
+	mr, redisConfig := setupMiniRedis(t)
+	defer mr.Close()
+
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	inputTokens := uint32(600)
+	require.NoError(t, rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit: &inputTokens,
+		Unit:               networkingv1alpha1.Minute,
+		Global: &networkingv1alpha1.GlobalRateLimit{
+			Redis: redisConfig,
+		},
+	}))
+	require.NoError(t, reserveInput(rl, model, "", 200))
+
+	// The status is the one returned by Redis when the request was admitted, without reading it again
+	commands := mr.CommandCount()
+	status := rl.Status(model, "")
+	assert.Equal(t, commands, mr.CommandCount())
+	require.NotNil(t, status)
+	assert.Nil(t, status.Requests)
+	assert.Equal(t, 600, status.Tokens.Limit)
+	assert.InDelta(t, 400, status.Tokens.Remaining, 1)
+	assert.InDelta(t, float64(20*time.Second), float64(status.Tokens.Reset), float64(time.Second))
+
+	// The tokens consumed by other routers are accounted for once this router uses the limit again
+	other := NewTokenRateLimiter()
+	require.NoError(t, other.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit: &inputTokens,
+		Unit:               networkingv1alpha1.Minute,
+		Global: &networkingv1alpha1.GlobalRateLimit{
+			Redis: redisConfig,
+		},
+	}))
+	require.NoError(t, reserveInput(other, model, "", 300))
+	assert.InDelta(t, 400, rl.Status(model, "").Tokens.Remaining, 1)
+	require.NoError(t, reserveInput(rl, model, "", 50))
+	assert.InDelta(t, 50, rl.Status(model, "").Tokens.Remaining, 1)
+}
@@ -28,6 +28,41 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// CachedTokens are the prompt tokens served from the prefix cache of the inference engine, read from
+	// prompt_tokens_details or input_tokens_details
+	CachedTokens int `json:"-"`
+}
+
+type tokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// UnmarshalJSON also accepts the usage of the Responses API, which names the fields input_tokens and output_tokens.
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	type usage Usage
+	var v struct {
+		usage
+		InputTokens         int            `json:"input_tokens"`
+		OutputTokens        int            `json:"output_tokens"`
+		PromptTokensDetails *tokensDetails `json:"prompt_tokens_details"`
+		InputTokensDetails  *tokensDetails `json:"input_tokens_details"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*u = Usage(v.usage)
+	if v.PromptTokensDetails != nil {
+		u.CachedTokens = v.PromptTokensDetails.CachedTokens
+	} else if v.InputTokensDetails != nil {
+		u.CachedTokens = v.InputTokensDetails.CachedTokens
+	}
+	if u.PromptTokens == 0 {
+		u.PromptTokens = v.InputTokens
+	}
+	if u.CompletionTokens == 0 {
+		u.CompletionTokens = v.OutputTokens
+	}
+	return nil
 }
 
 // Define a struct to represent the OpenAI response body
@@ -56,6 +91,13 @@ const (
 	streamingEndMsg     = "data: [DONE]"
 )
 
+// responsesStreamEvent is an event of a streamed Responses API response. The usage is only sent
+// in the response of the terminal events, like response.completed.
+type responsesStreamEvent struct {
+	Type     string          `json:"type"`
+	Response *OpenAIResponse `json:"response"`
+}
+
 // Example message if "stream_options": {"include_usage": "true"} is included in the request:
 // data: {"id":"...","object":"text_completion","created":1739400043,"model":"tweet-summary-0","choices":[],
 // "usage":{"prompt_tokens":7,"total_tokens":17,"completion_tokens":10}}
@@ -67,6 +109,10 @@ const (
 //
 // If include_usage is not included in the request, `data: [DONE]` is returned separately, which
 // indicates end of streaming.
+//
+// Responses API streams have no [DONE] message, the usage is sent in the response of the last event:
+// data: {"type":"response.completed","sequence_number":12,"response":{"id":"...","object":"response",
+// "usage":{"input_tokens":7,"output_tokens":10,"total_tokens":17}}}
 func ParseStreamRespForUsage(
 	responseText string,
 ) OpenAIResponse {
@@ -82,5 +128,11 @@ func ParseStreamRespForUsage(
 		return response
 	}
 
+	if response.Object == "" {
+		var event responsesStreamEvent
+		if err := json.Unmarshal(byteSlice, &event); err == nil && event.Response != nil {
+			return *event.Response
+		}
+	}
 	return response
 }
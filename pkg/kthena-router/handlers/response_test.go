@@ -0,0 +1,71 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStreamRespForUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Usage
+	}{
+		{
+			name: "chat completion usage chunk",
+			line: `data: {"id":"1","object":"chat.completion.chunk","choices":[],"usage":{"prompt_tokens":7,"completion_tokens":10,"total_tokens":17}}`,
+			want: Usage{PromptTokens: 7, CompletionTokens: 10, TotalTokens: 17},
+		},
+		{
+			name: "chat completion usage chunk with cached tokens",
+			line: `data: {"id":"1","object":"chat.completion.chunk","choices":[],"usage":{"prompt_tokens":7,"completion_tokens":10,"total_tokens":17,"prompt_tokens_details":{"cached_tokens":4}}}`,
+			want: Usage{PromptTokens: 7, CompletionTokens: 10, TotalTokens: 17, CachedTokens: 4},
+		},
+		{
+			name: "responses completed event",
+			line: `data: {"type":"response.completed","sequence_number":12,"response":{"id":"resp_1","object":"response","usage":{"input_tokens":7,"output_tokens":10,"total_tokens":17}}}`,
+			want: Usage{PromptTokens: 7, CompletionTokens: 10, TotalTokens: 17},
+		},
+		{
+			name: "responses delta event",
+			line: `data: {"type":"response.output_text.delta","sequence_number":3,"delta":"hi"}`,
+		},
+		{
+			name: "event name",
+			line: `event: response.completed`,
+		},
+		{
+			name: "done",
+			line: `data: [DONE]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseStreamRespForUsage(tt.line).Usage)
+		})
+	}
+}
+
+func TestParseOpenAIResponseBodyResponsesUsage(t *testing.T) {
+	resp, err := ParseOpenAIResponseBody([]byte(`{"id":"resp_1","object":"response","usage":{"input_tokens":3,"output_tokens":4,"total_tokens":7,"input_tokens_details":{"cached_tokens":2}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Usage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7, CachedTokens: 2}, resp.Usage)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+)
+
+// shutdownTimeout bounds the time taken to export the buffered usage records when the router shuts down
+const shutdownTimeout = 5 * time.Second
+
+// Exporter exports usage records to a sink by batches.
+//
+// Records are buffered in a bounded queue, so that recording them never blocks requests. They are exported
+// once a batch is full or the flush interval elapsed. When the sink fails, records are spooled to disk and
+// replayed on the next flush intervals, once the sink recovers. While the sink is failing, new batches go
+// straight to the spool rather than waiting for the sink to time out again. Records are dropped if the queue
+// is full, since requests must not wait for the spool, or if they can't be spooled.
+type Exporter struct {
+	sink          Sink
+	queue         chan *UsageRecord
+	spool         *Spool
+	batchSize     int
+	flushInterval time.Duration
+	metrics       *metrics.Metrics
+
+	// failing is set once the sink failed to export a batch, until the spool is replayed.
+	// It is only accessed by the goroutine running the exporter.
+	failing bool
+}
+
+// NewExporter creates a new Exporter to sink. Records are dropped rather than spooled if spool is nil.
+func NewExporter(sink Sink, spool *Spool, queueSize, batchSize int, flushInterval time.Duration, m *metrics.Metrics) *Exporter {
+	return &Exporter{
+		sink:          sink,
+		queue:         make(chan *UsageRecord, queueSize),
+		spool:         spool,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		metrics:       m,
+	}
+}
+
+// Record queues a usage record to be exported, dropping it if the queue is full
+func (e *Exporter) Record(record *UsageRecord) {
+	select {
+	case e.queue <- record:
+	default:
+		klog.V(2).Infof("usage queue of %s is full, dropping the usage record of request %s", e.sink.Name(), record.RequestID)
+		e.metrics.RecordUsageRecords(e.sink.Name(), metrics.ResultDropped, 1)
+	}
+}
+
+// Run exports the queued usage records until ctx is done, then exports the records left in the queue
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*UsageRecord, 0, e.batchSize)
+	for {
+		select {
+		case record := <-e.queue:
+			batch = append(batch, record)
+			if len(batch) >= e.batchSize {
+				e.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.flush(ctx, batch)
+			batch = batch[:0]
+			e.replay(ctx)
+		case <-ctx.Done():
+			e.shutdown(batch)
+			return
+		}
+	}
+}
+
+// flush exports a batch to the sink, spooling it if the sink fails or is failing
+func (e *Exporter) flush(ctx context.Context, batch []*UsageRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	if e.failing {
+		e.overflow(batch)
+		return
+	}
+	if err := e.sink.Export(ctx, batch); err != nil {
+		klog.Errorf("failed to export %d usage records to %s: %v", len(batch), e.sink.Name(), err)
+		e.failing = e.spool != nil
+		e.overflow(batch)
+		return
+	}
+	e.metrics.RecordUsageRecords(e.sink.Name(), metrics.ResultExported, len(batch))
+}
+
+// replay exports the spooled records, once the sink recovered
+func (e *Exporter) replay(ctx context.Context) {
+	if e.spool == nil || (!e.failing && e.spool.Empty()) {
+		return
+	}
+	err := e.spool.Drain(e.batchSize, func(records []*UsageRecord) error {
+		if err := e.sink.Export(ctx, records); err != nil {
+			return err
+		}
+		e.metrics.RecordUsageRecords(e.sink.Name(), metrics.ResultExported, len(records))
+		return nil
+	})
+	if err != nil {
+		klog.V(2).Infof("failed to replay spooled usage records to %s: %v", e.sink.Name(), err)
+		e.failing = true
+		return
+	}
+	e.failing = false
+}
+
+// shutdown exports the records left in the queue when the router shuts down, spooling them if it can't
+func (e *Exporter) shutdown(batch []*UsageRecord) {
+	// The exporter is the only consumer of the queue
+	for len(e.queue) > 0 {
+		batch = append(batch, <-e.queue)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for len(batch) > 0 {
+		n := min(e.batchSize, len(batch))
+		e.flush(ctx, batch[:n])
+		batch = batch[n:]
+	}
+}
+
+// overflow spools records which can't be exported now, or drops them if they can't be spooled
+func (e *Exporter) overflow(records []*UsageRecord) {
+	if e.spool != nil {
+		err := e.spool.Append(records)
+		if err == nil {
+			e.metrics.RecordUsageRecords(e.sink.Name(), metrics.ResultSpooled, len(records))
+			return
+		}
+		klog.Errorf("failed to spool %d usage records of %s: %v", len(records), e.sink.Name(), err)
+	}
+	e.metrics.RecordUsageRecords(e.sink.Name(), metrics.ResultDropped, len(records))
+}
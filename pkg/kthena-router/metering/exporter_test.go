@@ -0,0 +1,196 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+)
+
+// fakeSink records the batches it exports, and fails while it is down
+type fakeSink struct {
+	mutex   sync.Mutex
+	name    string
+	down    bool
+	batches [][]string
+}
+
+func (s *fakeSink) Name() string {
+	return s.name
+}
+
+func (s *fakeSink) Export(_ context.Context, records []*UsageRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.down {
+		return errors.New("sink unavailable")
+	}
+	s.batches = append(s.batches, requestIDs(records))
+	return nil
+}
+
+func (s *fakeSink) setDown(down bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.down = down
+}
+
+func (s *fakeSink) exported() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var ids []string
+	for _, batch := range s.batches {
+		ids = append(ids, batch...)
+	}
+	return ids
+}
+
+func counterValue(counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	_ = counter.Write(metric)
+	return metric.GetCounter().GetValue()
+}
+
+// runExporter runs exporter until the test completes
+func runExporter(t *testing.T, exporter *Exporter) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		exporter.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestExporter_Batches(t *testing.T) {
+	sink := &fakeSink{name: "batches"}
+	exporter := NewExporter(sink, nil, 10, 2, time.Hour, metrics.DefaultMetrics)
+	runExporter(t, exporter)
+
+	for _, record := range newRecords("1", "2", "3") {
+		exporter.Record(record)
+	}
+
+	// The full batch is exported without waiting for the flush interval
+	assert.Eventually(t, func() bool {
+		return len(sink.exported()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"1", "2"}, sink.exported())
+}
+
+func TestExporter_FlushInterval(t *testing.T) {
+	sink := &fakeSink{name: "flush-interval"}
+	exporter := NewExporter(sink, nil, 10, 100, 20*time.Millisecond, metrics.DefaultMetrics)
+	runExporter(t, exporter)
+
+	exporter.Record(newRecords("1")[0])
+	assert.Eventually(t, func() bool {
+		return len(sink.exported()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestExporter_SpoolsWhileSinkIsDown(t *testing.T) {
+	sink := &fakeSink{name: "spool", down: true}
+	spool, err := NewSpool(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	exporter := NewExporter(sink, spool, 10, 2, 20*time.Millisecond, metrics.DefaultMetrics)
+	runExporter(t, exporter)
+
+	for _, record := range newRecords("1", "2", "3") {
+		exporter.Record(record)
+	}
+	assert.Eventually(t, func() bool {
+		return !spool.Empty()
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, sink.exported())
+
+	// The spooled records are replayed once the sink recovers
+	sink.setDown(false)
+	exporter.Record(newRecords("4")[0])
+	assert.Eventually(t, func() bool {
+		return len(sink.exported()) == 4
+	}, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, sink.exported())
+	assert.True(t, spool.Empty())
+}
+
+func TestExporter_DropsWithoutSpool(t *testing.T) {
+	sink := &fakeSink{name: "drop", down: true}
+	exporter := NewExporter(sink, nil, 10, 2, time.Hour, metrics.DefaultMetrics)
+	runExporter(t, exporter)
+
+	dropped := metrics.DefaultMetrics.UsageRecords.WithLabelValues("drop", metrics.ResultDropped)
+	before := counterValue(dropped)
+	for _, record := range newRecords("1", "2") {
+		exporter.Record(record)
+	}
+	assert.Eventually(t, func() bool {
+		return counterValue(dropped)-before == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestExporter_QueueOverflow(t *testing.T) {
+	sink := &fakeSink{name: "overflow"}
+	spool, err := NewSpool(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	// The exporter is not running, so that the queue fills up
+	exporter := NewExporter(sink, spool, 1, 10, time.Hour, metrics.DefaultMetrics)
+
+	dropped := metrics.DefaultMetrics.UsageRecords.WithLabelValues("overflow", metrics.ResultDropped)
+	before := counterValue(dropped)
+	for _, record := range newRecords("1", "2", "3") {
+		exporter.Record(record)
+	}
+	// The records which don't fit in the queue are dropped rather than spooled by the request
+	assert.Equal(t, 1, len(exporter.queue))
+	assert.Equal(t, float64(2), counterValue(dropped)-before)
+	assert.True(t, spool.Empty())
+
+	// The queued record is exported on the first flush interval
+	exporter.flushInterval = 20 * time.Millisecond
+	runExporter(t, exporter)
+	assert.Eventually(t, func() bool {
+		return len(sink.exported()) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"1"}, sink.exported())
+}
+
+func TestExporter_ShutdownExportsQueuedRecords(t *testing.T) {
+	sink := &fakeSink{name: "shutdown"}
+	exporter := NewExporter(sink, nil, 10, 100, time.Hour, metrics.DefaultMetrics)
+	for _, record := range newRecords("1", "2", "3") {
+		exporter.Record(record)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	exporter.Run(ctx)
+	assert.Equal(t, []string{"1", "2", "3"}, sink.exported())
+}
@@ -0,0 +1,119 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+	defaultQueueSize     = 10000
+	defaultSpoolMaxBytes = 1 << 30
+	defaultTimeout       = 10 * time.Second
+)
+
+// Meter exports the usage records of requests to the configured sinks, each with its own exporter,
+// so that a slow or failing sink does not hold back the others.
+type Meter struct {
+	exporters []*Exporter
+}
+
+// NewMeter creates a new Meter exporting to the sinks of config. The meter has no sink, and records are
+// discarded, if config has none.
+func NewMeter(config conf.MeteringConfig, m *metrics.Metrics) (*Meter, error) {
+	var sinks []Sink
+	if config.File != "" {
+		sinks = append(sinks, NewFileSink(config.File))
+	}
+	if config.Webhook != nil && config.Webhook.URL != "" {
+		timeout, err := parseDuration(config.Webhook.Timeout, defaultTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook timeout: %w", err)
+		}
+		sinks = append(sinks, NewWebhookSink(config.Webhook.URL, config.Webhook.Headers, timeout))
+	}
+
+	flushInterval, err := parseDuration(config.FlushInterval, defaultFlushInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flush interval: %w", err)
+	}
+	batchSize := defaultBatchSize
+	if config.BatchSize > 0 {
+		batchSize = config.BatchSize
+	}
+	queueSize := defaultQueueSize
+	if config.QueueSize > 0 {
+		queueSize = config.QueueSize
+	}
+	spoolMaxBytes := int64(defaultSpoolMaxBytes)
+	if config.SpoolMaxBytes > 0 {
+		spoolMaxBytes = config.SpoolMaxBytes
+	}
+
+	meter := &Meter{}
+	for _, sink := range sinks {
+		var spool *Spool
+		if config.SpoolDir != "" {
+			if spool, err = NewSpool(filepath.Join(config.SpoolDir, sink.Name()), spoolMaxBytes); err != nil {
+				return nil, err
+			}
+		}
+		meter.exporters = append(meter.exporters, NewExporter(sink, spool, queueSize, batchSize, flushInterval, m))
+	}
+	return meter, nil
+}
+
+// Enabled reports whether the meter has sinks
+func (m *Meter) Enabled() bool {
+	return len(m.exporters) > 0
+}
+
+// Record queues a usage record to be exported to all the sinks
+func (m *Meter) Record(record *UsageRecord) {
+	for _, exporter := range m.exporters {
+		exporter.Record(record)
+	}
+}
+
+// Run exports the usage records until ctx is done, and the usage records left once it is
+func (m *Meter) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, exporter := range m.exporters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exporter.Run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(value)
+}
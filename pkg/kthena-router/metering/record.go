@@ -0,0 +1,44 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"time"
+)
+
+// UsageRecord is the token usage of a request, as exported for chargeback
+type UsageRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"request_id"`
+	// User is the authenticated user of the request
+	User string `json:"user,omitempty"`
+	// Tenant is the tenant of the request, read from its JWT or headers as configured
+	Tenant      string `json:"tenant,omitempty"`
+	Model       string `json:"model"`
+	ModelRoute  string `json:"model_route,omitempty"`
+	ModelServer string `json:"model_server,omitempty"`
+	Path        string `json:"path"`
+	StatusCode  int    `json:"status_code"`
+
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	// CachedTokens are the input tokens served from the prefix cache of the inference engine, when it reports them
+	CachedTokens int `json:"cached_tokens"`
+
+	// LatencyMs is the time taken by the router to serve the request, in milliseconds
+	LatencyMs int64 `json:"latency_ms"`
+}
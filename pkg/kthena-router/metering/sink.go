@@ -0,0 +1,131 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink is a destination of usage records
+type Sink interface {
+	// Name returns the name of the sink, used in metrics and as the spool directory of the sink
+	Name() string
+	// Export exports a batch of usage records. The batch is retried later if it returns an error,
+	// so sinks may receive the same records more than once.
+	Export(ctx context.Context, records []*UsageRecord) error
+}
+
+// FileSink appends usage records to a JSON lines file
+type FileSink struct {
+	mutex sync.Mutex
+	path  string
+}
+
+// NewFileSink creates a new FileSink appending usage records to the file at path
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Name implements Sink interface
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Export implements Sink interface
+func (s *FileSink) Export(_ context.Context, records []*UsageRecord) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open usage file: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write usage file: %w", err)
+	}
+	// The records are only acknowledged once they are on disk
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync usage file: %w", err)
+	}
+	return f.Close()
+}
+
+// WebhookSink posts batches of usage records to an HTTP endpoint, as JSON arrays
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookSink creates a new WebhookSink posting usage records to url with headers
+func NewWebhookSink(url string, headers map[string]string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Name implements Sink interface
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Export implements Sink interface. The batch is acknowledged by a 2xx response.
+func (s *WebhookSink) Export(ctx context.Context, records []*UsageRecord) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post usage records: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to post usage records: webhook returned %s", resp.Status)
+	}
+	return nil
+}
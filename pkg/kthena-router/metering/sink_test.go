@@ -0,0 +1,83 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink_Export(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	sink := NewFileSink(path)
+
+	require.NoError(t, sink.Export(context.Background(), newRecords("1", "2")))
+	require.NoError(t, sink.Export(context.Background(), newRecords("3")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+
+	record := &UsageRecord{}
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), record))
+	assert.Equal(t, "3", record.RequestID)
+	assert.Equal(t, "test-model", record.Model)
+	assert.Equal(t, 10, record.InputTokens)
+	assert.Equal(t, 20, record.OutputTokens)
+}
+
+func TestWebhookSink_Export(t *testing.T) {
+	var received []*UsageRecord
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, map[string]string{"Authorization": "Bearer secret"}, time.Second)
+	require.NoError(t, sink.Export(context.Background(), newRecords("1", "2")))
+	assert.Equal(t, []string{"1", "2"}, requestIDs(received))
+}
+
+func TestWebhookSink_ExportFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, nil, time.Second)
+	err := sink.Export(context.Background(), newRecords("1"))
+	assert.ErrorContains(t, err, "503 Service Unavailable")
+
+	// Unreachable endpoint
+	server.Close()
+	assert.Error(t, sink.Export(context.Background(), newRecords("1")))
+}
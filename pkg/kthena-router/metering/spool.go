@@ -0,0 +1,241 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// ErrSpoolFull is returned when the usage records do not fit in the spool
+var ErrSpoolFull = errors.New("usage spool is full")
+
+const (
+	// activeSpoolFile is the file the usage records are appended to
+	activeSpoolFile = "spool.jsonl"
+	// pendingSpoolPrefix is the prefix of the files holding the usage records waiting to be replayed
+	pendingSpoolPrefix = "pending-"
+)
+
+// Spool keeps usage records on disk, as JSON lines, until they can be exported.
+//
+// Records are appended to an active file. When the spool is drained, the active file becomes a pending
+// file, so that records can keep being appended while the pending files are replayed, oldest first.
+// The records of a pending file which could not be replayed are written back to it.
+type Spool struct {
+	mutex    sync.Mutex
+	dir      string
+	maxBytes int64
+	// size is the size of the files of the spool. It is read from dir once, then kept up to date
+	// as records are appended and drained.
+	size int64
+}
+
+// NewSpool creates a new Spool keeping usage records in dir, up to maxBytes.
+func NewSpool(dir string, maxBytes int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create usage spool %s: %w", dir, err)
+	}
+	size, err := dirSize(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Spool{dir: dir, maxBytes: maxBytes, size: size}, nil
+}
+
+// Append appends records to the spool, or returns ErrSpoolFull if they do not fit in it
+func (s *Spool) Append(records []*UsageRecord) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.size+int64(buf.Len()) > s.maxBytes {
+		return ErrSpoolFull
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, activeSpoolFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open usage spool: %w", err)
+	}
+	n, err := f.Write(buf.Bytes())
+	s.size += int64(n)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write usage spool: %w", err)
+	}
+	return f.Close()
+}
+
+// Drain replays the records of the spool by batches of batchSize with export, deleting them once exported.
+// It stops at the first batch which fails to be exported, which is kept in the spool with the ones after it.
+func (s *Spool) Drain(batchSize int, export func([]*UsageRecord) error) error {
+	files, err := s.rotate()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := s.drainFile(file, batchSize, export); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Empty reports whether the spool holds no records
+func (s *Spool) Empty() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.size == 0
+}
+
+// rotate turns the active file into a pending file, and returns the pending files oldest first
+func (s *Spool) rotate() ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	active := filepath.Join(s.dir, activeSpoolFile)
+	if info, err := os.Stat(active); err == nil && info.Size() > 0 {
+		// Pending files are named after their rotation time, so that they sort oldest first
+		pending := filepath.Join(s.dir, fmt.Sprintf("%s%020d.jsonl", pendingSpoolPrefix, time.Now().UnixNano()))
+		if err := os.Rename(active, pending); err != nil {
+			return nil, fmt.Errorf("failed to rotate usage spool: %w", err)
+		}
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage spool: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), pendingSpoolPrefix) {
+			files = append(files, filepath.Join(s.dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (s *Spool) drainFile(file string, batchSize int, export func([]*UsageRecord) error) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("failed to read usage spool: %w", err)
+	}
+	records, err := readRecords(file)
+	if err != nil {
+		return err
+	}
+	for len(records) > 0 {
+		batch := records[:min(batchSize, len(records))]
+		if err := export(batch); err != nil {
+			// Keep the records which were not exported for the next drain
+			if size, writeErr := writeRecords(file, records); writeErr != nil {
+				klog.Errorf("failed to write back usage spool %s: %v", file, writeErr)
+			} else {
+				s.mutex.Lock()
+				s.size = max(0, s.size-info.Size()+size)
+				s.mutex.Unlock()
+			}
+			return err
+		}
+		records = records[len(batch):]
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := os.Remove(file); err != nil {
+		return err
+	}
+	s.size = max(0, s.size-info.Size())
+	return nil
+}
+
+// dirSize returns the size of the files of the spool in dir
+func dirSize(dir string) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read usage spool: %w", err)
+	}
+	var size int64
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+	}
+	return size, nil
+}
+
+// readRecords reads the records of a spool file. Records which can't be parsed, e.g. the last one
+// if the router crashed while writing it, are skipped.
+func readRecords(file string) ([]*UsageRecord, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage spool: %w", err)
+	}
+	defer f.Close()
+
+	var records []*UsageRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		record := &UsageRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			klog.Warningf("skipping invalid usage record in spool %s: %v", file, err)
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage spool: %w", err)
+	}
+	return records, nil
+}
+
+// writeRecords replaces the records of a spool file atomically, and returns its new size
+func writeRecords(file string, records []*UsageRecord) (int64, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return 0, err
+		}
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o640); err != nil {
+		return 0, err
+	}
+	return int64(buf.Len()), os.Rename(tmp, file)
+}
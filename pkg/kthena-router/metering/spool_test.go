@@ -0,0 +1,140 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metering
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRecords(ids ...string) []*UsageRecord {
+	records := make([]*UsageRecord, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, &UsageRecord{RequestID: id, Model: "test-model", InputTokens: 10, OutputTokens: 20})
+	}
+	return records
+}
+
+func requestIDs(records []*UsageRecord) []string {
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.RequestID)
+	}
+	return ids
+}
+
+func TestSpool_Drain(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	assert.True(t, spool.Empty())
+
+	require.NoError(t, spool.Append(newRecords("1", "2", "3")))
+	require.NoError(t, spool.Append(newRecords("4", "5")))
+	assert.False(t, spool.Empty())
+
+	var exported []string
+	err = spool.Drain(2, func(records []*UsageRecord) error {
+		assert.LessOrEqual(t, len(records), 2)
+		exported = append(exported, requestIDs(records)...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, exported)
+	assert.True(t, spool.Empty())
+}
+
+func TestSpool_DrainKeepsUnexportedRecords(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, spool.Append(newRecords("1", "2", "3", "4")))
+
+	// The second batch fails, the records it holds and the ones after it are kept
+	batches := 0
+	err = spool.Drain(2, func(records []*UsageRecord) error {
+		batches++
+		if batches == 2 {
+			return errors.New("sink unavailable")
+		}
+		return nil
+	})
+	assert.Error(t, err)
+
+	// Records spooled in the meantime are replayed after the ones kept
+	require.NoError(t, spool.Append(newRecords("5")))
+
+	var exported []string
+	err = spool.Drain(10, func(records []*UsageRecord) error {
+		exported = append(exported, requestIDs(records)...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "4", "5"}, exported)
+	assert.True(t, spool.Empty())
+}
+
+func TestSpool_Full(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(dir, 200)
+	require.NoError(t, err)
+
+	require.NoError(t, spool.Append(newRecords("1")))
+	assert.ErrorIs(t, spool.Append(newRecords("2", "3", "4")), ErrSpoolFull)
+
+	// The records left by a previous router count in the size of the spool
+	reopened, err := NewSpool(dir, 200)
+	require.NoError(t, err)
+	assert.False(t, reopened.Empty())
+	assert.ErrorIs(t, reopened.Append(newRecords("2", "3", "4")), ErrSpoolFull)
+
+	var exported []string
+	err = spool.Drain(10, func(records []*UsageRecord) error {
+		exported = append(exported, requestIDs(records)...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, exported)
+
+	// The space of the drained records is freed
+	require.NoError(t, spool.Append(newRecords("2")))
+}
+
+func TestSpool_SkipsInvalidRecords(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(dir, 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, spool.Append(newRecords("1")))
+
+	// A record truncated by a crash of the router
+	f, err := os.OpenFile(filepath.Join(dir, activeSpoolFile), os.O_WRONLY|os.O_APPEND, 0o640)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"request_id":"2","mod`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	var exported []string
+	err = spool.Drain(10, func(records []*UsageRecord) error {
+		exported = append(exported, requestIDs(records)...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, exported)
+}
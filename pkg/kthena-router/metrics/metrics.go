@@ -33,9 +33,15 @@ const (
 	LabelPlugin      = "plugin"
 	LabelType        = "type"
 	LabelLimitType   = "limit_type"
+	LabelLimitKey    = "limit_key"
 	LabelModelRoute  = "model_route"
 	LabelModelServer = "model_server"
 	LabelUserID      = "user_id"
+	LabelEngine      = "engine"
+	LabelResult      = "result"
+	LabelFallback    = "fallback_model_server"
+	LabelTrigger     = "trigger"
+	LabelSink        = "sink"
 
 	// Token type values
 	TokenTypeInput  = "input"
@@ -49,6 +55,20 @@ const (
 	LimitTypeInputTokens  = "input_tokens"
 	LimitTypeOutputTokens = "output_tokens"
 	LimitTypeRequests     = "requests"
+	LimitTypeQuota        = "quota"
+
+	// Pod scrape type values
+	ScrapeTypeMetrics = "metrics"
+	ScrapeTypeModels  = "models"
+
+	// Result values of pod probes and mirrored requests
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+
+	// Result values of usage records
+	ResultExported = "exported"
+	ResultSpooled  = "spooled"
+	ResultDropped  = "dropped"
 )
 
 // Metrics holds all Prometheus metrics for the kthena-router
@@ -75,6 +95,30 @@ type Metrics struct {
 	ActiveUpstreamRequests   prometheus.GaugeVec
 	FairnessQueueSize        prometheus.GaugeVec
 	FairnessQueueDuration    prometheus.HistogramVec
+
+	// Pod scrape metrics
+	PodScrapeDuration prometheus.HistogramVec
+	PodScrapeFailures prometheus.CounterVec
+
+	// Outlier detection metrics
+	PodEjections prometheus.CounterVec
+
+	// Active health check metrics
+	PodProbesTotal   prometheus.CounterVec
+	PodProbeDuration prometheus.HistogramVec
+
+	// Request hedging metrics
+	HedgedRequests prometheus.CounterVec
+	HedgeWins      prometheus.CounterVec
+
+	// Traffic mirroring metrics
+	MirroredRequests prometheus.CounterVec
+
+	// Model fallback metrics
+	ModelFallbacks prometheus.CounterVec
+
+	// Usage metering metrics
+	UsageRecords prometheus.CounterVec
 }
 
 // NewMetrics creates a new Metrics instance with all Prometheus metrics registered
@@ -137,7 +181,7 @@ func NewMetrics() *Metrics {
 				Name: "kthena_router_rate_limit_exceeded_total",
 				Help: "Number of requests rejected due to rate limiting",
 			},
-			[]string{LabelModel, LabelLimitType, LabelPath},
+			[]string{LabelModel, LabelLimitType, LabelPath, LabelLimitKey},
 		),
 
 		ActiveDownstreamRequests: *promauto.NewGaugeVec(
@@ -172,6 +216,88 @@ func NewMetrics() *Metrics {
 			},
 			[]string{LabelModel, LabelUserID},
 		),
+
+		PodScrapeDuration: *promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kthena_router_pod_scrape_duration_seconds",
+				Help:    "Time taken to scrape the metrics or models of backend pods",
+				Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+			},
+			[]string{LabelEngine, LabelType},
+		),
+
+		PodScrapeFailures: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_pod_scrape_failures_total",
+				Help: "Total number of failed scrapes of the metrics or models of backend pods",
+			},
+			[]string{LabelEngine, LabelType},
+		),
+
+		PodEjections: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_pod_ejections_total",
+				Help: "Total number of backend pods ejected from load balancing by the outlier detection",
+			},
+			[]string{LabelModelServer},
+		),
+
+		PodProbesTotal: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_pod_probes_total",
+				Help: "Total number of active health check probes of backend pods",
+			},
+			[]string{LabelModelServer, LabelResult},
+		),
+
+		PodProbeDuration: *promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kthena_router_pod_probe_duration_seconds",
+				Help:    "Time taken by the active health check probes of backend pods",
+				Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			},
+			[]string{LabelModelServer},
+		),
+
+		HedgedRequests: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_hedged_requests_total",
+				Help: "Total number of hedged requests sent to backend pods while waiting for the first token of another pod",
+			},
+			[]string{LabelModelServer},
+		),
+
+		HedgeWins: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_hedge_wins_total",
+				Help: "Total number of requests served by a hedged request, which produced its first token before the original one",
+			},
+			[]string{LabelModelServer},
+		),
+
+		MirroredRequests: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_mirrored_requests_total",
+				Help: "Total number of requests mirrored to the backend pods of a model server, whose responses are discarded",
+			},
+			[]string{LabelModelServer, LabelResult},
+		),
+
+		ModelFallbacks: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_model_fallbacks_total",
+				Help: "Total number of requests which fell back from a model server to another one of their ModelRoute rule",
+			},
+			[]string{LabelModelServer, LabelFallback, LabelTrigger},
+		),
+
+		UsageRecords: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_usage_records_total",
+				Help: "Total number of usage records exported to a metering sink, spooled to disk while it is unavailable, or dropped",
+			},
+			[]string{LabelSink, LabelResult},
+		),
 	}
 }
 
@@ -201,9 +327,10 @@ func (m *Metrics) RecordTokens(model, path string, inputTokens, outputTokens int
 	}
 }
 
-// RecordRateLimitExceeded records when a request is rejected due to rate limiting
-func (m *Metrics) RecordRateLimitExceeded(model, limitType, path string) {
-	m.RateLimitExceeded.WithLabelValues(model, limitType, path).Inc()
+// RecordRateLimitExceeded records when a request is rejected due to rate limiting.
+// limitKey is the label of the rate limit key of the request, whose cardinality must be bounded.
+func (m *Metrics) RecordRateLimitExceeded(model, limitType, path, limitKey string) {
+	m.RateLimitExceeded.WithLabelValues(model, limitType, path, limitKey).Inc()
 }
 
 // RecordSchedulerPluginDuration records the processing time for a specific scheduler plugin
@@ -261,6 +388,58 @@ func (m *Metrics) RecordFairnessQueueDuration(model, userID string, duration tim
 	m.FairnessQueueDuration.WithLabelValues(model, userID).Observe(duration.Seconds())
 }
 
+// RecordPodScrape records the duration and the result of a scrape of a backend pod
+func (m *Metrics) RecordPodScrape(engine, scrapeType string, duration time.Duration, err error) {
+	m.PodScrapeDuration.WithLabelValues(engine, scrapeType).Observe(duration.Seconds())
+	if err != nil {
+		m.PodScrapeFailures.WithLabelValues(engine, scrapeType).Inc()
+	}
+}
+
+// RecordPodEjection records the ejection of a backend pod of the model server
+func (m *Metrics) RecordPodEjection(modelServer string) {
+	m.PodEjections.WithLabelValues(modelServer).Inc()
+}
+
+// RecordPodProbe records the duration and the result of an active health check probe of a backend pod of the model server
+func (m *Metrics) RecordPodProbe(modelServer string, duration time.Duration, err error) {
+	m.PodProbeDuration.WithLabelValues(modelServer).Observe(duration.Seconds())
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+	}
+	m.PodProbesTotal.WithLabelValues(modelServer, result).Inc()
+}
+
+// RecordHedgedRequest records a hedged request sent to a backend pod of the model server
+func (m *Metrics) RecordHedgedRequest(modelServer string) {
+	m.HedgedRequests.WithLabelValues(modelServer).Inc()
+}
+
+// RecordHedgeWin records a request served by one of its hedged requests rather than the original one
+func (m *Metrics) RecordHedgeWin(modelServer string) {
+	m.HedgeWins.WithLabelValues(modelServer).Inc()
+}
+
+// RecordMirroredRequest records the outcome of a request mirrored to the model server
+func (m *Metrics) RecordMirroredRequest(modelServer string, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+	}
+	m.MirroredRequests.WithLabelValues(modelServer, result).Inc()
+}
+
+// RecordModelFallback records a request falling back from the model server to another one on trigger
+func (m *Metrics) RecordModelFallback(modelServer, fallbackModelServer, trigger string) {
+	m.ModelFallbacks.WithLabelValues(modelServer, fallbackModelServer, trigger).Inc()
+}
+
+// RecordUsageRecords records count usage records of the sink with the result
+func (m *Metrics) RecordUsageRecords(sink, result string, count int) {
+	m.UsageRecords.WithLabelValues(sink, result).Add(float64(count))
+}
+
 // RequestMetricsRecorder is a helper struct to record detailed metrics for individual requests
 type RequestMetricsRecorder struct {
 	metrics          *Metrics
@@ -304,8 +483,8 @@ func (r *RequestMetricsRecorder) RecordOutputTokens(tokens int) {
 }
 
 // RecordRateLimitExceeded records when rate limiting is applied
-func (r *RequestMetricsRecorder) RecordRateLimitExceeded(limitType string) {
-	r.metrics.RecordRateLimitExceeded(r.model, limitType, r.path)
+func (r *RequestMetricsRecorder) RecordRateLimitExceeded(limitType, limitKey string) {
+	r.metrics.RecordRateLimitExceeded(r.model, limitType, r.path, limitKey)
 }
 
 // StartPrefillPhase marks the start of prefill phase for PD-disaggregated requests
@@ -0,0 +1,538 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+)
+
+const anthropicMessagesPath = "/v1/messages"
+
+// The Anthropic Messages API is served by translating requests into OpenAI chat completions,
+// which are routed like any other request, and translating the responses back.
+
+type anthropicMessagesRequest struct {
+	Model         string               `json:"model"`
+	Messages      []anthropicMessage   `json:"messages"`
+	System        json.RawMessage      `json:"system,omitempty"`
+	MaxTokens     int                  `json:"max_tokens"`
+	StopSequences []string             `json:"stop_sequences,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	TopK          *int                 `json:"top_k,omitempty"`
+	Tools         []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice    *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Metadata      *anthropicMetadata   `json:"metadata,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role string `json:"role"`
+	// Content is either a string or an array of content blocks
+	Content json.RawMessage `json:"content"`
+}
+
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	// text blocks
+	Text string `json:"text,omitempty"`
+	// image blocks
+	Source *anthropicImageSource `json:"source,omitempty"`
+	// tool_use blocks
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// tool_result blocks, the content is either a string or an array of content blocks
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+type anthropicMessagesResponse struct {
+	ID           string                  `json:"id"`
+	Type         string                  `json:"type"`
+	Role         string                  `json:"role"`
+	Model        string                  `json:"model"`
+	Content      []anthropicContentBlock `json:"content"`
+	StopReason   *string                 `json:"stop_reason"`
+	StopSequence *string                 `json:"stop_sequence"`
+	Usage        anthropicUsage          `json:"usage"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// isAnthropicMessagesRequest reports whether the request is sent to the Anthropic Messages API.
+func isAnthropicMessagesRequest(req *http.Request) bool {
+	return req.Method == http.MethodPost && strings.TrimSuffix(req.URL.Path, "/") == anthropicMessagesPath
+}
+
+// serveAnthropicMessages rewrites a Messages API request into a chat completions request and replaces
+// the response writer of c with one translating the responses back. The returned function must be called
+// once the request is handled to write the translated response and restore the response writer.
+func serveAnthropicMessages(c *gin.Context) (func(), error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	var request anthropicMessagesRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+	chatRequest, err := toChatCompletionRequest(&request)
+	if err != nil {
+		return nil, err
+	}
+	chatBody, err := json.Marshal(chatRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Request.URL.Path = chatCompletionsPath
+	c.Request.URL.RawPath = ""
+	c.Request.Body = io.NopCloser(bytes.NewReader(chatBody))
+	c.Request.ContentLength = int64(len(chatBody))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	return translateChatCompletionResponse(c, request.Stream, &anthropicStreamTranslator{model: request.Model}), nil
+}
+
+// toChatCompletionRequest translates a Messages API request into an OpenAI chat completions request.
+func toChatCompletionRequest(request *anthropicMessagesRequest) (ModelRequest, error) {
+	if request.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	var messages []interface{}
+	if len(request.System) > 0 {
+		blocks, err := parseAnthropicContent(request.System)
+		if err != nil {
+			return nil, fmt.Errorf("invalid system: %w", err)
+		}
+		messages = append(messages, map[string]interface{}{
+			"role":    "system",
+			"content": joinAnthropicText(blocks),
+		})
+	}
+	for i, message := range request.Messages {
+		translated, err := toChatMessages(message)
+		if err != nil {
+			return nil, fmt.Errorf("invalid messages[%d]: %w", i, err)
+		}
+		messages = append(messages, translated...)
+	}
+
+	chatRequest := ModelRequest{
+		"model":    request.Model,
+		"messages": messages,
+	}
+	if request.MaxTokens > 0 {
+		chatRequest["max_tokens"] = request.MaxTokens
+	}
+	if len(request.StopSequences) > 0 {
+		chatRequest["stop"] = request.StopSequences
+	}
+	if request.Temperature != nil {
+		chatRequest["temperature"] = *request.Temperature
+	}
+	if request.TopP != nil {
+		chatRequest["top_p"] = *request.TopP
+	}
+	if request.TopK != nil {
+		// not part of the OpenAI API, but supported by vLLM and SGLang
+		chatRequest["top_k"] = *request.TopK
+	}
+	if request.Metadata != nil && request.Metadata.UserID != "" {
+		chatRequest["user"] = request.Metadata.UserID
+	}
+	if request.Stream {
+		chatRequest["stream"] = true
+		// The usage is always needed to send the message_delta event
+		chatRequest["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	if len(request.Tools) > 0 {
+		tools := make([]interface{}, 0, len(request.Tools))
+		for _, tool := range request.Tools {
+			tools = append(tools, map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        tool.Name,
+					"description": tool.Description,
+					"parameters":  tool.InputSchema,
+				},
+			})
+		}
+		chatRequest["tools"] = tools
+	}
+	if request.ToolChoice != nil {
+		switch request.ToolChoice.Type {
+		case "auto", "none":
+			chatRequest["tool_choice"] = request.ToolChoice.Type
+		case "any":
+			chatRequest["tool_choice"] = "required"
+		case "tool":
+			chatRequest["tool_choice"] = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": request.ToolChoice.Name},
+			}
+		}
+	}
+	return chatRequest, nil
+}
+
+// toChatMessages translates a message into chat messages. Tool results are sent as separate tool messages,
+// which must directly follow the assistant message with the tool calls.
+func toChatMessages(message anthropicMessage) ([]interface{}, error) {
+	blocks, err := parseAnthropicContent(message.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []interface{}
+	var parts []interface{}
+	var toolCalls []interface{}
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			parts = append(parts, map[string]interface{}{"type": "text", "text": block.Text})
+		case "image":
+			if block.Source == nil {
+				return nil, fmt.Errorf("image source is required")
+			}
+			url := block.Source.URL
+			if block.Source.Type == "base64" {
+				url = fmt.Sprintf("data:%s;base64,%s", block.Source.MediaType, block.Source.Data)
+			}
+			parts = append(parts, map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": url}})
+		case "tool_use":
+			arguments := "{}"
+			if len(block.Input) > 0 {
+				arguments = string(block.Input)
+			}
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"id":       block.ID,
+				"type":     "function",
+				"function": map[string]interface{}{"name": block.Name, "arguments": arguments},
+			})
+		case "tool_result":
+			content := ""
+			if len(block.Content) > 0 {
+				resultBlocks, err := parseAnthropicContent(block.Content)
+				if err != nil {
+					return nil, fmt.Errorf("invalid tool_result content: %w", err)
+				}
+				content = joinAnthropicText(resultBlocks)
+			}
+			messages = append(messages, map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": block.ToolUseID,
+				"content":      content,
+			})
+		default:
+			// e.g. thinking blocks, which are not sent back to the model
+			klog.V(4).Infof("skip unsupported content block type %s", block.Type)
+		}
+	}
+
+	if len(parts) == 0 && len(toolCalls) == 0 {
+		return messages, nil
+	}
+	chatMessage := map[string]interface{}{"role": message.Role}
+	if len(parts) == 1 && parts[0].(map[string]interface{})["type"] == "text" {
+		chatMessage["content"] = parts[0].(map[string]interface{})["text"]
+	} else if len(parts) > 0 {
+		chatMessage["content"] = parts
+	}
+	if len(toolCalls) > 0 {
+		chatMessage["tool_calls"] = toolCalls
+	}
+	return append(messages, chatMessage), nil
+}
+
+// parseAnthropicContent parses a content which is either a string or an array of content blocks.
+func parseAnthropicContent(raw json.RawMessage) ([]anthropicContentBlock, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []anthropicContentBlock{{Type: "text", Text: text}}, nil
+	}
+	var blocks []anthropicContentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of content blocks")
+	}
+	return blocks, nil
+}
+
+func joinAnthropicText(blocks []anthropicContentBlock) string {
+	var texts []string
+	for _, block := range blocks {
+		if block.Type == "text" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// toAnthropicStopReason maps the finish reason of a chat completion to the stop reason of a message.
+func toAnthropicStopReason(finishReason string) string {
+	switch finishReason {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	default:
+		return "end_turn"
+	}
+}
+
+// toAnthropicMessage translates a chat completion into a Messages API response.
+func toAnthropicMessage(completion *openAIChatCompletion, model string) *anthropicMessagesResponse {
+	message := &anthropicMessagesResponse{
+		ID:      toAnthropicMessageID(completion.ID),
+		Type:    "message",
+		Role:    "assistant",
+		Model:   model,
+		Content: []anthropicContentBlock{},
+	}
+	if len(completion.Choices) > 0 {
+		choice := completion.Choices[0]
+		if choice.Message != nil {
+			if choice.Message.Content != nil && *choice.Message.Content != "" {
+				message.Content = append(message.Content, anthropicContentBlock{Type: "text", Text: *choice.Message.Content})
+			}
+			for _, toolCall := range choice.Message.ToolCalls {
+				input := json.RawMessage(toolCall.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				message.Content = append(message.Content, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    toolCall.ID,
+					Name:  toolCall.Function.Name,
+					Input: input,
+				})
+			}
+		}
+		if choice.FinishReason != nil {
+			stopReason := toAnthropicStopReason(*choice.FinishReason)
+			message.StopReason = &stopReason
+		}
+	}
+	if completion.Usage != nil {
+		message.Usage = anthropicUsage{
+			InputTokens:  completion.Usage.PromptTokens,
+			OutputTokens: completion.Usage.CompletionTokens,
+		}
+	}
+	return message
+}
+
+func toAnthropicMessageID(id string) string {
+	if strings.HasPrefix(id, "msg_") {
+		return id
+	}
+	return "msg_" + strings.TrimPrefix(id, "chatcmpl-")
+}
+
+// toAnthropicError builds a Messages API error from the body of an error response of the router or of the upstream.
+func toAnthropicError(status int, body []byte) gin.H {
+	message := errorMessage(status, body)
+
+	errorType := "api_error"
+	switch status {
+	case http.StatusBadRequest:
+		errorType = "invalid_request_error"
+	case http.StatusUnauthorized:
+		errorType = "authentication_error"
+	case http.StatusForbidden:
+		errorType = "permission_error"
+	case http.StatusNotFound:
+		errorType = "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		errorType = "request_too_large"
+	case http.StatusTooManyRequests:
+		errorType = "rate_limit_error"
+	case http.StatusServiceUnavailable, 529:
+		errorType = "overloaded_error"
+	}
+	return gin.H{
+		"type":  "error",
+		"error": gin.H{"type": errorType, "message": message},
+	}
+}
+
+// anthropicStreamTranslator translates chat completions into Messages API responses.
+type anthropicStreamTranslator struct {
+	model string
+
+	started  bool
+	finished bool
+	// index of the next content block
+	nextIndex int
+	// type of the open content block, if any
+	openBlock  string
+	stopReason string
+	usage      anthropicUsage
+}
+
+func (t *anthropicStreamTranslator) translateChunk(chunk *openAIChatCompletion) []sseEvent {
+	var events []sseEvent
+	if !t.started {
+		t.started = true
+		events = append(events, sseEvent{"message_start", gin.H{
+			"type": "message_start",
+			"message": anthropicMessagesResponse{
+				ID:      toAnthropicMessageID(chunk.ID),
+				Type:    "message",
+				Role:    "assistant",
+				Model:   t.model,
+				Content: []anthropicContentBlock{},
+			},
+		}})
+	}
+
+	if len(chunk.Choices) > 0 {
+		choice := chunk.Choices[0]
+		if delta := choice.Delta; delta != nil {
+			if delta.Content != nil && *delta.Content != "" {
+				if t.openBlock != "text" {
+					events = append(events, t.closeBlock()...)
+					events = append(events, t.startBlock(anthropicContentBlock{Type: "text", Text: ""})...)
+				}
+				events = append(events, t.blockDelta(gin.H{"type": "text_delta", "text": *delta.Content}))
+			}
+			for _, toolCall := range delta.ToolCalls {
+				// the id is only sent in the first chunk of a tool call
+				if toolCall.ID != "" {
+					events = append(events, t.closeBlock()...)
+					events = append(events, t.startBlock(anthropicContentBlock{
+						Type:  "tool_use",
+						ID:    toolCall.ID,
+						Name:  toolCall.Function.Name,
+						Input: json.RawMessage("{}"),
+					})...)
+				}
+				if toolCall.Function.Arguments != "" && t.openBlock == "tool_use" {
+					events = append(events, t.blockDelta(gin.H{"type": "input_json_delta", "partial_json": toolCall.Function.Arguments}))
+				}
+			}
+		}
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			events = append(events, t.closeBlock()...)
+			t.stopReason = toAnthropicStopReason(*choice.FinishReason)
+		}
+	}
+
+	if chunk.Usage != nil {
+		t.usage = anthropicUsage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+		// the usage is sent in the last chunk, after the finish reason
+		if t.stopReason != "" {
+			events = append(events, t.finishStream()...)
+		}
+	}
+	return events
+}
+
+func (t *anthropicStreamTranslator) startBlock(block anthropicContentBlock) []sseEvent {
+	t.openBlock = block.Type
+	// content_block_start always has the text field of text blocks, even if empty
+	var contentBlock interface{} = block
+	if block.Type == "text" {
+		contentBlock = gin.H{"type": "text", "text": ""}
+	}
+	return []sseEvent{{"content_block_start", gin.H{
+		"type":          "content_block_start",
+		"index":         t.nextIndex,
+		"content_block": contentBlock,
+	}}}
+}
+
+func (t *anthropicStreamTranslator) blockDelta(delta gin.H) sseEvent {
+	return sseEvent{"content_block_delta", gin.H{
+		"type":  "content_block_delta",
+		"index": t.nextIndex,
+		"delta": delta,
+	}}
+}
+
+func (t *anthropicStreamTranslator) closeBlock() []sseEvent {
+	if t.openBlock == "" {
+		return nil
+	}
+	event := sseEvent{"content_block_stop", gin.H{"type": "content_block_stop", "index": t.nextIndex}}
+	t.openBlock = ""
+	t.nextIndex++
+	return []sseEvent{event}
+}
+
+// finishStream ends the message. It is a no-op if the stream has not started or has already ended.
+func (t *anthropicStreamTranslator) finishStream() []sseEvent {
+	if !t.started || t.finished {
+		return nil
+	}
+	t.finished = true
+	events := t.closeBlock()
+	if t.stopReason == "" {
+		t.stopReason = "end_turn"
+	}
+	return append(events,
+		sseEvent{"message_delta", gin.H{
+			"type":  "message_delta",
+			"delta": gin.H{"stop_reason": t.stopReason, "stop_sequence": nil},
+			"usage": t.usage,
+		}},
+		sseEvent{"message_stop", gin.H{"type": "message_stop"}},
+	)
+}
+
+func (t *anthropicStreamTranslator) translateCompletion(completion *openAIChatCompletion) interface{} {
+	return toAnthropicMessage(completion, t.model)
+}
+
+func (t *anthropicStreamTranslator) translateError(status int, body []byte) interface{} {
+	return toAnthropicError(status, body)
+}
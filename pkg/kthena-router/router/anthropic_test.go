@@ -0,0 +1,260 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToChatCompletionRequest(t *testing.T) {
+	body := `{
+		"model": "claude-test",
+		"max_tokens": 1024,
+		"system": [{"type": "text", "text": "be nice"}],
+		"stop_sequences": ["END"],
+		"temperature": 0.5,
+		"top_k": 10,
+		"stream": true,
+		"metadata": {"user_id": "user-1"},
+		"tools": [{"name": "get_weather", "description": "weather of a city", "input_schema": {"type": "object"}}],
+		"tool_choice": {"type": "any"},
+		"messages": [
+			{"role": "user", "content": "what is the weather in Paris?"},
+			{"role": "assistant", "content": [
+				{"type": "text", "text": "let me check"},
+				{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}}
+			]},
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "toolu_1", "content": [{"type": "text", "text": "sunny"}]},
+				{"type": "text", "text": "and this image?"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "AAAA"}}
+			]}
+		]
+	}`
+	var request anthropicMessagesRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &request))
+	chatRequest, err := toChatCompletionRequest(&request)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(chatRequest)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"model": "claude-test",
+		"max_tokens": 1024,
+		"stop": ["END"],
+		"temperature": 0.5,
+		"top_k": 10,
+		"stream": true,
+		"stream_options": {"include_usage": true},
+		"user": "user-1",
+		"tools": [{"type": "function", "function": {"name": "get_weather", "description": "weather of a city", "parameters": {"type": "object"}}}],
+		"tool_choice": "required",
+		"messages": [
+			{"role": "system", "content": "be nice"},
+			{"role": "user", "content": "what is the weather in Paris?"},
+			{"role": "assistant", "content": "let me check", "tool_calls": [
+				{"id": "toolu_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\": \"Paris\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "toolu_1", "content": "sunny"},
+			{"role": "user", "content": [
+				{"type": "text", "text": "and this image?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA"}}
+			]}
+		]
+	}`, string(data))
+
+	_, err = toChatCompletionRequest(&anthropicMessagesRequest{})
+	assert.Error(t, err)
+}
+
+func TestToAnthropicMessage(t *testing.T) {
+	completion := `{
+		"id": "chatcmpl-123",
+		"model": "test-model-base",
+		"choices": [{
+			"message": {"role": "assistant", "content": "checking", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]},
+			"finish_reason": "tool_calls"
+		}],
+		"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+	}`
+	var parsed openAIChatCompletion
+	require.NoError(t, json.Unmarshal([]byte(completion), &parsed))
+
+	data, err := json.Marshal(toAnthropicMessage(&parsed, "claude-test"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "msg_123",
+		"type": "message",
+		"role": "assistant",
+		"model": "claude-test",
+		"content": [
+			{"type": "text", "text": "checking"},
+			{"type": "tool_use", "id": "call_1", "name": "get_weather", "input": {"city": "Paris"}}
+		],
+		"stop_reason": "tool_use",
+		"stop_sequence": null,
+		"usage": {"input_tokens": 10, "output_tokens": 5}
+	}`, string(data))
+}
+
+func TestToAnthropicError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   string
+	}{
+		{
+			status: http.StatusTooManyRequests,
+			body:   `"input token rate limit exceeded"`,
+			want:   `{"type":"error","error":{"type":"rate_limit_error","message":"input token rate limit exceeded"}}`,
+		},
+		{
+			status: http.StatusBadRequest,
+			body:   `{"object":"error","message":"max_tokens is too large"}`,
+			want:   `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens is too large"}}`,
+		},
+		{
+			status: http.StatusNotFound,
+			body:   `{"error":{"message":"model not found"}}`,
+			want:   `{"type":"error","error":{"type":"not_found_error","message":"model not found"}}`,
+		},
+		{
+			status: http.StatusInternalServerError,
+			body:   ``,
+			want:   `{"type":"error","error":{"type":"api_error","message":"Internal Server Error"}}`,
+		},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(toAnthropicError(tt.status, []byte(tt.body)))
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.want, string(data))
+	}
+}
+
+func TestRouter_HandlerFunc_AnthropicMessages(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, chatCompletionsPath, r.URL.Path)
+		var reqBody ModelRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &reqBody))
+		assert.Equal(t, "test-model-base", reqBody["model"])
+		assert.Equal(t, float64(128), reqBody["max_tokens"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	})
+
+	req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(`{"model": "test-model", "max_tokens": 128, "messages": [{"role": "user", "content": "hello"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveAggregatedRequest(t, handler, nil, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"id": "msg_1",
+		"type": "message",
+		"role": "assistant",
+		"model": "test-model",
+		"content": [{"type": "text", "text": "hi"}],
+		"stop_reason": "end_turn",
+		"stop_sequence": null,
+		"usage": {"input_tokens": 3, "output_tokens": 1}
+	}`, w.Body.String())
+}
+
+func TestRouter_HandlerFunc_AnthropicMessagesStream(t *testing.T) {
+	chunks := []string{
+		`{"id":"chatcmpl-1","choices":[{"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","choices":[{"delta":{"content":"Hel"},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","choices":[{"delta":{"content":"lo"},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"get_weather","arguments":""}}]},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		`{"id":"chatcmpl-1","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":7,"total_tokens":10}}`,
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody ModelRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &reqBody))
+		assert.Equal(t, true, reqBody["stream"])
+		assert.Equal(t, map[string]interface{}{"include_usage": true}, reqBody["stream_options"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(`{"model": "test-model", "max_tokens": 128, "stream": true, "messages": [{"role": "user", "content": "hello"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveAggregatedRequest(t, handler, nil, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	var names []string
+	var data []string
+	for _, event := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		lines := strings.Split(event, "\n")
+		require.Len(t, lines, 2, event)
+		names = append(names, strings.TrimPrefix(lines[0], "event: "))
+		data = append(data, strings.TrimPrefix(lines[1], "data: "))
+	}
+	assert.Equal(t, []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}, names)
+	assert.JSONEq(t, `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}`, data[2])
+	assert.JSONEq(t, `{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"call_1","name":"get_weather","input":{}}}`, data[5])
+	assert.JSONEq(t, `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`, data[6])
+	assert.JSONEq(t, `{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"input_tokens":3,"output_tokens":7}}`, data[9])
+}
+
+func TestRouter_HandlerFunc_AnthropicMessagesError(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"object":"error","message":"max_tokens is too large"}`)
+	})
+
+	req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(`{"model": "test-model", "max_tokens": 1000000, "stream": true, "messages": [{"role": "user", "content": "hello"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveAggregatedRequest(t, handler, nil, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens is too large"}}`, w.Body.String())
+
+	req, _ = http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(`{"model": "test-model", "messages": "hello"}`))
+	w = serveAggregatedRequest(t, handler, nil, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"invalid_request_error"`)
+}
@@ -0,0 +1,266 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/handlers"
+)
+
+const chatCompletionsPath = "/v1/chat/completions"
+
+// openAIChatCompletion is the part of a chat completion, or of a chunk of a streamed chat completion,
+// which is translated into a Messages API response.
+type openAIChatCompletion struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		// Message is set in chat completions and Delta in chunks
+		Message      *openAIChatMessage `json:"message"`
+		Delta        *openAIChatMessage `json:"delta"`
+		FinishReason *string            `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *handlers.Usage `json:"usage"`
+}
+
+type openAIChatMessage struct {
+	Content   *string          `json:"content"`
+	ToolCalls []openAIToolCall `json:"tool_calls"`
+}
+
+type openAIToolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// sseEvent is a server-sent event with a name.
+type sseEvent struct {
+	name string
+	data interface{}
+}
+
+// chatCompletionTranslator translates chat completions into the responses of another API.
+type chatCompletionTranslator interface {
+	// translateChunk translates a chunk of a streamed chat completion into events.
+	translateChunk(chunk *openAIChatCompletion) []sseEvent
+	// finishStream returns the events ending the stream. It is called at least once, even if the stream has already ended.
+	finishStream() []sseEvent
+	// translateCompletion translates a chat completion.
+	translateCompletion(completion *openAIChatCompletion) interface{}
+	// translateError translates the body of an error response of the router or of the upstream.
+	translateError(status int, body []byte) interface{}
+}
+
+// translateChatCompletionResponse replaces the response writer of c with one translating the chat completion
+// responses with translator. The returned function must be called once the request is handled to write the
+// translated response and restore the response writer.
+func translateChatCompletionResponse(c *gin.Context, stream bool, translator chatCompletionTranslator) func() {
+	writer := &chatCompletionResponseWriter{
+		ResponseWriter: c.Writer,
+		stream:         stream,
+		status:         http.StatusOK,
+		translator:     translator,
+	}
+	c.Writer = writer
+	return func() {
+		writer.finish()
+		c.Writer = writer.ResponseWriter
+	}
+}
+
+// chatCompletionResponseWriter translates the chat completion responses written by the router into the responses of another API.
+// Streamed responses are translated event by event, others are buffered until finish is called.
+type chatCompletionResponseWriter struct {
+	gin.ResponseWriter
+
+	stream     bool
+	status     int
+	written    bool
+	headerSent bool
+	// buffered response body, or incomplete line of a streamed response
+	buf        bytes.Buffer
+	translator chatCompletionTranslator
+}
+
+func (w *chatCompletionResponseWriter) WriteHeader(code int) {
+	if !w.headerSent {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow is deferred until the translated response is written.
+func (w *chatCompletionResponseWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *chatCompletionResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *chatCompletionResponseWriter) Written() bool {
+	return w.written
+}
+
+func (w *chatCompletionResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *chatCompletionResponseWriter) Write(data []byte) (int, error) {
+	w.written = true
+	w.buf.Write(data)
+	if !w.isStreaming() {
+		return len(data), nil
+	}
+
+	w.sendHeader("text/event-stream")
+	for {
+		line, err := w.buf.ReadBytes('\n')
+		if err != nil {
+			// keep the incomplete line for the next write
+			w.buf.Reset()
+			w.buf.Write(line)
+			break
+		}
+		if err := w.translateLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *chatCompletionResponseWriter) isStreaming() bool {
+	return w.stream && w.status >= http.StatusOK && w.status < http.StatusMultipleChoices
+}
+
+func (w *chatCompletionResponseWriter) sendHeader(contentType string) {
+	if w.headerSent {
+		return
+	}
+	w.headerSent = true
+	// the length of the translated response differs from the upstream one
+	w.ResponseWriter.Header().Del("Content-Length")
+	w.ResponseWriter.Header().Set("Content-Type", contentType)
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// translateLine translates a line of a chat completion event stream.
+func (w *chatCompletionResponseWriter) translateLine(line []byte) error {
+	data, ok := strings.CutPrefix(strings.TrimSpace(string(line)), "data:")
+	if !ok {
+		return nil
+	}
+	data = strings.TrimSpace(data)
+	var events []sseEvent
+	if data == "[DONE]" {
+		events = w.translator.finishStream()
+	} else {
+		var chunk openAIChatCompletion
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			klog.Errorf("failed to parse chat completion chunk: %v", err)
+			return nil
+		}
+		events = w.translator.translateChunk(&chunk)
+	}
+	return w.writeEvents(events)
+}
+
+func (w *chatCompletionResponseWriter) writeEvents(events []sseEvent) error {
+	for _, event := range events {
+		data, err := json.Marshal(event.data)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w.ResponseWriter, "event: %s\ndata: %s\n\n", event.name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish writes the translated buffered response, or ends the event stream.
+func (w *chatCompletionResponseWriter) finish() {
+	if !w.written {
+		return
+	}
+	if w.isStreaming() {
+		if w.buf.Len() > 0 {
+			_ = w.translateLine(w.buf.Bytes())
+		}
+		// the upstream stream may have ended without [DONE]
+		_ = w.writeEvents(w.translator.finishStream())
+		return
+	}
+
+	var response interface{}
+	if w.status >= http.StatusMultipleChoices {
+		response = w.translator.translateError(w.status, w.buf.Bytes())
+	} else {
+		var completion openAIChatCompletion
+		if err := json.Unmarshal(w.buf.Bytes(), &completion); err != nil {
+			klog.Errorf("failed to parse chat completion: %v", err)
+			w.status = http.StatusBadGateway
+			response = w.translator.translateError(w.status, []byte(`"invalid upstream response"`))
+		} else {
+			response = w.translator.translateCompletion(&completion)
+		}
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		klog.Errorf("failed to marshal translated response: %v", err)
+		return
+	}
+	w.sendHeader("application/json")
+	_, _ = w.ResponseWriter.Write(body)
+}
+
+// errorMessage extracts the message of an error response of the router, which is a json string,
+// or of the upstream, which is an OpenAI or vLLM error object.
+func errorMessage(status int, body []byte) string {
+	message := strings.TrimSpace(string(body))
+	var text string
+	var openAIError struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &text); err == nil {
+		message = text
+	} else if err := json.Unmarshal(body, &openAIError); err == nil {
+		if openAIError.Error.Message != "" {
+			message = openAIError.Error.Message
+		} else if openAIError.Message != "" {
+			message = openAIError.Message
+		}
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return message
+}
@@ -0,0 +1,258 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+)
+
+var (
+	// errAllPodsFailed is returned when all the upstream attempts to the pods of a ModelServer failed.
+	errAllPodsFailed = errors.New("request to all pods failed")
+	// errAllPDPairsFailed is returned when all the upstream attempts to the prefill/decode pairs of a ModelServer failed.
+	errAllPDPairsFailed = errors.New("all prefill/decode attempts failed")
+)
+
+// attemptsFailed returns failure wrapping the error of the last upstream attempt, if any.
+func attemptsFailed(failure, err error) error {
+	if err == nil {
+		return failure
+	}
+	return fmt.Errorf("%w: %w", failure, err)
+}
+
+// fallbackTrigger returns the fallback trigger of an error of the proxy, or "" if it is not an upstream failure.
+func fallbackTrigger(err error) v1alpha1.FallbackTrigger {
+	if errors.Is(err, errPodsSaturated) {
+		return v1alpha1.FallbackOnAllFiltered
+	}
+	if !errors.Is(err, errAllPodsFailed) && !errors.Is(err, errAllPDPairsFailed) {
+		return ""
+	}
+	if errors.Is(err, context.Canceled) {
+		return ""
+	}
+	var statusErr *common.UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return v1alpha1.FallbackOnRateLimited
+		case statusErr.StatusCode >= http.StatusInternalServerError:
+			return v1alpha1.FallbackOnServerError
+		}
+		return ""
+	}
+	// Connection failures and timeouts
+	return v1alpha1.FallbackOnServerError
+}
+
+// getFallbacks returns the fallbacks of the rule of modelRoute the request matched.
+func (r *Router) getFallbacks(c *gin.Context, modelName string, modelRoute *v1alpha1.ModelRoute) []*v1alpha1.FallbackTarget {
+	if modelRoute == nil {
+		return nil
+	}
+	rule, err := r.store.MatchRule(modelName, c.Request, modelRoute)
+	if err != nil {
+		return nil
+	}
+	return rule.Fallbacks
+}
+
+// serveWithFallbacks serves the request with modelServerName, then with the fallbacks in order as long as the failure
+// of the previous ModelServer triggers them. The client only gets the response of the last ModelServer tried, the
+// error responses of the ones which fell back are discarded. The model of the request is rewritten to the model of
+// the fallback ModelServer. Each ModelServer is served a copy of the original request, since serving it may modify
+// the request, e.g. the KV connectors turn it into a non streaming prefill request.
+func (r *Router) serveWithFallbacks(
+	c *gin.Context,
+	modelRequest ModelRequest,
+	modelName string,
+	modelServerName types.NamespacedName,
+	isLora bool,
+	modelRoute *v1alpha1.ModelRoute,
+	fallbacks []*v1alpha1.FallbackTarget,
+) {
+	req, writer := c.Request, c.Writer
+	attempt := newFallbackWriter(writer)
+	c.Writer = attempt
+	defer func() {
+		attempt.release()
+		c.Writer = writer
+	}()
+
+	trigger := r.serveModelServer(c, copyModelRequest(modelRequest), modelName, modelServerName, isLora, modelRoute)
+	for _, fallback := range fallbacks {
+		// Nothing can be retried once the response has been sent to the client, or once the client is gone
+		if trigger == "" || !attempt.held() || req.Context().Err() != nil {
+			return
+		}
+		if len(fallback.On) > 0 && !slices.Contains(fallback.On, trigger) {
+			continue
+		}
+
+		fallbackName := types.NamespacedName{Namespace: modelServerName.Namespace, Name: fallback.ModelServerName}
+		klog.V(4).Infof("model server %s failed with %s, falling back to %s", modelServerName, trigger, fallbackName)
+		r.metrics.RecordModelFallback(modelServerName.String(), fallbackName.String(), string(trigger))
+		accesslog.SetFallback(c, modelServerName.String(), string(trigger))
+
+		attempt = newFallbackWriter(writer)
+		c.Writer = attempt
+		// The previous attempts may have replaced the request and modified its body
+		c.Request = req
+		trigger = r.serveModelServer(c, copyModelRequest(modelRequest), modelName, fallbackName, false, modelRoute)
+	}
+}
+
+// copyModelRequest returns a deep copy of the JSON values of modelRequest
+func copyModelRequest(modelRequest ModelRequest) ModelRequest {
+	return copyJSONValue(map[string]interface{}(modelRequest)).(map[string]interface{})
+}
+
+func copyJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyJSONValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyJSONValue(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// fallbackWriter holds back the error response of a ModelServer, so that the request can fall back to another
+// ModelServer without the client noticing. Other responses are written through as soon as their status is written.
+type fallbackWriter struct {
+	gin.ResponseWriter
+
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	committed bool
+}
+
+func newFallbackWriter(w gin.ResponseWriter) *fallbackWriter {
+	return &fallbackWriter{ResponseWriter: w, header: http.Header{}}
+}
+
+// held returns whether an error response is being held back.
+func (w *fallbackWriter) held() bool {
+	return !w.committed && w.status != 0
+}
+
+// release sends the error response held back, if any, to the client.
+func (w *fallbackWriter) release() {
+	if !w.held() {
+		return
+	}
+	w.commit(w.status)
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
+
+func (w *fallbackWriter) commit(code int) {
+	dst := w.ResponseWriter.Header()
+	for k, vv := range w.header {
+		dst[k] = vv
+	}
+	w.ResponseWriter.WriteHeader(code)
+	w.committed = true
+}
+
+func (w *fallbackWriter) Header() http.Header {
+	if w.committed {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *fallbackWriter) WriteHeader(code int) {
+	switch {
+	case w.committed:
+		w.ResponseWriter.WriteHeader(code)
+	case code >= http.StatusBadRequest:
+		w.status = code
+	case w.status == 0:
+		w.commit(code)
+	}
+}
+
+func (w *fallbackWriter) WriteHeaderNow() {
+	if !w.committed && w.status == 0 {
+		w.commit(http.StatusOK)
+	}
+	if w.committed {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *fallbackWriter) Write(data []byte) (int, error) {
+	if !w.committed && w.status == 0 {
+		w.commit(http.StatusOK)
+	}
+	if w.committed {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *fallbackWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *fallbackWriter) Status() int {
+	if w.held() {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *fallbackWriter) Size() int {
+	if w.held() {
+		return w.body.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *fallbackWriter) Written() bool {
+	return w.held() || w.ResponseWriter.Written()
+}
+
+func (w *fallbackWriter) Flush() {
+	if w.committed {
+		w.ResponseWriter.Flush()
+	}
+}
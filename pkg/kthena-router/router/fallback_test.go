@@ -0,0 +1,317 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"istio.io/istio/pkg/util/sets"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8ssets "k8s.io/apimachinery/pkg/util/sets"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+)
+
+func TestFallbackTrigger(t *testing.T) {
+	statusErr := func(code int) error {
+		return &common.UpstreamStatusError{Op: "http request", StatusCode: code}
+	}
+	tests := []struct {
+		name     string
+		err      error
+		expected aiv1alpha1.FallbackTrigger
+	}{
+		{name: "saturated", err: errPodsSaturated, expected: aiv1alpha1.FallbackOnAllFiltered},
+		{name: "rate limited", err: attemptsFailed(errAllPodsFailed, statusErr(http.StatusTooManyRequests)), expected: aiv1alpha1.FallbackOnRateLimited},
+		{name: "server error", err: attemptsFailed(errAllPodsFailed, statusErr(http.StatusServiceUnavailable)), expected: aiv1alpha1.FallbackOnServerError},
+		{name: "pd server error", err: attemptsFailed(errAllPDPairsFailed, statusErr(http.StatusInternalServerError)), expected: aiv1alpha1.FallbackOnServerError},
+		{name: "connection error", err: attemptsFailed(errAllPodsFailed, errors.New("connection refused")), expected: aiv1alpha1.FallbackOnServerError},
+		{name: "timeout", err: attemptsFailed(errAllPodsFailed, context.DeadlineExceeded), expected: aiv1alpha1.FallbackOnServerError},
+		{name: "bad request", err: attemptsFailed(errAllPodsFailed, statusErr(http.StatusBadRequest)), expected: ""},
+		{name: "client canceled", err: attemptsFailed(errAllPodsFailed, context.Canceled), expected: ""},
+		{name: "not an upstream failure", err: errors.New("failed to translate responses request"), expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fallbackTrigger(tt.err))
+		})
+	}
+}
+
+func TestFallbackWriter(t *testing.T) {
+	t.Run("error response is held back", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		writer := newFallbackWriter(c.Writer)
+		c.Writer = writer
+
+		c.Header("x-upstream", "1")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, "unavailable")
+		assert.True(t, writer.held())
+		assert.True(t, c.Writer.Written())
+		assert.Equal(t, http.StatusServiceUnavailable, c.Writer.Status())
+		assert.Empty(t, w.Body.String())
+		assert.Empty(t, w.Header().Get("x-upstream"))
+
+		writer.release()
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, `"unavailable"`, w.Body.String())
+		assert.Equal(t, "1", w.Header().Get("x-upstream"))
+	})
+
+	t.Run("successful response is written through", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		writer := newFallbackWriter(c.Writer)
+		c.Writer = writer
+
+		c.Header("x-upstream", "1")
+		c.Status(http.StatusOK)
+		_, _ = c.Writer.Write([]byte("data: {}\n\n"))
+		assert.False(t, writer.held())
+		assert.Equal(t, "data: {}\n\n", w.Body.String())
+		assert.Equal(t, "1", w.Header().Get("x-upstream"))
+
+		writer.release()
+		assert.Equal(t, "data: {}\n\n", w.Body.String())
+	})
+}
+
+// addFallbackModelServer adds the ModelServer ms-fallback served by handler to the router, and makes it the fallback
+// of the ModelRoute test-model on the given triggers.
+func addFallbackModelServer(t *testing.T, router *Router, handler http.Handler, on ...aiv1alpha1.FallbackTrigger) {
+	backend := httptest.NewServer(handler)
+	t.Cleanup(backend.Close)
+	backendURL, _ := url.Parse(backend.URL)
+	backendPort, _ := strconv.Atoi(backendURL.Port())
+	modelServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-fallback", Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			Model:           func(s string) *string { return &s }("test-model-small"),
+			WorkloadPort:    aiv1alpha1.WorkloadPort{Port: int32(backendPort)},
+			InferenceEngine: "vLLM",
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod-fallback", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: backendURL.Hostname(), Phase: corev1.PodRunning},
+	}
+	assert.NoError(t, router.store.AddOrUpdateModelServer(modelServer, sets.New(types.NamespacedName{Name: "pod-fallback", Namespace: "default"})))
+	assert.NoError(t, router.store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{modelServer}))
+	assert.NoError(t, router.store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{{
+				TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}},
+				Fallbacks:    []*aiv1alpha1.FallbackTarget{{ModelServerName: "ms-fallback", On: on}},
+			}},
+		},
+	}))
+}
+
+// serveFallbackRequest sends a completion request through the router with an access log context,
+// and returns the response and the access log context.
+func serveFallbackRequest(router *Router) (*httptest.ResponseRecorder, *accesslog.AccessLogContext) {
+	req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(&closeNotifyRecorder{w})
+	c.Request = req
+	accessCtx := accesslog.NewAccessLogContext("request-1", req.Method, req.URL.Path, req.Proto, "")
+	c.Set(accesslog.AccessLogContextKey, accessCtx)
+	router.HandlerFunc()(c)
+	return w, accessCtx
+}
+
+func TestFallbackOnServerError(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}), nil)
+	var model atomic.Value
+	addFallbackModelServer(t, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		model.Store(body["model"])
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"fallback"}`)
+	}))
+
+	w, accessCtx := serveFallbackRequest(router)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":"fallback"}`, w.Body.String())
+	assert.Equal(t, "test-model-small", model.Load())
+	assert.Equal(t, "default/ms-1", accessCtx.FallbackFrom)
+	assert.Equal(t, string(aiv1alpha1.FallbackOnServerError), accessCtx.FallbackTrigger)
+	assert.Equal(t, "default/ms-fallback", accessCtx.ModelServer)
+	assert.Nil(t, accessCtx.Error)
+}
+
+func TestFallbackOnNoHealthyPods(t *testing.T) {
+	router := setupAggregatedRouter(t, http.NotFoundHandler(), nil)
+	assert.NoError(t, router.store.DeletePod(types.NamespacedName{Namespace: "default", Name: "pod-1"}))
+	addFallbackModelServer(t, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"fallback"}`)
+	}), aiv1alpha1.FallbackOnNoHealthyPods)
+
+	w, accessCtx := serveFallbackRequest(router)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":"fallback"}`, w.Body.String())
+	assert.Equal(t, string(aiv1alpha1.FallbackOnNoHealthyPods), accessCtx.FallbackTrigger)
+}
+
+func TestFallbackNotTriggered(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}), nil)
+	var calls atomic.Int32
+	addFallbackModelServer(t, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}), aiv1alpha1.FallbackOnRateLimited)
+
+	w, accessCtx := serveFallbackRequest(router)
+
+	// The server error does not trigger the fallback, the client gets the error of the first model server
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, `"request to all pods failed"`, w.Body.String())
+	assert.Equal(t, int32(0), calls.Load())
+	assert.Empty(t, accessCtx.FallbackFrom)
+	assert.NotNil(t, accessCtx.Error)
+}
+
+func TestFallbackResponsesTranslated(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}), nil)
+	router.responsesTranslationEngines = k8ssets.New("vLLM")
+	var path, model atomic.Value
+	addFallbackModelServer(t, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		path.Store(r.URL.Path)
+		model.Store(body["model"])
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	}))
+
+	req, _ := http.NewRequest("POST", "/v1/responses", bytes.NewBufferString(`{"model": "test-model", "input": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveRequest(router, req)
+
+	// The fallback ModelServer gets the request translated again from the original Responses API request
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, chatCompletionsPath, path.Load())
+	assert.Equal(t, "test-model-small", model.Load())
+	assert.Contains(t, w.Body.String(), `"object":"response"`)
+	assert.Contains(t, w.Body.String(), `"text":"hi"`)
+	assert.Equal(t, "/v1/responses", req.URL.Path)
+}
+
+func TestFallbackFromDisaggregatedModelServer(t *testing.T) {
+	// The prefill requests succeed, the decode requests fail
+	router, store, backend := setupTestRouter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := body["stream"]; !ok {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{"id":"prefill-resp"}`)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(backend.Close)
+	backendURL, _ := url.Parse(backend.URL)
+	backendPort, _ := strconv.Atoi(backendURL.Port())
+
+	modelServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			Model:           func(s string) *string { return &s }("test-model-base"),
+			WorkloadPort:    aiv1alpha1.WorkloadPort{Port: int32(backendPort)},
+			InferenceEngine: "vLLM",
+			WorkloadSelector: &aiv1alpha1.WorkloadSelector{
+				PDGroup: &aiv1alpha1.PDGroup{
+					GroupKey:      "group",
+					DecodeLabels:  map[string]string{"app": "decode"},
+					PrefillLabels: map[string]string{"app": "prefill"},
+				},
+			},
+		},
+	}
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "decode-pod-1", Namespace: "default", Labels: map[string]string{"app": "decode", "group": "test-group"}},
+			Status:     corev1.PodStatus{PodIP: backendURL.Hostname(), Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "prefill-pod-1", Namespace: "default", Labels: map[string]string{"app": "prefill", "group": "test-group"}},
+			Status:     corev1.PodStatus{PodIP: backendURL.Hostname(), Phase: corev1.PodRunning},
+		},
+	}
+	assert.NoError(t, store.AddOrUpdateModelServer(modelServer, sets.New(
+		types.NamespacedName{Name: "decode-pod-1", Namespace: "default"},
+		types.NamespacedName{Name: "prefill-pod-1", Namespace: "default"},
+	)))
+	for _, pod := range pods {
+		assert.NoError(t, store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{modelServer}))
+	}
+
+	var fallbackBody atomic.Value
+	addFallbackModelServer(t, router, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		fallbackBody.Store(body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "data: {\"id\":\"fallback\"}\n\n")
+	}))
+
+	req, _ := http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(
+		`{"model": "test-model", "messages": [{"role": "user", "content": "hello"}], "stream": true, "max_tokens": 100}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveRequest(router, req)
+
+	// The fallback ModelServer gets the original request, not the prefill request built from it
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `{"id":"fallback"}`)
+	body, _ := fallbackBody.Load().(map[string]any)
+	assert.Equal(t, true, body["stream"])
+	assert.Equal(t, float64(100), body["max_tokens"])
+	assert.Equal(t, "test-model-small", body["model"])
+}
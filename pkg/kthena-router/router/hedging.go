@@ -0,0 +1,155 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/handlers"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+// hedgeResult is the outcome of a request sent to one of the pods of a hedged request.
+type hedgeResult struct {
+	pod  int
+	resp *upstreamResponse
+	err  error
+}
+
+// proxyHedged proxies a streaming request to the pod start, whose capacity has been acquired by the caller.
+// While the first event is awaited, the request is hedged to the next pods of ctx.BestPods every hedging delay,
+// or right away when a pod fails. The first pod producing an event serves the request and the others are canceled.
+// It returns the index of the pod which served the request.
+func (r *Router) proxyHedged(
+	c *gin.Context,
+	req *http.Request,
+	ctx *framework.Context,
+	start int,
+	port int32,
+	hedging hedgePolicy,
+	limit concurrencyLimit,
+	modelServerName string,
+	modelRouteName string,
+	onUsage func(u handlers.OpenAIResponse),
+) (int, error) {
+	results := make(chan hedgeResult, hedging.maxHedges+1)
+	cancels := make(map[int]context.CancelFunc, hedging.maxHedges+1)
+	send := func(i int) {
+		attemptCtx, cancel := context.WithCancel(req.Context())
+		cancels[i] = cancel
+		attemptReq := req.Clone(attemptCtx)
+		r.metrics.IncActiveUpstreamRequests(modelServerName, modelRouteName)
+		go func() {
+			// The request is only hedged before the first event, so its first event is waited for without a deadline
+			resp, err := openUpstream(attemptReq, ctx.BestPods[i].Pod.Status.PodIP, port, true, -1)
+			results <- hedgeResult{pod: i, resp: resp, err: err}
+		}()
+	}
+	finish := func(result hedgeResult) {
+		r.metrics.DecActiveUpstreamRequests(modelServerName, modelRouteName)
+		ctx.BestPods[result.pod].ReleaseRequest()
+		// Requests canceled by the client or because another pod won say nothing about the health of the pod
+		if !errors.Is(result.err, context.Canceled) && !errors.Is(req.Context().Err(), context.Canceled) {
+			r.store.ReportRequestResult(ctx.ModelServerName, ctx.BestPods[result.pod], !isPodFailure(result.err))
+		}
+	}
+
+	inFlight, hedges := 1, 0
+	tried := map[int]bool{start: true}
+	send(start)
+	// hedge sends the request to the next pod not tried yet which has capacity, it returns false if there is none.
+	hedge := func() bool {
+		if hedges >= hedging.maxHedges {
+			return false
+		}
+		for n := 1; n < len(ctx.BestPods); n++ {
+			i := (start + n) % len(ctx.BestPods)
+			if tried[i] || !ctx.BestPods[i].AcquireRequest(limit.maxRequests) {
+				continue
+			}
+			tried[i] = true
+			hedges++
+			inFlight++
+			r.metrics.RecordHedgedRequest(modelServerName)
+			klog.V(4).Infof("hedging request to pod %s", ctx.BestPods[i].Pod.Name)
+			send(i)
+			return true
+		}
+		return false
+	}
+	// abandon cancels the requests still in flight and completes them in the background.
+	abandon := func(except int) {
+		for i, cancel := range cancels {
+			if i != except {
+				cancel()
+			}
+		}
+		go func(pending int) {
+			for ; pending > 0; pending-- {
+				result := <-results
+				if result.resp != nil {
+					result.resp.close()
+				}
+				finish(result)
+			}
+		}(inFlight)
+	}
+
+	timer := time.NewTimer(hedging.delay)
+	defer timer.Stop()
+	var err error
+	for inFlight > 0 {
+		select {
+		case <-timer.C:
+			if hedge() {
+				timer.Reset(hedging.delay)
+			}
+		case result := <-results:
+			inFlight--
+			if result.err != nil {
+				err = result.err
+				cancels[result.pod]()
+				finish(result)
+				klog.Errorf("pod request error: %v", err)
+				if !isRetryable(req.Context(), err) {
+					abandon(-1)
+					return -1, err
+				}
+				// The pod failed before its first event, another pod is tried without waiting
+				hedge()
+				continue
+			}
+
+			abandon(result.pod)
+			if result.pod != start {
+				r.metrics.RecordHedgeWin(modelServerName)
+			}
+			forwardResponse(c, result.resp, onUsage)
+			result.resp.close()
+			cancels[result.pod]()
+			finish(result)
+			return result.pod, nil
+		}
+	}
+	return -1, err
+}
@@ -0,0 +1,142 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"istio.io/istio/pkg/util/sets"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+func TestNewHedgePolicy(t *testing.T) {
+	assert.Equal(t, hedgePolicy{}, newHedgePolicy(nil))
+	assert.Equal(t, hedgePolicy{}, newHedgePolicy(&aiv1alpha1.TrafficPolicy{}))
+	assert.Equal(t, hedgePolicy{delay: time.Second, maxHedges: 1}, newHedgePolicy(&aiv1alpha1.TrafficPolicy{
+		Hedging: &aiv1alpha1.Hedging{Delay: v1.Duration{Duration: time.Second}},
+	}))
+	maxHedges := int32(3)
+	assert.Equal(t, hedgePolicy{delay: time.Second, maxHedges: 3}, newHedgePolicy(&aiv1alpha1.TrafficPolicy{
+		Hedging: &aiv1alpha1.Hedging{Delay: v1.Duration{Duration: time.Second}, MaxHedges: &maxHedges},
+	}))
+}
+
+// serveHedgedRequest sends a streaming completion request through the router to two aggregated pods backed by handler.
+func serveHedgedRequest(t *testing.T, handler http.Handler, trafficPolicy *aiv1alpha1.TrafficPolicy) *httptest.ResponseRecorder {
+	router := setupAggregatedRouter(t, handler, trafficPolicy)
+	msName := types.NamespacedName{Namespace: "default", Name: "ms-1"}
+	modelServer := router.store.GetModelServer(msName)
+	pod1 := router.store.GetPodInfo(types.NamespacedName{Namespace: "default", Name: "pod-1"}).Pod
+	pod2 := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod-2", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: pod1.Status.PodIP, Phase: corev1.PodRunning},
+	}
+	assert.NoError(t, router.store.AddOrUpdateModelServer(modelServer, sets.New(
+		types.NamespacedName{Name: "pod-1", Namespace: "default"},
+		types.NamespacedName{Name: "pod-2", Namespace: "default"},
+	)))
+	assert.NoError(t, router.store.AddOrUpdatePod(pod2, []*aiv1alpha1.ModelServer{modelServer}))
+
+	req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello", "stream": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	return serveRequest(router, req)
+}
+
+func TestHedgingSlowFirstToken(t *testing.T) {
+	var calls atomic.Int32
+	primaryCanceled := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if call == 1 {
+			// The primary pod never produces its first token, until the hedged request wins
+			<-r.Context().Done()
+			close(primaryCanceled)
+			return
+		}
+		fmt.Fprintf(w, "data: {\"id\":\"response-%d\"}\n\ndata: [DONE]\n\n", call)
+	})
+
+	w := serveHedgedRequest(t, handler, &aiv1alpha1.TrafficPolicy{
+		Hedging: &aiv1alpha1.Hedging{Delay: v1.Duration{Duration: 20 * time.Millisecond}},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "data: {\"id\":\"response-2\"}\n\ndata: [DONE]\n\n", w.Body.String())
+	assert.Equal(t, int32(2), calls.Load())
+	select {
+	case <-primaryCanceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request to the primary pod was not canceled")
+	}
+}
+
+func TestHedgingFastFirstToken(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "data: {\"id\":\"response-id\"}\n\n")
+		w.(http.Flusher).Flush()
+		// The rest of the stream may take longer than the hedging delay
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	w := serveHedgedRequest(t, handler, &aiv1alpha1.TrafficPolicy{
+		Hedging: &aiv1alpha1.Hedging{Delay: v1.Duration{Duration: 20 * time.Millisecond}},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "data: {\"id\":\"response-id\"}\n\ndata: [DONE]\n\n", w.Body.String())
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestHedgingPrimaryFailure(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "data: {\"id\":\"response-id\"}\n\n")
+	})
+
+	start := time.Now()
+	w := serveHedgedRequest(t, handler, &aiv1alpha1.TrafficPolicy{
+		Hedging: &aiv1alpha1.Hedging{Delay: v1.Duration{Duration: time.Minute}},
+	})
+
+	// The request is hedged right away when the primary pod fails
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "data: {\"id\":\"response-id\"}\n\n", w.Body.String())
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/auth"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metering"
+)
+
+// recordUsage exports the usage record of a request admitted by the rate limits, once it completes.
+func (r *Router) recordUsage(c *gin.Context, modelName string, inputTokens, outputTokens int, start time.Time) {
+	if !r.meter.Enabled() {
+		return
+	}
+	r.meter.Record(&metering.UsageRecord{
+		Timestamp:    start.UTC(),
+		RequestID:    c.Request.Header.Get("x-request-id"),
+		User:         c.GetString(common.UserIdKey),
+		Tenant:       r.tenant(c),
+		Model:        modelName,
+		ModelRoute:   c.GetString("modelRouteName"),
+		ModelServer:  c.GetString(common.ModelServerKey),
+		Path:         c.Request.URL.Path,
+		StatusCode:   c.Writer.Status(),
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+		CachedTokens: c.GetInt(common.CachedTokensKey),
+		LatencyMs:    time.Since(start).Milliseconds(),
+	})
+}
+
+// tenant returns the tenant of the request, read from its API key, or the configured JWT claim or header
+func (r *Router) tenant(c *gin.Context) string {
+	if tenant := c.GetString(common.TenantKey); tenant != "" {
+		return tenant
+	}
+	if r.tenantClaim != "" {
+		if tenant, ok := auth.GetClaim(c, r.tenantClaim); ok && tenant != "" {
+			return tenant
+		}
+	}
+	if r.tenantHeader != "" {
+		return c.Request.Header.Get(r.tenantHeader)
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metering"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+)
+
+func TestRouter_RecordUsage(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"cmpl-1","choices":[{"text":"hi","finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":50,"total_tokens":53,"prompt_tokens_details":{"cached_tokens":2}}}`)
+	}), nil)
+
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	meter, err := metering.NewMeter(conf.MeteringConfig{File: path, TenantHeader: "x-tenant"}, metrics.DefaultMetrics)
+	require.NoError(t, err)
+	router.meter = meter
+	router.tenantHeader = "x-tenant"
+
+	req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-request-id", "req-1")
+	req.Header.Set("x-tenant", "team-a")
+	w := serveRequest(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The records left in the queue are exported when the router shuts down
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	router.Run(ctx)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	record := &metering.UsageRecord{}
+	require.NoError(t, json.Unmarshal(data, record))
+	assert.Equal(t, "req-1", record.RequestID)
+	assert.Equal(t, "team-a", record.Tenant)
+	assert.Equal(t, "test-model", record.Model)
+	assert.Equal(t, "default/mr-1", record.ModelRoute)
+	assert.Equal(t, "default/ms-1", record.ModelServer)
+	assert.Equal(t, "/v1/completions", record.Path)
+	assert.Equal(t, http.StatusOK, record.StatusCode)
+	assert.Positive(t, record.InputTokens)
+	assert.Equal(t, 50, record.OutputTokens)
+	assert.Equal(t, 2, record.CachedTokens)
+	assert.False(t, record.Timestamp.IsZero())
+}
+
+func TestRouter_RecordUsageDisabled(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"cmpl-1","choices":[{"text":"hi","finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":50,"total_tokens":53}}`)
+	}), nil)
+	assert.False(t, router.meter.Enabled())
+
+	req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveRequest(router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
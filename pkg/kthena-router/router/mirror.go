@@ -0,0 +1,182 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+// mirrorTimeout bounds mirrored requests, as no client is waiting for them to be canceled.
+const mirrorTimeout = 5 * time.Minute
+
+var (
+	// errMirrorDisaggregated is logged for the requests mirrored to a PD disaggregated model server, as a
+	// mirrored request is sent to a single pod and is not split into prefill and decode requests.
+	errMirrorDisaggregated = errors.New("mirroring to a PD disaggregated model server is not supported")
+	errMirrorPodsEjected   = errors.New("all pods of the model server are ejected")
+)
+
+// mirrorRequest sends a copy of the request to the mirror target of the rule of modelRoute it matched, if the
+// request is sampled. The copy is sent in the background to a pod of the mirror ModelServer with capacity which
+// is not ejected by the outlier detection, it is not charged against the rate limits and its response is discarded.
+// Its outcome is logged to the access log with the request ID of the original request. Requests are not mirrored
+// to PD disaggregated ModelServers.
+// It must be called before modelRequest is rewritten for the ModelServer serving the request.
+func (r *Router) mirrorRequest(c *gin.Context, modelRequest ModelRequest, modelRoute *v1alpha1.ModelRoute, isLora bool) {
+	if modelRoute == nil {
+		return
+	}
+	modelName := modelRequest["model"].(string)
+	rule, err := r.store.MatchRule(modelName, c.Request, modelRoute)
+	if err != nil || rule.Mirror == nil || !sampleMirror(rule.Mirror.Percentage) {
+		return
+	}
+	// Only JSON bodies are mirrored, multipart and binary bodies are forwarded as is to a single pod
+	if _, ok := c.Get(multipartBodyKey); ok {
+		return
+	}
+	if _, ok := c.Get(openInferenceBodyKey); ok {
+		return
+	}
+
+	modelServerName := types.NamespacedName{Namespace: modelRoute.Namespace, Name: rule.Mirror.ModelServerName}
+	entry := &accesslog.AccessLogEntry{
+		Timestamp:   time.Now(),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		Protocol:    c.Request.Proto,
+		ModelName:   modelName,
+		ModelRoute:  fmt.Sprintf("%s/%s", modelRoute.Namespace, modelRoute.Name),
+		ModelServer: modelServerName.String(),
+		RequestID:   c.Request.Header.Get("x-request-id"),
+		Mirror:      true,
+	}
+
+	pods, modelServer, err := r.getPodsAndServer(modelServerName)
+	if err != nil {
+		r.logMirror(entry, nil, err)
+		return
+	}
+	if modelServer.Spec.WorkloadSelector != nil && modelServer.Spec.WorkloadSelector.PDGroup != nil {
+		r.logMirror(entry, nil, errMirrorDisaggregated)
+		return
+	}
+	pods = slices.DeleteFunc(pods, func(pod *datastore.PodInfo) bool {
+		return pod.IsEjected()
+	})
+	if len(pods) == 0 {
+		r.logMirror(entry, nil, errMirrorPodsEjected)
+		return
+	}
+	limit := newConcurrencyLimit(modelServer.Spec.TrafficPolicy)
+	// Mirrored traffic never waits for capacity, it is dropped when the pods are saturated
+	i := limit.acquire(pods, rand.Intn(len(pods)))
+	if i < 0 {
+		r.logMirror(entry, nil, errPodsSaturated)
+		return
+	}
+	pod := pods[i]
+	entry.SelectedPod = pod.Pod.Name
+
+	// The body is built right away, the model request is rewritten for the original ModelServer afterwards
+	mirrored := maps.Clone(modelRequest)
+	if modelServer.Spec.Model != nil && !isLora {
+		mirrored["model"] = *modelServer.Spec.Model
+	}
+	path := c.Request.URL.Path
+	// Like the original request, the request is translated if the mirror ModelServer does not implement the Responses API
+	if isResponsesRequest(c.Request) && r.responsesTranslationEngines.Has(string(modelServer.Spec.InferenceEngine)) {
+		if mirrored, err = toChatCompletionRequestFromResponsesBody(mirrored); err != nil {
+			pod.ReleaseRequest()
+			r.logMirror(entry, nil, err)
+			return
+		}
+		path = chatCompletionsPath
+	}
+	body, err := json.Marshal(mirrored)
+	if err != nil {
+		pod.ReleaseRequest()
+		r.logMirror(entry, nil, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mirrorTimeout)
+	req := c.Request.Clone(ctx)
+	req.URL.Scheme = "http"
+	req.URL.Path = path
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = nil
+
+	go func() {
+		defer cancel()
+		defer pod.ReleaseRequest()
+		resp, err := doRequest(req, pod.Pod.Status.PodIP, modelServer.Spec.WorkloadPort.Port)
+		if err == nil {
+			_, err = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		r.logMirror(entry, resp, err)
+	}()
+}
+
+// logMirror records the outcome of a mirrored request to the access log and metrics.
+func (r *Router) logMirror(entry *accesslog.AccessLogEntry, resp *http.Response, err error) {
+	r.metrics.RecordMirroredRequest(entry.ModelServer, err)
+	entry.DurationTotal = time.Since(entry.Timestamp).Milliseconds()
+	entry.DurationUpstreamProcessing = entry.DurationTotal
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		klog.V(4).Infof("mirrored request %s to model server %s failed: %v", entry.RequestID, entry.ModelServer, err)
+		var statusErr *common.UpstreamStatusError
+		if errors.As(err, &statusErr) {
+			entry.StatusCode = statusErr.StatusCode
+		}
+		entry.Error = &accesslog.ErrorInfo{Type: "mirror", Message: err.Error()}
+	}
+	if err := r.accessLogger.Log(entry); err != nil {
+		klog.Errorf("Failed to write access log: %v", err)
+	}
+}
+
+// sampleMirror returns whether a request is mirrored given the mirror percentage, which defaults to 100.
+func sampleMirror(percentage *uint32) bool {
+	if percentage == nil {
+		return true
+	}
+	return rand.Intn(100) < int(*percentage)
+}
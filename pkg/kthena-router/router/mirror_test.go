@@ -0,0 +1,290 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"istio.io/istio/pkg/util/sets"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+)
+
+// recordingAccessLogger sends the logged entries to a channel.
+type recordingAccessLogger struct {
+	entries chan *accesslog.AccessLogEntry
+}
+
+func (l *recordingAccessLogger) Log(entry *accesslog.AccessLogEntry) error {
+	l.entries <- entry
+	return nil
+}
+
+func (l *recordingAccessLogger) Close() error {
+	return nil
+}
+
+// serveMirroredRequest sends a completion request through a router set up by setupMirroredRouter.
+// It returns the response and the access log entry of the mirrored request.
+func serveMirroredRequest(t *testing.T, handler, mirrorHandler http.Handler) (*httptest.ResponseRecorder, *accesslog.AccessLogEntry) {
+	req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+	return sendMirroredRequest(t, setupMirroredRouter(t, handler, mirrorHandler), req)
+}
+
+// setupMirroredRouter returns a router whose ModelRoute mirrors all the requests to a ModelServer served by mirrorHandler.
+func setupMirroredRouter(t *testing.T, handler, mirrorHandler http.Handler) *Router {
+	router := setupAggregatedRouter(t, handler, nil)
+	router.accessLogger = &recordingAccessLogger{entries: make(chan *accesslog.AccessLogEntry, 1)}
+
+	mirrorBackend := httptest.NewServer(mirrorHandler)
+	t.Cleanup(mirrorBackend.Close)
+	mirrorURL, _ := url.Parse(mirrorBackend.URL)
+	mirrorPort, _ := strconv.Atoi(mirrorURL.Port())
+	mirrorServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-mirror", Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			Model:           func(s string) *string { return &s }("test-model-candidate"),
+			WorkloadPort:    aiv1alpha1.WorkloadPort{Port: int32(mirrorPort)},
+			InferenceEngine: "vLLM",
+		},
+	}
+	mirrorPod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod-mirror", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: mirrorURL.Hostname(), Phase: corev1.PodRunning},
+	}
+	assert.NoError(t, router.store.AddOrUpdateModelServer(mirrorServer, sets.New(types.NamespacedName{Name: "pod-mirror", Namespace: "default"})))
+	assert.NoError(t, router.store.AddOrUpdatePod(mirrorPod, []*aiv1alpha1.ModelServer{mirrorServer}))
+	assert.NoError(t, router.store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{{
+				TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}},
+				Mirror:       &aiv1alpha1.MirrorTarget{ModelServerName: "ms-mirror"},
+			}},
+		},
+	}))
+	return router
+}
+
+// sendMirroredRequest sends req through a router set up by setupMirroredRouter.
+// It returns the response and the access log entry of the mirrored request.
+func sendMirroredRequest(t *testing.T, router *Router, req *http.Request) (*httptest.ResponseRecorder, *accesslog.AccessLogEntry) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-request-id", "request-1")
+	w := serveRequest(router, req)
+
+	select {
+	case entry := <-router.accessLogger.(*recordingAccessLogger).entries:
+		return w, entry
+	case <-time.After(10 * time.Second):
+		t.Fatal("the mirrored request was not logged")
+		return w, nil
+	}
+}
+
+func TestMirrorRequest(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"primary"}`)
+	})
+	mirrored := make(chan map[string]any, 1)
+	mirrorHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		body["x-request-id"] = r.Header.Get("x-request-id")
+		mirrored <- body
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"mirror"}`)
+	})
+
+	w, entry := serveMirroredRequest(t, handler, mirrorHandler)
+
+	// The client only gets the response of the ModelServer of the rule
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":"primary"}`, w.Body.String())
+
+	body := <-mirrored
+	assert.Equal(t, "test-model-candidate", body["model"])
+	assert.Equal(t, "hello", body["prompt"])
+	assert.Equal(t, "request-1", body["x-request-id"])
+
+	assert.True(t, entry.Mirror)
+	assert.Equal(t, "request-1", entry.RequestID)
+	assert.Equal(t, "default/mr-1", entry.ModelRoute)
+	assert.Equal(t, "default/ms-mirror", entry.ModelServer)
+	assert.Equal(t, "pod-mirror", entry.SelectedPod)
+	assert.Equal(t, http.StatusOK, entry.StatusCode)
+	assert.Nil(t, entry.Error)
+}
+
+func TestMirrorRequestFailure(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"primary"}`)
+	})
+	mirrorHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	w, entry := serveMirroredRequest(t, handler, mirrorHandler)
+
+	// A failing mirror never affects the client
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":"primary"}`, w.Body.String())
+
+	assert.True(t, entry.Mirror)
+	assert.Equal(t, "request-1", entry.RequestID)
+	assert.Equal(t, http.StatusInternalServerError, entry.StatusCode)
+	if assert.NotNil(t, entry.Error) {
+		assert.Equal(t, "mirror", entry.Error.Type)
+	}
+}
+
+func TestMirrorRequestResponsesTranslated(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	})
+	mirrored := make(chan map[string]any, 1)
+	mirrorHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		body["path"] = r.URL.Path
+		mirrored <- body
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"chatcmpl-2"}`)
+	})
+	router := setupMirroredRouter(t, handler, mirrorHandler)
+	router.responsesTranslationEngines.Insert("vLLM")
+
+	req, _ := http.NewRequest("POST", "/v1/responses", bytes.NewBufferString(`{"model": "test-model", "input": "hello"}`))
+	w, entry := sendMirroredRequest(t, router, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, entry.Error)
+
+	// The mirror ModelServer gets the chat completion the request is translated into
+	body := <-mirrored
+	assert.Equal(t, "/v1/chat/completions", body["path"])
+	assert.Equal(t, "test-model-candidate", body["model"])
+	assert.Equal(t, []any{map[string]any{"role": "user", "content": "hello"}}, body["messages"])
+	assert.NotContains(t, body, "input")
+}
+
+func TestMirrorRequestSkipsEjectedPods(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"primary"}`)
+	})
+	mirrorHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"mirror"}`)
+	})
+	router := setupMirroredRouter(t, handler, mirrorHandler)
+
+	// The second mirror pod is unreachable and ejected after its first failure
+	mirrorServerName := types.NamespacedName{Name: "ms-mirror", Namespace: "default"}
+	mirrorServer := router.store.GetModelServer(mirrorServerName).DeepCopy()
+	mirrorServer.Spec.TrafficPolicy = &aiv1alpha1.TrafficPolicy{
+		OutlierDetection: &aiv1alpha1.OutlierDetection{
+			ConsecutiveFailures: func(i int32) *int32 { return &i }(1),
+			MaxEjectionPercent:  func(i int32) *int32 { return &i }(100),
+		},
+	}
+	ejectedPod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod-mirror-2", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: "192.0.2.1", Phase: corev1.PodRunning},
+	}
+	assert.NoError(t, router.store.AddOrUpdateModelServer(mirrorServer, sets.New(
+		types.NamespacedName{Name: "pod-mirror", Namespace: "default"},
+		types.NamespacedName{Name: "pod-mirror-2", Namespace: "default"},
+	)))
+	assert.NoError(t, router.store.AddOrUpdatePod(ejectedPod, []*aiv1alpha1.ModelServer{mirrorServer}))
+	pods, err := router.store.GetPodsByModelServer(mirrorServerName)
+	assert.NoError(t, err)
+	for _, pod := range pods {
+		if pod.Pod.Name == "pod-mirror-2" {
+			router.store.ReportRequestResult(mirrorServerName, pod, false)
+			assert.True(t, pod.IsEjected())
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+		_, entry := sendMirroredRequest(t, router, req)
+		assert.Equal(t, "pod-mirror", entry.SelectedPod)
+		assert.Nil(t, entry.Error)
+	}
+}
+
+func TestMirrorRequestDisaggregated(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"primary"}`)
+	})
+	var mirrored atomic.Int32
+	mirrorHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrored.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+	router := setupMirroredRouter(t, handler, mirrorHandler)
+
+	mirrorServerName := types.NamespacedName{Name: "ms-mirror", Namespace: "default"}
+	mirrorServer := router.store.GetModelServer(mirrorServerName).DeepCopy()
+	mirrorServer.Spec.WorkloadSelector = &aiv1alpha1.WorkloadSelector{
+		PDGroup: &aiv1alpha1.PDGroup{
+			GroupKey:      "group",
+			DecodeLabels:  map[string]string{"app": "decode"},
+			PrefillLabels: map[string]string{"app": "prefill"},
+		},
+	}
+	assert.NoError(t, router.store.AddOrUpdateModelServer(mirrorServer, sets.New(types.NamespacedName{Name: "pod-mirror", Namespace: "default"})))
+
+	req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+	w, entry := sendMirroredRequest(t, router, req)
+
+	// A mirrored request can't be split into prefill and decode requests, it is only logged
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":"primary"}`, w.Body.String())
+	if assert.NotNil(t, entry.Error) {
+		assert.Equal(t, errMirrorDisaggregated.Error(), entry.Error.Message)
+	}
+	assert.Zero(t, mirrored.Load())
+}
+
+func TestSampleMirror(t *testing.T) {
+	percentage := func(p uint32) *uint32 { return &p }
+	for i := 0; i < 100; i++ {
+		assert.True(t, sampleMirror(nil))
+		assert.True(t, sampleMirror(percentage(100)))
+		assert.False(t, sampleMirror(percentage(0)))
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+const (
+	modelsPath = "/v1/models"
+
+	// modelOwner is the owned_by field of the models listed by the router.
+	modelOwner = "kthena"
+)
+
+// Model is an entry of the OpenAI compatible models list.
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+	// Root is the model served by the inference engine, which can differ from the model name of the ModelRoute.
+	Root string `json:"root,omitempty"`
+	// Parent is the base model of a LoRA adapter.
+	Parent string `json:"parent,omitempty"`
+}
+
+// ModelList is the response of GET /v1/models.
+type ModelList struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
+
+// isModelsRequest reports whether the request lists or retrieves models, which is answered by the router itself.
+func isModelsRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	return path == modelsPath || strings.HasPrefix(path, modelsPath+"/")
+}
+
+// handleModels serves GET /v1/models and GET /v1/models/{id} from the ModelRoutes of the datastore.
+// Model ids may contain slashes, e.g. Qwen/Qwen2.5-7B-Instruct.
+func (r *Router) handleModels(c *gin.Context) {
+	models := r.listModels(c)
+
+	id := strings.TrimPrefix(strings.TrimSuffix(c.Request.URL.Path, "/"), modelsPath)
+	if id == "" {
+		c.JSON(http.StatusOK, ModelList{Object: "list", Data: models})
+		return
+	}
+	id = strings.TrimPrefix(id, "/")
+	for _, model := range models {
+		if model.ID == id {
+			c.JSON(http.StatusOK, model)
+			return
+		}
+	}
+	c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("model %s not found", id))
+}
+
+// listModels returns the models and LoRA adapters of the ModelRoutes, sorted by id. Like inference requests,
+// a model is only listed if the request can be routed to it, that is the ModelRoute is attached to the gateway
+// the request arrived on and one of its rules matches the request headers.
+// LoRA adapters are only listed once they are loaded on a pod of the ModelRoute.
+func (r *Router) listModels(c *gin.Context) []Model {
+	gatewayKey := getGatewayKey(c)
+
+	seen := make(map[string]bool)
+	models := []Model{}
+	for _, route := range r.store.GetAllModelRoutes() {
+		var names []string
+		if route.Spec.ModelName != "" {
+			names = append(names, route.Spec.ModelName)
+		}
+		names = append(names, route.Spec.LoraAdapters...)
+
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+			modelServerName, isLora, modelRoute, err := r.store.MatchModelServer(name, c.Request, gatewayKey)
+			if err != nil {
+				continue
+			}
+			if isLora && !r.isLoraLoaded(name, modelRoute) {
+				continue
+			}
+			seen[name] = true
+
+			model := Model{
+				ID:      name,
+				Object:  "model",
+				Created: modelRoute.CreationTimestamp.Unix(),
+				OwnedBy: modelOwner,
+				Root:    name,
+			}
+			if modelServer := r.store.GetModelServer(modelServerName); modelServer != nil && modelServer.Spec.Model != nil {
+				if isLora {
+					model.Parent = *modelServer.Spec.Model
+				} else {
+					model.Root = *modelServer.Spec.Model
+				}
+			}
+			models = append(models, model)
+		}
+	}
+
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].ID < models[j].ID
+	})
+	return models
+}
+
+// isLoraLoaded reports whether a pod of one of the ModelServers targeted by the ModelRoute serves the LoRA adapter.
+func (r *Router) isLoraLoaded(lora string, modelRoute *v1alpha1.ModelRoute) bool {
+	for _, rule := range modelRoute.Spec.Rules {
+		for _, target := range rule.TargetModels {
+			pods, err := r.store.GetPodsByModelServer(types.NamespacedName{Namespace: modelRoute.Namespace, Name: target.ModelServerName})
+			if err != nil {
+				continue
+			}
+			for _, pod := range pods {
+				if pod.Contains(lora) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// getGatewayKey returns the gateway the request arrived on, set by the Gateway listener. It is empty without Gateway API.
+func getGatewayKey(c *gin.Context) string {
+	if key, exists := c.Get(GatewayKey); exists {
+		if k, ok := key.(string); ok {
+			return k
+		}
+	}
+	return ""
+}
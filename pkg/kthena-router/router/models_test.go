@@ -0,0 +1,204 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"istio.io/istio/pkg/util/sets"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+func setupModelsTestRouter(t *testing.T) *Router {
+	router, store, backend := setupTestRouter(http.NotFoundHandler())
+	t.Cleanup(backend.Close)
+
+	modelServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			Model:           func(s string) *string { return &s }("Qwen/Qwen2.5-7B-Instruct"),
+			InferenceEngine: "vLLM",
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod-1", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.1", Phase: corev1.PodRunning},
+	}
+	require.NoError(t, store.AddOrUpdateModelServer(modelServer, sets.New(types.NamespacedName{Name: "pod-1", Namespace: "default"})))
+	require.NoError(t, store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{modelServer}))
+	store.GetPodInfo(types.NamespacedName{Name: "pod-1", Namespace: "default"}).UpdateModels([]string{"Qwen/Qwen2.5-7B-Instruct", "sql-lora"})
+
+	rules := []*aiv1alpha1.Rule{{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}}}}
+	routes := []*aiv1alpha1.ModelRoute{
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "qwen", Namespace: "default", CreationTimestamp: v1.Unix(1700000000, 0)},
+			Spec: aiv1alpha1.ModelRouteSpec{
+				ModelName:    "qwen",
+				LoraAdapters: []string{"sql-lora", "unloaded-lora"},
+				Rules:        rules,
+			},
+		},
+		{
+			// only routed for premium users
+			ObjectMeta: v1.ObjectMeta{Name: "premium", Namespace: "default"},
+			Spec: aiv1alpha1.ModelRouteSpec{
+				ModelName: "premium",
+				Rules: []*aiv1alpha1.Rule{{
+					ModelMatch: &aiv1alpha1.ModelMatch{
+						Headers: map[string]*aiv1alpha1.StringMatch{"x-user-tier": {Exact: func(s string) *string { return &s }("premium")}},
+					},
+					TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}},
+				}},
+			},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "internal", Namespace: "default"},
+			Spec: aiv1alpha1.ModelRouteSpec{
+				ModelName:  "internal",
+				ParentRefs: []gatewayv1.ParentReference{{Name: "internal-gateway"}},
+				Rules:      rules,
+			},
+		},
+	}
+	for _, route := range routes {
+		require.NoError(t, store.AddOrUpdateModelRoute(route))
+	}
+	require.NoError(t, store.AddOrUpdateGateway(&gatewayv1.Gateway{
+		ObjectMeta: v1.ObjectMeta{Name: "internal-gateway", Namespace: "default"},
+	}))
+	return router
+}
+
+func serveModelsRequest(router *Router, path string, header http.Header, gatewayKey string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		c.Request.Header[k] = v
+	}
+	if gatewayKey != "" {
+		c.Set(GatewayKey, gatewayKey)
+	}
+	router.HandlerFunc()(c)
+	return w
+}
+
+func modelIDs(t *testing.T, w *httptest.ResponseRecorder) []string {
+	var list ModelList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, "list", list.Object)
+	ids := []string{}
+	for _, model := range list.Data {
+		ids = append(ids, model.ID)
+	}
+	return ids
+}
+
+func TestRouter_ListModels(t *testing.T) {
+	router := setupModelsTestRouter(t)
+
+	tests := []struct {
+		name       string
+		header     http.Header
+		gatewayKey string
+		want       []string
+	}{
+		{
+			name: "without gateway",
+			want: []string{"qwen", "sql-lora"},
+		},
+		{
+			name:   "rules matching the request headers",
+			header: http.Header{"X-User-Tier": []string{"premium"}},
+			want:   []string{"premium", "qwen", "sql-lora"},
+		},
+		{
+			name:       "routes attached to the gateway",
+			gatewayKey: "default/internal-gateway",
+			want:       []string{"internal"},
+		},
+		{
+			name:       "unknown gateway",
+			gatewayKey: "default/unknown",
+			want:       []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveModelsRequest(router, "/v1/models", tt.header, tt.gatewayKey)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.want, modelIDs(t, w))
+		})
+	}
+}
+
+func TestRouter_GetModel(t *testing.T) {
+	router := setupModelsTestRouter(t)
+
+	w := serveModelsRequest(router, "/v1/models/qwen", nil, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var model Model
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &model))
+	assert.Equal(t, Model{
+		ID:      "qwen",
+		Object:  "model",
+		Created: 1700000000,
+		OwnedBy: modelOwner,
+		Root:    "Qwen/Qwen2.5-7B-Instruct",
+	}, model)
+
+	w = serveModelsRequest(router, "/v1/models/sql-lora", nil, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &model))
+	assert.Equal(t, "sql-lora", model.ID)
+	assert.Equal(t, "Qwen/Qwen2.5-7B-Instruct", model.Parent)
+
+	for _, path := range []string{"/v1/models/premium", "/v1/models/unloaded-lora", "/v1/models/unknown"} {
+		w = serveModelsRequest(router, path, nil, "")
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
+
+func TestIsModelsRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{method: http.MethodGet, path: "/v1/models", want: true},
+		{method: http.MethodGet, path: "/v1/models/", want: true},
+		{method: http.MethodGet, path: "/v1/models/Qwen/Qwen2.5-7B-Instruct", want: true},
+		{method: http.MethodPost, path: "/v1/models", want: false},
+		{method: http.MethodGet, path: "/v1/modelsx", want: false},
+		{method: http.MethodPost, path: "/v1/chat/completions", want: false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		assert.Equal(t, tt.want, isModelsRequest(req), "%s %s", tt.method, tt.path)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
+)
+
+const (
+	// OpenInferenceModelsPrefix is the prefix of the model endpoints of the Open Inference Protocol (KServe v2),
+	// implemented by Triton.
+	OpenInferenceModelsPrefix = "/v2/models/"
+
+	// openInferenceBodyKey is the gin context key of the raw body of an Open Inference Protocol request,
+	// which is forwarded unchanged. It can be json or use the binary tensor data extension.
+	openInferenceBodyKey = "openInferenceBody"
+)
+
+// openInferencePath is an infer request path: /v2/models/{name}[/versions/{version}]/infer
+type openInferencePath struct {
+	model string
+	// suffix is the part of the path following the model name
+	suffix string
+}
+
+// parseOpenInferencePath parses the path of an Open Inference Protocol infer request.
+func parseOpenInferencePath(path string) (openInferencePath, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSuffix(path, "/"), OpenInferenceModelsPrefix)
+	if !ok {
+		return openInferencePath{}, false
+	}
+	model, suffix, _ := strings.Cut(rest, "/")
+	if model == "" {
+		return openInferencePath{}, false
+	}
+	parts := strings.Split(suffix, "/")
+	isInfer := len(parts) == 1 && parts[0] == "infer" ||
+		len(parts) == 3 && parts[0] == "versions" && parts[1] != "" && parts[2] == "infer"
+	if !isInfer {
+		return openInferencePath{}, false
+	}
+	return openInferencePath{model: model, suffix: "/" + suffix}, true
+}
+
+// openInferenceRequestParser parses Open Inference Protocol infer requests, whose model is part of the path.
+// The body holds tensors, it is kept in the gin context and has no token-countable text.
+type openInferenceRequestParser struct{}
+
+func (p *openInferenceRequestParser) ParseBody(c *gin.Context, body []byte) (ModelRequest, error) {
+	path, ok := parseOpenInferencePath(c.Request.URL.Path)
+	if !ok {
+		return nil, fmt.Errorf("invalid infer request path %s", c.Request.URL.Path)
+	}
+	c.Set(openInferenceBodyKey, body)
+	return ModelRequest{"model": path.model}, nil
+}
+
+func (p *openInferenceRequestParser) ParsePrompt(_ ModelRequest) (common.ChatMessage, error) {
+	return common.ChatMessage{}, nil
+}
+
+// buildOpenInferenceRequest sets the path of the request with the model of modelRequest, which may have been
+// overridden by the ModelServer, and the unchanged body.
+func buildOpenInferenceRequest(req *http.Request, body []byte, modelRequest ModelRequest) (*http.Request, error) {
+	path, ok := parseOpenInferencePath(req.URL.Path)
+	if !ok {
+		return nil, fmt.Errorf("invalid infer request path %s", req.URL.Path)
+	}
+	if model, ok := modelRequest["model"].(string); ok {
+		path.model = model
+	}
+
+	req.URL.Scheme = "http"
+	req.URL.Path = OpenInferenceModelsPrefix + path.model + path.suffix
+	req.URL.RawPath = ""
+	connectors.SetRequestBody(req, body)
+	return req, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"istio.io/istio/pkg/util/sets"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+func TestParseOpenInferencePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want openInferencePath
+		ok   bool
+	}{
+		{path: "/v2/models/resnet/infer", want: openInferencePath{model: "resnet", suffix: "/infer"}, ok: true},
+		{path: "/v2/models/resnet/infer/", want: openInferencePath{model: "resnet", suffix: "/infer"}, ok: true},
+		{path: "/v2/models/resnet/versions/2/infer", want: openInferencePath{model: "resnet", suffix: "/versions/2/infer"}, ok: true},
+		{path: "/v2/models/resnet", ok: false},
+		{path: "/v2/models/resnet/ready", ok: false},
+		{path: "/v2/models/resnet/versions//infer", ok: false},
+		{path: "/v2/models//infer", ok: false},
+		{path: "/v1/chat/completions", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseOpenInferencePath(tt.path)
+		assert.Equal(t, tt.ok, ok, tt.path)
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+}
+
+func TestRouter_HandlerFunc_OpenInference(t *testing.T) {
+	inferBody := `{"inputs": [{"name": "INPUT0", "shape": [1, 4], "datatype": "FP32", "data": [1, 2, 3, 4]}]}`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the model name of the path is overridden by the ModelServer and the body is forwarded unchanged
+		assert.Equal(t, "/v2/models/test-model-base/versions/1/infer", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, inferBody, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"model_name": "test-model-base", "outputs": [{"name": "OUTPUT0", "shape": [1], "datatype": "INT64", "data": [2]}]}`)
+	})
+
+	req, _ := http.NewRequest("POST", "/v2/models/test-model/versions/1/infer", bytes.NewBufferString(inferBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveAggregatedRequest(t, handler, nil, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"OUTPUT0"`)
+
+	req, _ = http.NewRequest("POST", "/v2/models/unknown/infer", bytes.NewBufferString(inferBody))
+	w = serveAggregatedRequest(t, handler, nil, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRouter_HandlerFunc_OpenInferenceDisaggregated(t *testing.T) {
+	var calls atomic.Int32
+	router, store, backend := setupTestRouter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(backend.Close)
+	backendURL, _ := url.Parse(backend.URL)
+	backendPort, _ := strconv.Atoi(backendURL.Port())
+
+	modelServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			Model:           func(s string) *string { return &s }("test-model-base"),
+			WorkloadPort:    aiv1alpha1.WorkloadPort{Port: int32(backendPort)},
+			InferenceEngine: "vLLM",
+			WorkloadSelector: &aiv1alpha1.WorkloadSelector{
+				PDGroup: &aiv1alpha1.PDGroup{
+					GroupKey:      "group",
+					DecodeLabels:  map[string]string{"app": "decode"},
+					PrefillLabels: map[string]string{"app": "prefill"},
+				},
+			},
+		},
+	}
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "decode-pod-1", Namespace: "default", Labels: map[string]string{"app": "decode", "group": "test-group"}},
+			Status:     corev1.PodStatus{PodIP: backendURL.Hostname(), Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "prefill-pod-1", Namespace: "default", Labels: map[string]string{"app": "prefill", "group": "test-group"}},
+			Status:     corev1.PodStatus{PodIP: backendURL.Hostname(), Phase: corev1.PodRunning},
+		},
+	}
+	modelRoute := &aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}}},
+			},
+		},
+	}
+	assert.NoError(t, store.AddOrUpdateModelServer(modelServer, sets.New(
+		types.NamespacedName{Name: "decode-pod-1", Namespace: "default"},
+		types.NamespacedName{Name: "prefill-pod-1", Namespace: "default"},
+	)))
+	for _, pod := range pods {
+		assert.NoError(t, store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{modelServer}))
+	}
+	assert.NoError(t, store.AddOrUpdateModelRoute(modelRoute))
+
+	req, _ := http.NewRequest("POST", "/v2/models/test-model/infer", bytes.NewBufferString(
+		`{"inputs": [{"name": "INPUT0", "shape": [1], "datatype": "FP32", "data": [1]}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveRequest(router, req)
+
+	// The KV connectors can't split Open Inference requests into prefill and decode requests
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Zero(t, calls.Load())
+}
@@ -0,0 +1,149 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/auth"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/quota"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/ratelimit"
+)
+
+// Rate limit headers, as sent by OpenAI compatible APIs
+const (
+	headerLimitRequests     = "x-ratelimit-limit-requests"
+	headerLimitTokens       = "x-ratelimit-limit-tokens"
+	headerRemainingRequests = "x-ratelimit-remaining-requests"
+	headerRemainingTokens   = "x-ratelimit-remaining-tokens"
+	headerResetRequests     = "x-ratelimit-reset-requests"
+	headerResetTokens       = "x-ratelimit-reset-tokens"
+	headerRetryAfter        = "Retry-After"
+)
+
+// Token quota headers
+const (
+	headerQuotaLimit     = "x-quota-limit-tokens"
+	headerQuotaRemaining = "x-quota-remaining-tokens"
+	headerQuotaReset     = "x-quota-reset-tokens"
+	headerQuotaWarning   = "x-quota-warning"
+)
+
+// rateLimitKey returns the rate limit key of the request read from the source of key, or "" if it has none.
+func rateLimitKey(c *gin.Context, key *v1alpha1.RateLimitKey) string {
+	if key == nil {
+		return ""
+	}
+	switch key.Source {
+	case v1alpha1.RateLimitKeyUser:
+		return c.GetString(common.UserIdKey)
+	case v1alpha1.RateLimitKeyAPIKey:
+		apiKey := c.Request.Header.Get("x-api-key")
+		if bearer, ok := strings.CutPrefix(c.Request.Header.Get("Authorization"), "Bearer "); ok {
+			apiKey = bearer
+		}
+		if apiKey == "" {
+			return ""
+		}
+		// API keys are secrets, only their digest is kept as the key
+		digest := sha256.Sum256([]byte(apiKey))
+		return hex.EncodeToString(digest[:])
+	case v1alpha1.RateLimitKeyHeader:
+		return c.Request.Header.Get(key.Header)
+	case v1alpha1.RateLimitKeyJWTClaim:
+		claim, _ := auth.GetClaim(c, key.JWTClaim)
+		return claim
+	}
+	return ""
+}
+
+// maxOutputTokens returns the maximum number of output tokens set by the request, or 0 if it sets none.
+func maxOutputTokens(modelRequest ModelRequest) int {
+	for _, field := range []string{"max_completion_tokens", "max_tokens", "max_output_tokens"} {
+		switch v := modelRequest[field].(type) {
+		case float64:
+			if v > 0 {
+				return int(v)
+			}
+		case int:
+			if v > 0 {
+				return v
+			}
+		}
+	}
+	return 0
+}
+
+// addOutputTokens adds the output tokens generated for the request, to be charged to its rate limit once it completes.
+func addOutputTokens(c *gin.Context, tokens int) {
+	c.Set(common.OutputTokensKey, c.GetInt(common.OutputTokensKey)+tokens)
+}
+
+// setRateLimitHeaders sets the rate limit headers of the response from the state of the rate limits of the request.
+func setRateLimitHeaders(c *gin.Context, status *ratelimit.Status) {
+	if status == nil {
+		return
+	}
+	if status.Requests != nil {
+		c.Header(headerLimitRequests, strconv.Itoa(status.Requests.Limit))
+		c.Header(headerRemainingRequests, strconv.Itoa(status.Requests.Remaining))
+		c.Header(headerResetRequests, formatReset(status.Requests.Reset))
+	}
+	if status.Tokens != nil {
+		c.Header(headerLimitTokens, strconv.Itoa(status.Tokens.Limit))
+		c.Header(headerRemainingTokens, strconv.Itoa(status.Tokens.Remaining))
+		c.Header(headerResetTokens, formatReset(status.Tokens.Reset))
+	}
+}
+
+// setQuotaHeaders sets the quota headers of the response from the state of the token quota of the request,
+// with a warning once its soft budget is consumed.
+func setQuotaHeaders(c *gin.Context, status *quota.Status) {
+	if status == nil {
+		return
+	}
+	if status.Limit > 0 {
+		c.Header(headerQuotaLimit, strconv.FormatInt(status.Limit, 10))
+		c.Header(headerQuotaRemaining, strconv.FormatInt(max(status.Limit-status.Used, 0), 10))
+	}
+	c.Header(headerQuotaReset, formatReset(status.Reset))
+	if status.SoftExceeded() {
+		c.Header(headerQuotaWarning, fmt.Sprintf("soft token quota of %d tokens per %s exceeded", status.SoftLimit, status.Period))
+	}
+}
+
+// setRetryAfter sets the Retry-After header of a rejected request in seconds, rounded up so that clients
+// do not retry before the limit allows the request.
+func setRetryAfter(c *gin.Context, retryAfter time.Duration) {
+	seconds := max(int64(math.Ceil(retryAfter.Seconds())), 1)
+	c.Header(headerRetryAfter, strconv.FormatInt(seconds, 10))
+}
+
+// formatReset formats a reset duration like OpenAI, e.g. 1s, 6m0s or 20ms.
+func formatReset(reset time.Duration) string {
+	return reset.Round(time.Millisecond).String()
+}
@@ -0,0 +1,370 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/quota"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/ratelimit"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	digest := sha256.Sum256([]byte("sk-test"))
+	apiKeyDigest := hex.EncodeToString(digest[:])
+
+	tests := []struct {
+		name     string
+		key      *aiv1alpha1.RateLimitKey
+		headers  map[string]string
+		userID   string
+		expected string
+	}{
+		{
+			name:     "not keyed",
+			headers:  map[string]string{"x-tenant": "tenant-a"},
+			expected: "",
+		},
+		{
+			name:     "user",
+			key:      &aiv1alpha1.RateLimitKey{Source: aiv1alpha1.RateLimitKeyUser},
+			userID:   "user-1",
+			expected: "user-1",
+		},
+		{
+			name:     "unauthenticated user",
+			key:      &aiv1alpha1.RateLimitKey{Source: aiv1alpha1.RateLimitKeyUser},
+			expected: "",
+		},
+		{
+			name:     "bearer API key",
+			key:      &aiv1alpha1.RateLimitKey{Source: aiv1alpha1.RateLimitKeyAPIKey},
+			headers:  map[string]string{"Authorization": "Bearer sk-test"},
+			expected: apiKeyDigest,
+		},
+		{
+			name:     "x-api-key",
+			key:      &aiv1alpha1.RateLimitKey{Source: aiv1alpha1.RateLimitKeyAPIKey},
+			headers:  map[string]string{"x-api-key": "sk-test"},
+			expected: apiKeyDigest,
+		},
+		{
+			name:     "missing API key",
+			key:      &aiv1alpha1.RateLimitKey{Source: aiv1alpha1.RateLimitKeyAPIKey},
+			expected: "",
+		},
+		{
+			name:     "header",
+			key:      &aiv1alpha1.RateLimitKey{Source: aiv1alpha1.RateLimitKeyHeader, Header: "x-tenant"},
+			headers:  map[string]string{"x-tenant": "tenant-a"},
+			expected: "tenant-a",
+		},
+		{
+			name:     "unauthenticated JWT claim",
+			key:      &aiv1alpha1.RateLimitKey{Source: aiv1alpha1.RateLimitKeyJWTClaim, JWTClaim: "tenant"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			for name, value := range tt.headers {
+				c.Request.Header.Set(name, value)
+			}
+			if tt.userID != "" {
+				c.Set(common.UserIdKey, tt.userID)
+			}
+			assert.Equal(t, tt.expected, rateLimitKey(c, tt.key))
+		})
+	}
+}
+
+func TestRouter_KeyedRateLimit(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), nil)
+	tokens := uint32(20)
+	assert.NoError(t, router.loadRateLimiter.AddOrUpdateLimiter("test-model", &aiv1alpha1.RateLimit{
+		InputTokensPerUnit: &tokens,
+		Unit:               aiv1alpha1.Minute,
+		Key:                &aiv1alpha1.RateLimitKey{Source: aiv1alpha1.RateLimitKeyHeader, Header: "x-tenant"},
+	}))
+
+	send := func(tenant string) int {
+		body := `{"model": "test-model", "prompt": "` + strings.Repeat("hello ", 10) + `"}`
+		req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-tenant", tenant)
+		return serveRequest(router, req).Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("tenant-a"))
+	assert.Equal(t, http.StatusTooManyRequests, send("tenant-a"))
+	// Other tenants are not affected by the requests of tenant-a
+	assert.Equal(t, http.StatusOK, send("tenant-b"))
+}
+
+func TestRouter_RequestRateLimit(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), nil)
+	requests := uint32(1)
+	assert.NoError(t, router.loadRateLimiter.AddOrUpdateLimiter("test-model", &aiv1alpha1.RateLimit{
+		RequestsPerUnit: &requests,
+		Unit:            aiv1alpha1.Minute,
+	}))
+
+	send := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return serveRequest(router, req)
+	}
+
+	w := send()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("x-ratelimit-limit-requests"))
+	assert.Equal(t, "0", w.Header().Get("x-ratelimit-remaining-requests"))
+	assert.Empty(t, w.Header().Get("x-ratelimit-limit-tokens"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = send()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, `"request rate limit exceeded"`, w.Body.String())
+	assert.Equal(t, "0", w.Header().Get("x-ratelimit-remaining-requests"))
+	// The request is refilled within a minute
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.InDelta(t, 60, retryAfter, 1)
+}
+
+func TestRouter_ReserveOutputTokens(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"cmpl-1","choices":[{"text":"hi","finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":100,"total_tokens":103}}`)
+	}), nil)
+	outputTokens := uint32(1000)
+	assert.NoError(t, router.loadRateLimiter.AddOrUpdateLimiter("test-model", &aiv1alpha1.RateLimit{
+		OutputTokensPerUnit:   &outputTokens,
+		OutputTokensAdmission: aiv1alpha1.OutputTokensReserve,
+		Unit:                  aiv1alpha1.Hour,
+	}))
+
+	send := func(maxTokens int) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"model": "test-model", "prompt": "hello", "max_tokens": %d}`, maxTokens)
+		req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		return serveRequest(router, req)
+	}
+
+	// max_tokens is reserved at admission
+	w := send(800)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "200", w.Header().Get("x-ratelimit-remaining-tokens"))
+	// The unused part of the reservation is refunded once the usage is known
+	w = send(800)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("x-ratelimit-remaining-tokens"))
+	// The tokens left can't cover max_tokens
+	w = send(900)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, `"output token rate limit exceeded"`, w.Body.String())
+}
+
+func TestRouter_ReserveOutputTokensStream(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody ModelRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&reqBody))
+		// The router asks for the usage, which the client did not ask for
+		assert.Equal(t, map[string]interface{}{"include_usage": true}, reqBody["stream_options"])
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "data: {\"id\":\"cmpl-1\",\"choices\":[{\"text\":\"hi\",\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"cmpl-1\",\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":100,\"total_tokens\":103}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}), nil)
+	outputTokens := uint32(1000)
+	assert.NoError(t, router.loadRateLimiter.AddOrUpdateLimiter("test-model", &aiv1alpha1.RateLimit{
+		OutputTokensPerUnit:   &outputTokens,
+		OutputTokensAdmission: aiv1alpha1.OutputTokensReserve,
+		Unit:                  aiv1alpha1.Hour,
+	}))
+
+	send := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello", "max_tokens": 800, "stream": true}`))
+		req.Header.Set("Content-Type", "application/json")
+		return serveRequest(router, req)
+	}
+
+	w := send()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "200", w.Header().Get("x-ratelimit-remaining-tokens"))
+	// The usage added by the router is not sent to the client
+	assert.NotContains(t, w.Body.String(), "usage")
+	// The usage is still charged: 100 output tokens of the 800 reserved
+	w = send()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("x-ratelimit-remaining-tokens"))
+}
+
+func TestRouter_Quota(t *testing.T) {
+	router := setupAggregatedRouter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"cmpl-1","choices":[{"text":"hi","finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":50,"total_tokens":53}}`)
+	}), nil)
+	// The quotas are configured directly rather than by the ModelRoute callback, which runs asynchronously
+	fileLedger, err := quota.NewFileLedger("")
+	assert.NoError(t, err)
+	router.quotas = quota.NewManager(fileLedger)
+	hard, soft := int64(100), int64(40)
+	router.quotas.AddOrUpdateQuota("test-model", &aiv1alpha1.RateLimit{
+		Quota: &aiv1alpha1.TokenQuota{
+			Period:              aiv1alpha1.QuotaMonth,
+			TokensPerPeriod:     &hard,
+			SoftTokensPerPeriod: &soft,
+		},
+	}, nil)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		return serveRequest(router, req)
+	}
+
+	// The input tokens of the request are reserved at admission
+	w := send(`{"model": "test-model", "prompt": "hello"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("x-quota-limit-tokens"))
+	assert.Equal(t, "98", w.Header().Get("x-quota-remaining-tokens"))
+	assert.NotEmpty(t, w.Header().Get("x-quota-reset-tokens"))
+	assert.Empty(t, w.Header().Get("x-quota-warning"))
+
+	// The input and output tokens of the first request consumed the soft budget, and the rest of the hard budget
+	// can't hold max_tokens
+	w = send(`{"model": "test-model", "prompt": "hello", "max_tokens": 60}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, `"token quota exceeded"`, w.Body.String())
+	assert.Equal(t, "48", w.Header().Get("x-quota-remaining-tokens"))
+
+	// The rejected request reserved nothing
+	w = send(`{"model": "test-model", "prompt": "hello", "max_tokens": 40}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "6", w.Header().Get("x-quota-remaining-tokens"))
+	assert.Equal(t, "soft token quota of 40 tokens per month exceeded", w.Header().Get("x-quota-warning"))
+
+	// The hard budget is consumed
+	w = send(`{"model": "test-model", "prompt": "hello"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, `"token quota exceeded"`, w.Body.String())
+	assert.Equal(t, "0", w.Header().Get("x-quota-remaining-tokens"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestRateLimitCallback(t *testing.T) {
+	loadRateLimiter := ratelimit.NewTokenRateLimiter()
+	fileLedger, err := quota.NewFileLedger("")
+	assert.NoError(t, err)
+	quotas := quota.NewManager(fileLedger)
+	callback := rateLimitCallback(loadRateLimiter, quotas)
+	reserve := func() *quota.Status {
+		_, status, err := quotas.Reserve(context.Background(), "test-model", "", 1)
+		assert.NoError(t, err)
+		return status
+	}
+
+	tokens := int64(100)
+	modelRoute := &aiv1alpha1.ModelRoute{Spec: aiv1alpha1.ModelRouteSpec{
+		ModelName: "test-model",
+		RateLimit: &aiv1alpha1.RateLimit{
+			Quota: &aiv1alpha1.TokenQuota{Period: aiv1alpha1.QuotaDay, TokensPerPeriod: &tokens},
+		},
+	}}
+	callback(datastore.EventData{EventType: datastore.EventAdd, ModelName: "test-model", ModelRoute: modelRoute})
+	assert.NotNil(t, reserve())
+
+	// Removing the rate limit from the ModelRoute removes its quota
+	modelRoute = modelRoute.DeepCopy()
+	modelRoute.Spec.RateLimit = nil
+	callback(datastore.EventData{EventType: datastore.EventUpdate, ModelName: "test-model", ModelRoute: modelRoute})
+	assert.Nil(t, reserve())
+
+	// With global rate limiting, the quota is kept in Redis with the client of the rate limiter
+	mr := miniredis.RunT(t)
+	modelRoute = modelRoute.DeepCopy()
+	modelRoute.Spec.RateLimit = &aiv1alpha1.RateLimit{
+		Global: &aiv1alpha1.GlobalRateLimit{Redis: &aiv1alpha1.RedisConfig{Address: mr.Addr()}},
+		Quota:  &aiv1alpha1.TokenQuota{Period: aiv1alpha1.QuotaDay, TokensPerPeriod: &tokens},
+	}
+	callback(datastore.EventData{EventType: datastore.EventUpdate, ModelName: "test-model", ModelRoute: modelRoute})
+	assert.NotNil(t, reserve())
+	assert.Len(t, mr.Keys(), 1)
+	assert.Equal(t, 1, mr.TotalConnectionCount())
+}
+
+func TestMaxOutputTokens(t *testing.T) {
+	assert.Equal(t, 0, maxOutputTokens(ModelRequest{"model": "test-model"}))
+	assert.Equal(t, 256, maxOutputTokens(ModelRequest{"max_tokens": float64(256)}))
+	assert.Equal(t, 128, maxOutputTokens(ModelRequest{"max_completion_tokens": float64(128), "max_tokens": float64(256)}))
+	assert.Equal(t, 512, maxOutputTokens(ModelRequest{"max_output_tokens": 512}))
+	assert.Equal(t, 0, maxOutputTokens(ModelRequest{"max_tokens": "256"}))
+}
+
+func TestSetRateLimitHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	setRateLimitHeaders(c, &ratelimit.Status{
+		Requests: &ratelimit.LimitStatus{Limit: 60, Remaining: 59, Reset: time.Second},
+		Tokens:   &ratelimit.LimitStatus{Limit: 150000, Remaining: 149984, Reset: 6*time.Minute + 400*time.Microsecond},
+	})
+	setRetryAfter(c, 1500*time.Millisecond)
+
+	assert.Equal(t, "60", w.Header().Get("x-ratelimit-limit-requests"))
+	assert.Equal(t, "59", w.Header().Get("x-ratelimit-remaining-requests"))
+	assert.Equal(t, "1s", w.Header().Get("x-ratelimit-reset-requests"))
+	assert.Equal(t, "150000", w.Header().Get("x-ratelimit-limit-tokens"))
+	assert.Equal(t, "149984", w.Header().Get("x-ratelimit-remaining-tokens"))
+	assert.Equal(t, "6m0s", w.Header().Get("x-ratelimit-reset-tokens"))
+	// Retry-After is rounded up
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	// Models without rate limit have no headers
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	setRateLimitHeaders(c, nil)
+	assert.Empty(t, w.Header())
+}
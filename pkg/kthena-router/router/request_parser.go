@@ -0,0 +1,278 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+const (
+	// multipartBodyKey is the gin context key of the raw body of a multipart request,
+	// which is forwarded to the upstream instead of the json encoded ModelRequest.
+	multipartBodyKey = "multipartBody"
+
+	// maxMultipartFieldSize bounds the size of a non-file field of a multipart request.
+	maxMultipartFieldSize = 1 << 20
+)
+
+// requestParser extracts the model name, the token-countable text and the streaming flag
+// of the requests of one OpenAI/vLLM endpoint family.
+type requestParser interface {
+	// ParseBody decodes the request body into a ModelRequest.
+	ParseBody(c *gin.Context, body []byte) (ModelRequest, error)
+	// ParsePrompt returns the text of the request used for token counting and prompt-aware scheduling.
+	ParsePrompt(modelRequest ModelRequest) (common.ChatMessage, error)
+}
+
+// jsonRequestParser parses requests with a json body.
+type jsonRequestParser struct {
+	parsePrompt func(body map[string]interface{}) (common.ChatMessage, error)
+}
+
+func (p *jsonRequestParser) ParseBody(_ *gin.Context, body []byte) (ModelRequest, error) {
+	var modelRequest ModelRequest
+	if err := json.Unmarshal(body, &modelRequest); err != nil {
+		return nil, err
+	}
+	return modelRequest, nil
+}
+
+func (p *jsonRequestParser) ParsePrompt(modelRequest ModelRequest) (common.ChatMessage, error) {
+	return p.parsePrompt(modelRequest)
+}
+
+// multipartRequestParser parses multipart/form-data requests, like audio transcriptions.
+// Only the non-file fields are decoded into the ModelRequest, the raw body is kept in the gin context.
+type multipartRequestParser struct{}
+
+func (p *multipartRequestParser) ParseBody(c *gin.Context, body []byte) (ModelRequest, error) {
+	reader, _, err := newMultipartReader(c.Request.Header.Get("Content-Type"), body)
+	if err != nil {
+		return nil, err
+	}
+
+	modelRequest := ModelRequest{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read multipart body: %w", err)
+		}
+		if part.FileName() != "" {
+			continue
+		}
+		value, err := io.ReadAll(io.LimitReader(part, maxMultipartFieldSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read multipart field %s: %w", part.FormName(), err)
+		}
+		modelRequest[part.FormName()] = string(value)
+	}
+
+	// Form fields are strings, convert the streaming flag so that it is handled like json requests.
+	if v, ok := modelRequest["stream"].(string); ok {
+		stream, _ := strconv.ParseBool(v)
+		modelRequest["stream"] = stream
+	}
+
+	c.Set(multipartBodyKey, body)
+	return modelRequest, nil
+}
+
+func (p *multipartRequestParser) ParsePrompt(modelRequest ModelRequest) (common.ChatMessage, error) {
+	// The audio itself is not token-countable, only the optional prompt is.
+	prompt, _ := modelRequest["prompt"].(string)
+	return common.ChatMessage{Text: prompt}, nil
+}
+
+var (
+	defaultRequestParser = &jsonRequestParser{parsePrompt: utils.ParsePrompt}
+	openInferenceParser  = &openInferenceRequestParser{}
+
+	// requestParsers maps the request paths to their parsers. Other paths are parsed as completions or chat completions.
+	requestParsers = map[string]requestParser{
+		"/v1/embeddings":           &jsonRequestParser{parsePrompt: parseEmbeddingsPrompt},
+		"/v1/rerank":               &jsonRequestParser{parsePrompt: parseRerankPrompt},
+		"/v1/score":                &jsonRequestParser{parsePrompt: parseScorePrompt},
+		common.ResponsesPath:       &jsonRequestParser{parsePrompt: parseResponsesPrompt},
+		"/v1/audio/transcriptions": &multipartRequestParser{},
+		"/v1/audio/translations":   &multipartRequestParser{},
+	}
+)
+
+// getRequestParser returns the parser of the requests sent to path.
+func getRequestParser(path string) requestParser {
+	if parser, ok := requestParsers[strings.TrimSuffix(path, "/")]; ok {
+		return parser
+	}
+	if _, ok := parseOpenInferencePath(path); ok {
+		return openInferenceParser
+	}
+	return defaultRequestParser
+}
+
+// parseEmbeddingsPrompt extracts the input of an embeddings request.
+// The input can be a string, an array of strings, or token arrays which have no text to count.
+func parseEmbeddingsPrompt(body map[string]interface{}) (common.ChatMessage, error) {
+	// vLLM also accepts chat messages for embedding models
+	if _, ok := body["messages"]; ok {
+		return utils.ParsePrompt(body)
+	}
+	input, ok := body["input"]
+	if !ok {
+		return common.ChatMessage{}, fmt.Errorf("input not found in request body")
+	}
+	texts, err := parseTexts(input)
+	if err != nil {
+		return common.ChatMessage{}, fmt.Errorf("invalid input: %w", err)
+	}
+	return common.ChatMessage{Text: strings.Join(texts, "\n")}, nil
+}
+
+// parseRerankPrompt extracts the query and the documents of a rerank request.
+func parseRerankPrompt(body map[string]interface{}) (common.ChatMessage, error) {
+	query, ok := body["query"].(string)
+	if !ok {
+		return common.ChatMessage{}, fmt.Errorf("query not found in request body")
+	}
+	documents, ok := body["documents"]
+	if !ok {
+		return common.ChatMessage{}, fmt.Errorf("documents not found in request body")
+	}
+	texts, err := parseTexts(documents)
+	if err != nil {
+		return common.ChatMessage{}, fmt.Errorf("invalid documents: %w", err)
+	}
+	return common.ChatMessage{Text: strings.Join(append([]string{query}, texts...), "\n")}, nil
+}
+
+// parseScorePrompt extracts the texts of a score request.
+func parseScorePrompt(body map[string]interface{}) (common.ChatMessage, error) {
+	var texts []string
+	for _, key := range []string{"text_1", "text_2"} {
+		value, ok := body[key]
+		if !ok {
+			return common.ChatMessage{}, fmt.Errorf("%s not found in request body", key)
+		}
+		parsed, err := parseTexts(value)
+		if err != nil {
+			return common.ChatMessage{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		texts = append(texts, parsed...)
+	}
+	return common.ChatMessage{Text: strings.Join(texts, "\n")}, nil
+}
+
+// parseTexts returns the texts of a field that is either a string or an array. Array items can be strings,
+// objects with a "text" field, or token ids that are skipped.
+func parseTexts(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		var texts []string
+		for _, item := range v {
+			switch i := item.(type) {
+			case string:
+				texts = append(texts, i)
+			case map[string]interface{}:
+				if text, ok := i["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			case float64, []interface{}:
+				// token ids
+			default:
+				return nil, fmt.Errorf("unsupported item type %T", item)
+			}
+		}
+		return texts, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// newMultipartReader returns a reader of the multipart body and its boundary.
+func newMultipartReader(contentType string, body []byte) (*multipart.Reader, string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid content type: %w", err)
+	}
+	boundary := params["boundary"]
+	if mediaType != "multipart/form-data" || boundary == "" {
+		return nil, "", fmt.Errorf("content type %s is not multipart/form-data", contentType)
+	}
+	return multipart.NewReader(bytes.NewReader(body), boundary), boundary, nil
+}
+
+// buildMultipartRequest sets the multipart body of the request with the model of modelRequest,
+// which may have been overridden by the ModelServer. The other parts are copied unchanged.
+func buildMultipartRequest(req *http.Request, body []byte, modelRequest ModelRequest) (*http.Request, error) {
+	reader, boundary, err := newMultipartReader(req.Header.Get("Content-Type"), body)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read multipart body: %w", err)
+		}
+		w, err := writer.CreatePart(textproto.MIMEHeader(part.Header))
+		if err != nil {
+			return nil, err
+		}
+		if model, ok := modelRequest["model"].(string); ok && part.FormName() == "model" && part.FileName() == "" {
+			_, err = io.WriteString(w, model)
+		} else {
+			_, err = io.Copy(w, part)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req.URL.Scheme = "http"
+	connectors.SetRequestBody(req, buf.Bytes())
+	return req, nil
+}
@@ -0,0 +1,258 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+)
+
+func TestRequestParserParsePrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		want    common.ChatMessage
+		wantErr bool
+	}{
+		{
+			name: "completions",
+			path: "/v1/completions",
+			body: `{"model": "m", "prompt": "hello"}`,
+			want: common.ChatMessage{Text: "hello"},
+		},
+		{
+			name: "chat completions",
+			path: "/v1/chat/completions",
+			body: `{"model": "m", "messages": [{"role": "user", "content": "hi"}]}`,
+			want: common.ChatMessage{Messages: []common.Message{{Role: "user", Content: "hi"}}},
+		},
+		{
+			name: "embeddings with string input",
+			path: "/v1/embeddings",
+			body: `{"model": "m", "input": "hello world"}`,
+			want: common.ChatMessage{Text: "hello world"},
+		},
+		{
+			name: "embeddings with array input",
+			path: "/v1/embeddings",
+			body: `{"model": "m", "input": ["hello", "world"]}`,
+			want: common.ChatMessage{Text: "hello\nworld"},
+		},
+		{
+			name: "embeddings with token input",
+			path: "/v1/embeddings",
+			body: `{"model": "m", "input": [[1, 2, 3]]}`,
+			want: common.ChatMessage{Text: ""},
+		},
+		{
+			name: "embeddings with chat messages",
+			path: "/v1/embeddings",
+			body: `{"model": "m", "messages": [{"role": "user", "content": "hi"}]}`,
+			want: common.ChatMessage{Messages: []common.Message{{Role: "user", Content: "hi"}}},
+		},
+		{
+			name:    "embeddings without input",
+			path:    "/v1/embeddings",
+			body:    `{"model": "m"}`,
+			wantErr: true,
+		},
+		{
+			name: "rerank with string documents",
+			path: "/v1/rerank",
+			body: `{"model": "m", "query": "q", "documents": ["a", "b"]}`,
+			want: common.ChatMessage{Text: "q\na\nb"},
+		},
+		{
+			name: "rerank with object documents",
+			path: "/v1/rerank",
+			body: `{"model": "m", "query": "q", "documents": [{"text": "a"}]}`,
+			want: common.ChatMessage{Text: "q\na"},
+		},
+		{
+			name:    "rerank without query",
+			path:    "/v1/rerank",
+			body:    `{"model": "m", "documents": ["a"]}`,
+			wantErr: true,
+		},
+		{
+			name: "score",
+			path: "/v1/score",
+			body: `{"model": "m", "text_1": "q", "text_2": ["a", "b"]}`,
+			want: common.ChatMessage{Text: "q\na\nb"},
+		},
+		{
+			name:    "score without text_2",
+			path:    "/v1/score",
+			body:    `{"model": "m", "text_1": "q"}`,
+			wantErr: true,
+		},
+		{
+			name: "responses with string input",
+			path: "/v1/responses",
+			body: `{"model": "m", "instructions": "be nice", "input": "hi"}`,
+			want: common.ChatMessage{Messages: []common.Message{
+				{Role: "system", Content: "be nice"},
+				{Role: "user", Content: "hi"},
+			}},
+		},
+		{
+			name: "responses with input items",
+			path: "/v1/responses",
+			body: `{"model": "m", "input": [
+				{"role": "developer", "content": "be nice"},
+				{"type": "message", "role": "user", "content": [
+					{"type": "input_text", "text": "what is this?"},
+					{"type": "input_image", "image_url": "https://example.com/cat.png"}
+				]},
+				{"type": "function_call", "call_id": "call_1", "name": "f", "arguments": "{}"},
+				{"type": "function_call_output", "call_id": "call_1", "output": "42"}
+			]}`,
+			want: common.ChatMessage{Messages: []common.Message{
+				{Role: "system", Content: "be nice"},
+				{
+					Role:    "user",
+					Content: "what is this?",
+					Parts: []common.ContentPart{
+						{Type: common.ContentPartText, Text: "what is this?"},
+						{Type: common.ContentPartImageURL},
+					},
+				},
+				{Role: "tool", Content: "42"},
+			}},
+		},
+		{
+			name:    "responses without input",
+			path:    "/v1/responses",
+			body:    `{"model": "m"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &body))
+			got, err := getRequestParser(tt.path).ParsePrompt(body)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func newTranscriptionRequest(t *testing.T, fields map[string]string) *http.Request {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	fileWriter, err := writer.CreateFormFile("file", "audio.wav")
+	require.NoError(t, err)
+	_, err = fileWriter.Write([]byte("RIFF fake audio"))
+	require.NoError(t, err)
+	for k, v := range fields {
+		require.NoError(t, writer.WriteField(k, v))
+	}
+	require.NoError(t, writer.Close())
+
+	req, err := http.NewRequest("POST", "/v1/audio/transcriptions", &buf)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestRouter_HandlerFunc_Embeddings(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		var reqBody ModelRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &reqBody))
+		assert.Equal(t, "test-model-base", reqBody["model"])
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"object":"list","data":[{"embedding":[0.1]}]}`)
+	})
+
+	req, _ := http.NewRequest("POST", "/v1/embeddings", bytes.NewBufferString(`{"model": "test-model", "input": ["hello", "world"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveAggregatedRequest(t, handler, nil, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"embedding"`)
+}
+
+func TestRouter_HandlerFunc_AudioTranscriptions(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audio/transcriptions", r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		// the model is rewritten, other fields and files are forwarded as is
+		assert.Equal(t, "test-model-base", r.FormValue("model"))
+		assert.Equal(t, "en", r.FormValue("language"))
+		file, _, err := r.FormFile("file")
+		require.NoError(t, err)
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, "RIFF fake audio", string(content))
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"text":"hello"}`)
+	})
+
+	req := newTranscriptionRequest(t, map[string]string{"model": "test-model", "language": "en"})
+	w := serveAggregatedRequest(t, handler, nil, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"text":"hello"}`, w.Body.String())
+}
+
+func TestMultipartRequestParserParseBody(t *testing.T) {
+	req := newTranscriptionRequest(t, map[string]string{"model": "whisper", "prompt": "hi", "stream": "true"})
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	parser := getRequestParser(req.URL.Path)
+	modelRequest, err := parser.ParseBody(c, body)
+	require.NoError(t, err)
+	assert.Equal(t, "whisper", modelRequest["model"])
+	assert.Equal(t, true, modelRequest["stream"])
+	assert.NotContains(t, modelRequest, "file")
+
+	prompt, err := parser.ParsePrompt(modelRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, common.ChatMessage{Text: "hi"}, prompt)
+
+	raw, ok := c.Get(multipartBodyKey)
+	assert.True(t, ok)
+	assert.Equal(t, body, raw)
+
+	_, err = parser.ParseBody(c, []byte(`{"model": "whisper"}`))
+	assert.Error(t, err)
+	c.Request.Header.Set("Content-Type", "application/json")
+	_, err = parser.ParseBody(c, []byte(`{"model": "whisper"}`))
+	assert.Error(t, err)
+}
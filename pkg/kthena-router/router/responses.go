@@ -0,0 +1,691 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/handlers"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+// The OpenAI Responses API is proxied as is to the inference engines implementing it. For the others,
+// requests are translated into chat completions once the ModelServer is selected, and the responses
+// are translated back. The router is stateless, so stored responses (previous_response_id) are not supported
+// by the translation.
+
+type responsesRequest struct {
+	Model string `json:"model"`
+	// Input is either a string or an array of input items
+	Input              json.RawMessage      `json:"input"`
+	Instructions       string               `json:"instructions,omitempty"`
+	MaxOutputTokens    *int                 `json:"max_output_tokens,omitempty"`
+	Temperature        *float64             `json:"temperature,omitempty"`
+	TopP               *float64             `json:"top_p,omitempty"`
+	Stream             bool                 `json:"stream,omitempty"`
+	Tools              []responsesTool      `json:"tools,omitempty"`
+	ToolChoice         json.RawMessage      `json:"tool_choice,omitempty"`
+	ParallelToolCalls  *bool                `json:"parallel_tool_calls,omitempty"`
+	Text               *responsesTextConfig `json:"text,omitempty"`
+	User               string               `json:"user,omitempty"`
+	PreviousResponseID string               `json:"previous_response_id,omitempty"`
+}
+
+type responsesInputItem struct {
+	// Type is message when omitted
+	Type string `json:"type"`
+	// message items, the content is either a string or an array of content parts
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+	// function_call and function_call_output items
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	// Output is either a string or an array of content parts
+	Output json.RawMessage `json:"output"`
+}
+
+type responsesContentPart struct {
+	Type string `json:"type"`
+	// input_text, output_text and refusal parts
+	Text    string `json:"text"`
+	Refusal string `json:"refusal"`
+	// input_image parts
+	ImageURL string `json:"image_url"`
+	Detail   string `json:"detail"`
+	// input_audio parts
+	InputAudio json.RawMessage `json:"input_audio"`
+}
+
+type responsesTool struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+type responsesTextConfig struct {
+	Format *responsesTextFormat `json:"format,omitempty"`
+}
+
+type responsesTextFormat struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+type responsesResponse struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	CreatedAt int64  `json:"created_at"`
+	Status    string `json:"status"`
+	Model     string `json:"model"`
+	// Output items are *responsesMessageItem or *responsesFunctionCallItem
+	Output            []interface{}               `json:"output"`
+	IncompleteDetails *responsesIncompleteDetails `json:"incomplete_details"`
+	Usage             *responsesUsage             `json:"usage"`
+}
+
+type responsesMessageItem struct {
+	Type    string                `json:"type"`
+	ID      string                `json:"id"`
+	Status  string                `json:"status"`
+	Role    string                `json:"role"`
+	Content []responsesOutputText `json:"content"`
+}
+
+type responsesOutputText struct {
+	Type        string        `json:"type"`
+	Text        string        `json:"text"`
+	Annotations []interface{} `json:"annotations"`
+}
+
+type responsesFunctionCallItem struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Status    string `json:"status"`
+}
+
+type responsesIncompleteDetails struct {
+	Reason string `json:"reason"`
+}
+
+type responsesUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// isResponsesRequest reports whether the request is sent to the Responses API.
+func isResponsesRequest(req *http.Request) bool {
+	return req.Method == http.MethodPost && strings.TrimSuffix(req.URL.Path, "/") == common.ResponsesPath
+}
+
+// decodeResponsesRequest decodes the body of a Responses API request.
+func decodeResponsesRequest(body map[string]interface{}) (*responsesRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	var request responsesRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if len(request.Input) == 0 {
+		return nil, fmt.Errorf("input not found in request body")
+	}
+	return &request, nil
+}
+
+// parseResponsesPrompt extracts the instructions and the input of a Responses API request as chat messages,
+// so that they are tokenized like chat completions.
+func parseResponsesPrompt(body map[string]interface{}) (common.ChatMessage, error) {
+	request, err := decodeResponsesRequest(body)
+	if err != nil {
+		return common.ChatMessage{}, err
+	}
+	messages, err := toChatMessagesFromResponses(request)
+	if err != nil {
+		return common.ChatMessage{}, err
+	}
+	return utils.ParsePrompt(map[string]interface{}{"messages": messages})
+}
+
+// translateResponsesRequest translates a Responses API request routed to a ModelServer whose inference engine
+// does not implement the API into a chat completions request, and replaces the response writer of c with one
+// translating the responses back. The returned function must be called once the request is handled.
+// req is left untouched, since a fallback ModelServer may serve the original request, the returned copy of it
+// is sent upstream instead.
+func translateResponsesRequest(c *gin.Context, req *http.Request, modelRequest ModelRequest, model string) (*http.Request, ModelRequest, func(), error) {
+	request, err := decodeResponsesRequest(modelRequest)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	chatRequest, err := toChatCompletionRequestFromResponses(request)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	upstreamReq := req.Clone(req.Context())
+	upstreamReq.URL.Path = chatCompletionsPath
+
+	translator := &responsesTranslator{model: model, createdAt: time.Now().Unix()}
+	return upstreamReq, chatRequest, translateChatCompletionResponse(c, request.Stream, translator), nil
+}
+
+// toChatCompletionRequestFromResponsesBody translates the body of a Responses API request into a chat completions
+// request, for requests whose responses are not translated back, e.g. mirrored requests.
+func toChatCompletionRequestFromResponsesBody(modelRequest ModelRequest) (ModelRequest, error) {
+	request, err := decodeResponsesRequest(modelRequest)
+	if err != nil {
+		return nil, err
+	}
+	return toChatCompletionRequestFromResponses(request)
+}
+
+// toChatCompletionRequestFromResponses translates a Responses API request into an OpenAI chat completions request.
+func toChatCompletionRequestFromResponses(request *responsesRequest) (ModelRequest, error) {
+	if request.PreviousResponseID != "" {
+		return nil, fmt.Errorf("previous_response_id is not supported, the conversation must be sent in the input")
+	}
+	messages, err := toChatMessagesFromResponses(request)
+	if err != nil {
+		return nil, err
+	}
+
+	chatRequest := ModelRequest{
+		"model":    request.Model,
+		"messages": messages,
+	}
+	if request.MaxOutputTokens != nil {
+		chatRequest["max_tokens"] = *request.MaxOutputTokens
+	}
+	if request.Temperature != nil {
+		chatRequest["temperature"] = *request.Temperature
+	}
+	if request.TopP != nil {
+		chatRequest["top_p"] = *request.TopP
+	}
+	if request.User != "" {
+		chatRequest["user"] = request.User
+	}
+	if request.Stream {
+		chatRequest["stream"] = true
+		// the usage is part of the last event of the Responses API
+		chatRequest["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	if request.ParallelToolCalls != nil {
+		chatRequest["parallel_tool_calls"] = *request.ParallelToolCalls
+	}
+
+	if len(request.Tools) > 0 {
+		tools := make([]interface{}, 0, len(request.Tools))
+		for _, tool := range request.Tools {
+			if tool.Type != "function" {
+				return nil, fmt.Errorf("unsupported tool type %s", tool.Type)
+			}
+			function := map[string]interface{}{"name": tool.Name}
+			if tool.Description != "" {
+				function["description"] = tool.Description
+			}
+			if len(tool.Parameters) > 0 {
+				function["parameters"] = tool.Parameters
+			}
+			if tool.Strict != nil {
+				function["strict"] = *tool.Strict
+			}
+			tools = append(tools, map[string]interface{}{"type": "function", "function": function})
+		}
+		chatRequest["tools"] = tools
+	}
+
+	if len(request.ToolChoice) > 0 {
+		var mode string
+		var choice struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(request.ToolChoice, &mode); err == nil {
+			chatRequest["tool_choice"] = mode
+		} else if err := json.Unmarshal(request.ToolChoice, &choice); err == nil && choice.Type == "function" {
+			chatRequest["tool_choice"] = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": choice.Name},
+			}
+		} else {
+			return nil, fmt.Errorf("unsupported tool_choice %s", request.ToolChoice)
+		}
+	}
+
+	if request.Text != nil && request.Text.Format != nil {
+		format := request.Text.Format
+		switch format.Type {
+		case "text", "json_object":
+			chatRequest["response_format"] = map[string]interface{}{"type": format.Type}
+		case "json_schema":
+			schema := map[string]interface{}{"name": format.Name, "schema": format.Schema}
+			if format.Description != "" {
+				schema["description"] = format.Description
+			}
+			if format.Strict != nil {
+				schema["strict"] = *format.Strict
+			}
+			chatRequest["response_format"] = map[string]interface{}{"type": "json_schema", "json_schema": schema}
+		default:
+			return nil, fmt.Errorf("unsupported text format %s", format.Type)
+		}
+	}
+	return chatRequest, nil
+}
+
+// toChatMessagesFromResponses translates the instructions and the input items of a Responses API request into
+// chat messages. Function calls are attached to the preceding assistant message, like parallel tool calls.
+func toChatMessagesFromResponses(request *responsesRequest) ([]interface{}, error) {
+	var messages []interface{}
+	if request.Instructions != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": request.Instructions})
+	}
+
+	var text string
+	if err := json.Unmarshal(request.Input, &text); err == nil {
+		return append(messages, map[string]interface{}{"role": "user", "content": text}), nil
+	}
+	var items []responsesInputItem
+	if err := json.Unmarshal(request.Input, &items); err != nil {
+		return nil, fmt.Errorf("input must be a string or an array of input items")
+	}
+
+	for _, item := range items {
+		switch item.Type {
+		case "", "message":
+			content, err := toChatContentFromResponses(item.Content)
+			if err != nil {
+				return nil, err
+			}
+			role := item.Role
+			if role == "developer" {
+				role = "system"
+			}
+			messages = append(messages, map[string]interface{}{"role": role, "content": content})
+		case "function_call":
+			toolCall := map[string]interface{}{
+				"id":       item.CallID,
+				"type":     "function",
+				"function": map[string]interface{}{"name": item.Name, "arguments": item.Arguments},
+			}
+			var last map[string]interface{}
+			if len(messages) > 0 {
+				last, _ = messages[len(messages)-1].(map[string]interface{})
+			}
+			if last != nil && last["role"] == "assistant" {
+				toolCalls, _ := last["tool_calls"].([]interface{})
+				last["tool_calls"] = append(toolCalls, toolCall)
+			} else {
+				messages = append(messages, map[string]interface{}{
+					"role":       "assistant",
+					"content":    nil,
+					"tool_calls": []interface{}{toolCall},
+				})
+			}
+		case "function_call_output":
+			output, err := toChatContentFromResponses(item.Output)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": item.CallID,
+				"content":      output,
+			})
+		case "reasoning":
+			// reasoning of previous turns is not sent back to chat completions
+		default:
+			return nil, fmt.Errorf("unsupported input item type %s", item.Type)
+		}
+	}
+	return messages, nil
+}
+
+// toChatContentFromResponses translates the content of an input item into the content of a chat message.
+// Text only contents are joined into a string, which all chat templates support.
+func toChatContentFromResponses(raw json.RawMessage) (interface{}, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	var parts []responsesContentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of content parts")
+	}
+
+	var texts []string
+	chatParts := make([]interface{}, 0, len(parts))
+	textOnly := true
+	for _, part := range parts {
+		switch part.Type {
+		case "input_text", "output_text":
+			texts = append(texts, part.Text)
+			chatParts = append(chatParts, map[string]interface{}{"type": common.ContentPartText, "text": part.Text})
+		case "refusal":
+			texts = append(texts, part.Refusal)
+			chatParts = append(chatParts, map[string]interface{}{"type": common.ContentPartText, "text": part.Refusal})
+		case "input_image":
+			if part.ImageURL == "" {
+				return nil, fmt.Errorf("input_image without image_url is not supported")
+			}
+			imageURL := map[string]interface{}{"url": part.ImageURL}
+			if part.Detail != "" {
+				imageURL["detail"] = part.Detail
+			}
+			textOnly = false
+			chatParts = append(chatParts, map[string]interface{}{"type": common.ContentPartImageURL, "image_url": imageURL})
+		case "input_audio":
+			textOnly = false
+			chatParts = append(chatParts, map[string]interface{}{"type": common.ContentPartInputAudio, "input_audio": part.InputAudio})
+		default:
+			return nil, fmt.Errorf("unsupported content part type %s", part.Type)
+		}
+	}
+	if textOnly {
+		return strings.Join(texts, "\n"), nil
+	}
+	return chatParts, nil
+}
+
+func newResponsesOutputText(text string) responsesOutputText {
+	return responsesOutputText{Type: "output_text", Text: text, Annotations: []interface{}{}}
+}
+
+func toResponsesUsage(usage *handlers.Usage) *responsesUsage {
+	return &responsesUsage{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+		TotalTokens:  usage.PromptTokens + usage.CompletionTokens,
+	}
+}
+
+// toResponsesID derives the ids of the response and of its message from the chat completion id.
+func toResponsesID(prefix, id string) string {
+	return prefix + strings.TrimPrefix(id, "chatcmpl-")
+}
+
+// setResponsesStatus sets the status of the response from the finish reason of the chat completion.
+func setResponsesStatus(response *responsesResponse, finishReason string) {
+	if finishReason == "length" {
+		response.Status = "incomplete"
+		response.IncompleteDetails = &responsesIncompleteDetails{Reason: "max_output_tokens"}
+		return
+	}
+	response.Status = "completed"
+}
+
+// toResponsesResponse translates a chat completion into a Responses API response.
+func toResponsesResponse(completion *openAIChatCompletion, model string, createdAt int64) *responsesResponse {
+	response := &responsesResponse{
+		ID:        toResponsesID("resp_", completion.ID),
+		Object:    "response",
+		CreatedAt: createdAt,
+		Model:     model,
+		Output:    []interface{}{},
+	}
+	var finishReason string
+	if len(completion.Choices) > 0 {
+		choice := completion.Choices[0]
+		if choice.FinishReason != nil {
+			finishReason = *choice.FinishReason
+		}
+		if choice.Message != nil {
+			if choice.Message.Content != nil && *choice.Message.Content != "" {
+				response.Output = append(response.Output, &responsesMessageItem{
+					Type:    "message",
+					ID:      toResponsesID("msg_", completion.ID),
+					Status:  "completed",
+					Role:    "assistant",
+					Content: []responsesOutputText{newResponsesOutputText(*choice.Message.Content)},
+				})
+			}
+			for _, toolCall := range choice.Message.ToolCalls {
+				response.Output = append(response.Output, &responsesFunctionCallItem{
+					Type:      "function_call",
+					ID:        "fc_" + toolCall.ID,
+					CallID:    toolCall.ID,
+					Name:      toolCall.Function.Name,
+					Arguments: toolCall.Function.Arguments,
+					Status:    "completed",
+				})
+			}
+		}
+	}
+	setResponsesStatus(response, finishReason)
+	if completion.Usage != nil {
+		response.Usage = toResponsesUsage(completion.Usage)
+	}
+	return response
+}
+
+// toResponsesError builds a Responses API error from the body of an error response of the router or of the upstream.
+func toResponsesError(status int, body []byte) gin.H {
+	errorType := "server_error"
+	if status < http.StatusInternalServerError {
+		errorType = "invalid_request_error"
+	}
+	return gin.H{
+		"error": gin.H{"message": errorMessage(status, body), "type": errorType, "param": nil, "code": nil},
+	}
+}
+
+// responsesTranslator translates chat completions into Responses API responses.
+type responsesTranslator struct {
+	model     string
+	createdAt int64
+
+	started        bool
+	finished       bool
+	sequenceNumber int
+	response       responsesResponse
+	// the output item being streamed, if any
+	message      *responsesMessageItem
+	functionCall *responsesFunctionCallItem
+	finishReason string
+}
+
+func (t *responsesTranslator) translateChunk(chunk *openAIChatCompletion) []sseEvent {
+	var events []sseEvent
+	if !t.started {
+		t.started = true
+		t.response = responsesResponse{
+			ID:        toResponsesID("resp_", chunk.ID),
+			Object:    "response",
+			CreatedAt: t.createdAt,
+			Status:    "in_progress",
+			Model:     t.model,
+			Output:    []interface{}{},
+		}
+		snapshot := t.response
+		events = append(events,
+			t.event("response.created", gin.H{"response": snapshot}),
+			t.event("response.in_progress", gin.H{"response": snapshot}),
+		)
+	}
+
+	for _, choice := range chunk.Choices {
+		if choice.Delta != nil {
+			if choice.Delta.Content != nil && *choice.Delta.Content != "" {
+				if t.message == nil {
+					events = append(events, t.closeItem()...)
+					events = append(events, t.startMessage(chunk.ID)...)
+				}
+				t.message.Content[0].Text += *choice.Delta.Content
+				events = append(events, t.event("response.output_text.delta", gin.H{
+					"item_id":       t.message.ID,
+					"output_index":  t.outputIndex(),
+					"content_index": 0,
+					"delta":         *choice.Delta.Content,
+				}))
+			}
+			for _, toolCall := range choice.Delta.ToolCalls {
+				// the first chunk of a tool call has its id
+				if toolCall.ID != "" {
+					events = append(events, t.closeItem()...)
+					events = append(events, t.startFunctionCall(toolCall)...)
+				}
+				if t.functionCall != nil && toolCall.Function.Arguments != "" {
+					t.functionCall.Arguments += toolCall.Function.Arguments
+					events = append(events, t.event("response.function_call_arguments.delta", gin.H{
+						"item_id":      t.functionCall.ID,
+						"output_index": t.outputIndex(),
+						"delta":        toolCall.Function.Arguments,
+					}))
+				}
+			}
+		}
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			events = append(events, t.closeItem()...)
+			t.finishReason = *choice.FinishReason
+		}
+	}
+
+	if chunk.Usage != nil {
+		t.response.Usage = toResponsesUsage(chunk.Usage)
+		// the usage is sent in the last chunk, after the finish reason
+		if t.finishReason != "" {
+			events = append(events, t.finishStream()...)
+		}
+	}
+	return events
+}
+
+// event builds a Responses API event, which are numbered in the stream.
+func (t *responsesTranslator) event(name string, data gin.H) sseEvent {
+	data["type"] = name
+	data["sequence_number"] = t.sequenceNumber
+	t.sequenceNumber++
+	return sseEvent{name, data}
+}
+
+func (t *responsesTranslator) outputIndex() int {
+	return len(t.response.Output) - 1
+}
+
+func (t *responsesTranslator) startMessage(id string) []sseEvent {
+	t.message = &responsesMessageItem{
+		Type:    "message",
+		ID:      toResponsesID("msg_", id),
+		Status:  "in_progress",
+		Role:    "assistant",
+		Content: []responsesOutputText{newResponsesOutputText("")},
+	}
+	t.response.Output = append(t.response.Output, t.message)
+
+	// the added item has no content yet
+	item := *t.message
+	item.Content = []responsesOutputText{}
+	return []sseEvent{
+		t.event("response.output_item.added", gin.H{"output_index": t.outputIndex(), "item": item}),
+		t.event("response.content_part.added", gin.H{
+			"item_id":       t.message.ID,
+			"output_index":  t.outputIndex(),
+			"content_index": 0,
+			"part":          newResponsesOutputText(""),
+		}),
+	}
+}
+
+func (t *responsesTranslator) startFunctionCall(toolCall openAIToolCall) []sseEvent {
+	t.functionCall = &responsesFunctionCallItem{
+		Type:   "function_call",
+		ID:     "fc_" + toolCall.ID,
+		CallID: toolCall.ID,
+		Name:   toolCall.Function.Name,
+		Status: "in_progress",
+	}
+	t.response.Output = append(t.response.Output, t.functionCall)
+	return []sseEvent{t.event("response.output_item.added", gin.H{"output_index": t.outputIndex(), "item": *t.functionCall})}
+}
+
+// closeItem ends the output item being streamed, if any.
+func (t *responsesTranslator) closeItem() []sseEvent {
+	switch {
+	case t.message != nil:
+		message := t.message
+		t.message = nil
+		message.Status = "completed"
+		return []sseEvent{
+			t.event("response.output_text.done", gin.H{
+				"item_id":       message.ID,
+				"output_index":  t.outputIndex(),
+				"content_index": 0,
+				"text":          message.Content[0].Text,
+			}),
+			t.event("response.content_part.done", gin.H{
+				"item_id":       message.ID,
+				"output_index":  t.outputIndex(),
+				"content_index": 0,
+				"part":          message.Content[0],
+			}),
+			t.event("response.output_item.done", gin.H{"output_index": t.outputIndex(), "item": *message}),
+		}
+	case t.functionCall != nil:
+		functionCall := t.functionCall
+		t.functionCall = nil
+		functionCall.Status = "completed"
+		return []sseEvent{
+			t.event("response.function_call_arguments.done", gin.H{
+				"item_id":      functionCall.ID,
+				"output_index": t.outputIndex(),
+				"arguments":    functionCall.Arguments,
+			}),
+			t.event("response.output_item.done", gin.H{"output_index": t.outputIndex(), "item": *functionCall}),
+		}
+	}
+	return nil
+}
+
+// finishStream ends the response. It is a no-op if the stream has not started or has already ended.
+func (t *responsesTranslator) finishStream() []sseEvent {
+	if !t.started || t.finished {
+		return nil
+	}
+	t.finished = true
+	events := t.closeItem()
+	setResponsesStatus(&t.response, t.finishReason)
+	name := "response.completed"
+	if t.response.Status == "incomplete" {
+		name = "response.incomplete"
+	}
+	return append(events, t.event(name, gin.H{"response": t.response}))
+}
+
+func (t *responsesTranslator) translateCompletion(completion *openAIChatCompletion) interface{} {
+	return toResponsesResponse(completion, t.model, t.createdAt)
+}
+
+func (t *responsesTranslator) translateError(status int, body []byte) interface{} {
+	return toResponsesError(status, body)
+}
@@ -0,0 +1,265 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+)
+
+func TestToChatCompletionRequestFromResponses(t *testing.T) {
+	body := `{
+		"model": "m",
+		"instructions": "be nice",
+		"max_output_tokens": 256,
+		"temperature": 0.5,
+		"stream": true,
+		"user": "user-1",
+		"tools": [{"type": "function", "name": "get_weather", "description": "weather of a city", "parameters": {"type": "object"}, "strict": true}],
+		"tool_choice": {"type": "function", "name": "get_weather"},
+		"text": {"format": {"type": "json_schema", "name": "answer", "schema": {"type": "object"}}},
+		"input": [
+			{"role": "user", "content": "what is the weather in Paris?"},
+			{"type": "message", "role": "assistant", "content": [{"type": "output_text", "text": "let me check"}]},
+			{"type": "function_call", "call_id": "call_1", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}"},
+			{"type": "function_call_output", "call_id": "call_1", "output": "sunny"},
+			{"role": "user", "content": [
+				{"type": "input_text", "text": "and this image?"},
+				{"type": "input_image", "image_url": "data:image/png;base64,AAAA", "detail": "low"}
+			]}
+		]
+	}`
+	var modelRequest ModelRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &modelRequest))
+	request, err := decodeResponsesRequest(modelRequest)
+	require.NoError(t, err)
+	chatRequest, err := toChatCompletionRequestFromResponses(request)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(chatRequest)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"model": "m",
+		"max_tokens": 256,
+		"temperature": 0.5,
+		"stream": true,
+		"stream_options": {"include_usage": true},
+		"user": "user-1",
+		"tools": [{"type": "function", "function": {"name": "get_weather", "description": "weather of a city", "parameters": {"type": "object"}, "strict": true}}],
+		"tool_choice": {"type": "function", "function": {"name": "get_weather"}},
+		"response_format": {"type": "json_schema", "json_schema": {"name": "answer", "schema": {"type": "object"}}},
+		"messages": [
+			{"role": "system", "content": "be nice"},
+			{"role": "user", "content": "what is the weather in Paris?"},
+			{"role": "assistant", "content": "let me check", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "sunny"},
+			{"role": "user", "content": [
+				{"type": "text", "text": "and this image?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA", "detail": "low"}}
+			]}
+		]
+	}`, string(data))
+
+	for _, body := range []string{
+		`{"model": "m", "input": "hi", "previous_response_id": "resp_1"}`,
+		`{"model": "m", "input": "hi", "tools": [{"type": "web_search"}]}`,
+		`{"model": "m", "input": [{"type": "item_reference", "id": "msg_1"}]}`,
+	} {
+		require.NoError(t, json.Unmarshal([]byte(body), &modelRequest))
+		request, err := decodeResponsesRequest(modelRequest)
+		require.NoError(t, err)
+		_, err = toChatCompletionRequestFromResponses(request)
+		assert.Error(t, err, body)
+	}
+}
+
+func TestToResponsesResponse(t *testing.T) {
+	completion := `{
+		"id": "chatcmpl-123",
+		"choices": [{
+			"message": {"role": "assistant", "content": "checking", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]},
+			"finish_reason": "length"
+		}],
+		"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+	}`
+	var parsed openAIChatCompletion
+	require.NoError(t, json.Unmarshal([]byte(completion), &parsed))
+
+	data, err := json.Marshal(toResponsesResponse(&parsed, "m", 1700000000))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "resp_123",
+		"object": "response",
+		"created_at": 1700000000,
+		"status": "incomplete",
+		"model": "m",
+		"output": [
+			{"type": "message", "id": "msg_123", "status": "completed", "role": "assistant", "content": [
+				{"type": "output_text", "text": "checking", "annotations": []}
+			]},
+			{"type": "function_call", "id": "fc_call_1", "call_id": "call_1", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}", "status": "completed"}
+		],
+		"incomplete_details": {"reason": "max_output_tokens"},
+		"usage": {"input_tokens": 10, "output_tokens": 5, "total_tokens": 15}
+	}`, string(data))
+}
+
+func TestRouter_HandlerFunc_ResponsesNative(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, common.ResponsesPath, r.URL.Path)
+		var reqBody ModelRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &reqBody))
+		assert.Equal(t, "test-model-base", reqBody["model"])
+		assert.NotContains(t, reqBody, "stream_options")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"sequence_number\":0,\"delta\":\"hi\"}\n\n")
+		fmt.Fprint(w, "event: response.completed\ndata: {\"type\":\"response.completed\",\"sequence_number\":1,\"response\":{\"id\":\"resp_1\",\"object\":\"response\",\"usage\":{\"input_tokens\":3,\"output_tokens\":1,\"total_tokens\":4}}}\n\n")
+	})
+
+	req, _ := http.NewRequest("POST", "/v1/responses", bytes.NewBufferString(`{"model": "test-model", "stream": true, "input": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveAggregatedRequest(t, handler, nil, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// the events are forwarded unchanged
+	assert.Contains(t, w.Body.String(), `"type":"response.completed"`)
+}
+
+func TestRouter_HandlerFunc_ResponsesTranslated(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, chatCompletionsPath, r.URL.Path)
+		var reqBody ModelRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &reqBody))
+		assert.Equal(t, "test-model-base", reqBody["model"])
+		assert.Equal(t, float64(64), reqBody["max_tokens"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	})
+	router := setupAggregatedRouter(t, handler, nil)
+	router.responsesTranslationEngines = sets.New("vLLM")
+
+	req, _ := http.NewRequest("POST", "/v1/responses", bytes.NewBufferString(`{"model": "test-model", "max_output_tokens": 64, "input": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveRequest(router, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	delete(response, "created_at")
+	data, _ := json.Marshal(response)
+	assert.JSONEq(t, `{
+		"id": "resp_1",
+		"object": "response",
+		"status": "completed",
+		"model": "test-model",
+		"output": [{"type": "message", "id": "msg_1", "status": "completed", "role": "assistant", "content": [
+			{"type": "output_text", "text": "hi", "annotations": []}
+		]}],
+		"incomplete_details": null,
+		"usage": {"input_tokens": 3, "output_tokens": 1, "total_tokens": 4}
+	}`, string(data))
+
+	req, _ = http.NewRequest("POST", "/v1/responses", bytes.NewBufferString(`{"model": "test-model", "previous_response_id": "resp_0", "input": "hello"}`))
+	w = serveRequest(router, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"invalid_request_error"`)
+}
+
+func TestRouter_HandlerFunc_ResponsesTranslatedStream(t *testing.T) {
+	chunks := []string{
+		`{"id":"chatcmpl-1","choices":[{"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","choices":[{"delta":{"content":"Hel"},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","choices":[{"delta":{"content":"lo"},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"get_weather","arguments":""}}]},"finish_reason":null}]}`,
+		`{"id":"chatcmpl-1","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		`{"id":"chatcmpl-1","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":7,"total_tokens":10}}`,
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody ModelRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &reqBody))
+		assert.Equal(t, map[string]interface{}{"include_usage": true}, reqBody["stream_options"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	router := setupAggregatedRouter(t, handler, nil)
+	router.responsesTranslationEngines = sets.New("vLLM")
+
+	req, _ := http.NewRequest("POST", "/v1/responses", bytes.NewBufferString(`{"model": "test-model", "stream": true, "input": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveRequest(router, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	var names []string
+	var data []map[string]interface{}
+	for _, event := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		lines := strings.Split(event, "\n")
+		require.Len(t, lines, 2, event)
+		names = append(names, strings.TrimPrefix(lines[0], "event: "))
+		var d map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &d))
+		data = append(data, d)
+	}
+	assert.Equal(t, []string{
+		"response.created", "response.in_progress",
+		"response.output_item.added", "response.content_part.added",
+		"response.output_text.delta", "response.output_text.delta",
+		"response.output_text.done", "response.content_part.done", "response.output_item.done",
+		"response.output_item.added", "response.function_call_arguments.delta",
+		"response.function_call_arguments.done", "response.output_item.done",
+		"response.completed",
+	}, names)
+	for i, d := range data {
+		assert.Equal(t, float64(i), d["sequence_number"])
+		assert.Equal(t, names[i], d["type"])
+	}
+	assert.Equal(t, "Hello", data[6]["text"])
+	assert.Equal(t, "{\"city\":\"Paris\"}", data[11]["arguments"])
+
+	response := data[13]["response"].(map[string]interface{})
+	assert.Equal(t, "completed", response["status"])
+	assert.Len(t, response["output"], 2)
+	assert.Equal(t, map[string]interface{}{"input_tokens": float64(3), "output_tokens": float64(7), "total_tokens": float64(10)}, response["usage"])
+}
@@ -17,20 +17,23 @@ limitations under the License.
 package router
 
 import (
-	"bufio"
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"istio.io/istio/pkg/env"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
@@ -39,9 +42,11 @@ import (
 	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/auth"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/quota"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/ratelimit"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/tokenizer"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/handlers"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metering"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
@@ -56,14 +61,28 @@ const (
 
 var EnableFairnessScheduling = env.RegisterBoolVar("ENABLE_FAIRNESS_SCHEDULING", false, "Enable fairness scheduling for inference requests").Get()
 
+// RouterReplicas is the number of router instances, which do not share the rate limits and quotas kept in memory.
+var RouterReplicas = env.RegisterIntVar("ROUTER_REPLICAS", 1, "Number of replicas of the router").Get()
+
 type Router struct {
 	scheduler       scheduler.Scheduler
 	authenticator   *auth.JWTAuthenticator
+	apiKeys         *auth.APIKeyAuthenticator
 	store           datastore.Store
 	loadRateLimiter *ratelimit.TokenRateLimiter
+	quotas          *quota.Manager
+	quotaLedger     *quota.FileLedger
+	meter           *metering.Meter
 	accessLogger    accesslog.AccessLogger
 	metrics         *metrics.Metrics
 	tokenizer       tokenizer.Tokenizer
+	// tenantHeader and tenantClaim are the sources of the tenant of usage records
+	tenantHeader string
+	tenantClaim  string
+	// mediaTokensPerItem is the token cost of the non-text content parts of chat messages by type
+	mediaTokensPerItem map[string]int
+	// responsesTranslationEngines are the inference engines whose Responses API requests are translated into chat completions
+	responsesTranslationEngines sets.Set[string]
 
 	// KV Connector management
 	connectorFactory *connectors.Factory
@@ -79,30 +98,31 @@ func NewRouter(store datastore.Store, routerConfigPath string) *Router {
 	// Initialize tokenizer
 	tokenizerInstance := tokenizer.NewSimpleEstimateTokenizer()
 
-	store.RegisterCallback("ModelRoute", func(data datastore.EventData) {
-		switch data.EventType {
-		case datastore.EventAdd, datastore.EventUpdate:
-			if data.ModelRoute == nil || data.ModelRoute.Spec.RateLimit == nil {
-				return
-			}
-			klog.Infof("add or update rate limit for model %s", data.ModelName)
-
-			// Configure the unified rate limiter for this model
-			if err := loadRateLimiter.AddOrUpdateLimiter(data.ModelName, data.ModelRoute.Spec.RateLimit); err != nil {
-				klog.Errorf("failed to configure rate limiter for model %s: %v", data.ModelName, err)
-			}
-
-		case datastore.EventDelete:
-			klog.Infof("delete rate limit for model %s", data.ModelName)
-			loadRateLimiter.DeleteLimiter(data.ModelName)
-		}
-	})
-
 	routerConfig, err := conf.ParseRouterConfig(routerConfigPath)
 	if err != nil {
 		klog.Fatalf("failed to parse router config: %v", err)
 	}
 
+	// Token quotas of the ModelRoutes without global rate limiting are kept in the ledger file of the router
+	if routerConfig.Quota.LedgerPath == "" {
+		klog.Warning("quota ledger path is not configured, the usage of token quotas is only kept in memory")
+	} else if RouterReplicas > 1 {
+		klog.Warningf("the quota ledger %s is not shared by the %d router replicas, use global rate limiting with Redis to share the quotas",
+			routerConfig.Quota.LedgerPath, RouterReplicas)
+	}
+	fileLedger, err := quota.NewFileLedger(routerConfig.Quota.LedgerPath)
+	if err != nil {
+		klog.Fatalf("failed to load quota ledger: %v", err)
+	}
+	quotas := quota.NewManager(fileLedger)
+
+	meter, err := metering.NewMeter(routerConfig.Metering, metricsInstance)
+	if err != nil {
+		klog.Fatalf("failed to create usage meter: %v", err)
+	}
+
+	store.RegisterCallback("ModelRoute", rateLimitCallback(loadRateLimiter, quotas))
+
 	// Initialize access logger with configuration from environment variables
 	accessLogConfig := &accesslog.AccessLoggerConfig{
 		Enabled: true,
@@ -138,18 +158,67 @@ func NewRouter(store datastore.Store, routerConfigPath string) *Router {
 		store:            store,
 		scheduler:        scheduler.NewScheduler(store, routerConfig),
 		authenticator:    auth.NewJWTAuthenticator(routerConfig),
+		apiKeys:          auth.NewAPIKeyAuthenticator(routerConfig),
 		loadRateLimiter:  loadRateLimiter,
+		quotas:           quotas,
+		quotaLedger:      fileLedger,
+		meter:            meter,
 		accessLogger:     accessLogger,
 		metrics:          metricsInstance,
 		tokenizer:        tokenizerInstance,
 		connectorFactory: connectors.NewDefaultFactory(),
+
+		tenantHeader:                routerConfig.Metering.TenantHeader,
+		tenantClaim:                 routerConfig.Metering.TenantClaim,
+		mediaTokensPerItem:          newMediaTokensPerItem(routerConfig.Multimodal),
+		responsesTranslationEngines: sets.New(routerConfig.ResponsesAPI.TranslateToChatCompletions...),
+	}
+}
+
+// defaultMediaTokensPerItem is the token cost of one non-text content part when it is not configured.
+// Images are counted like a 336x336 image of a CLIP ViT-L/14 encoder, audio like 30 seconds of audio,
+// and videos like a few sampled frames.
+var defaultMediaTokensPerItem = map[string]int{
+	common.ContentPartImageURL:   576,
+	common.ContentPartInputAudio: 750,
+	common.ContentPartAudioURL:   750,
+	common.ContentPartVideoURL:   2304,
+}
+
+func newMediaTokensPerItem(config conf.MultimodalConfig) map[string]int {
+	tokensPerItem := make(map[string]int, len(defaultMediaTokensPerItem)+len(config.TokensPerItem))
+	for partType, tokens := range defaultMediaTokensPerItem {
+		tokensPerItem[partType] = tokens
+	}
+	for partType, tokens := range config.TokensPerItem {
+		tokensPerItem[partType] = tokens
 	}
+	return tokensPerItem
 }
 
 type ModelRequest map[string]interface{}
 
 func (r *Router) HandlerFunc() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		start := time.Now()
+
+		// Model discovery is answered by the router without a request body
+		if isModelsRequest(c.Request) {
+			r.handleModels(c)
+			return
+		}
+
+		// Anthropic Messages API requests are handled as chat completions
+		if isAnthropicMessagesRequest(c.Request) {
+			finish, err := serveAnthropicMessages(c)
+			if err != nil {
+				accesslog.SetError(c, "request_parsing", err.Error())
+				c.AbortWithStatusJSON(http.StatusBadRequest, toAnthropicError(http.StatusBadRequest, []byte(strconv.Quote(err.Error()))))
+				return
+			}
+			defer finish()
+		}
+
 		// Step 1: Parse and validate request
 		modelRequest, err := ParseModelRequest(c)
 		if err != nil {
@@ -177,7 +246,7 @@ func (r *Router) HandlerFunc() gin.HandlerFunc {
 			r.metrics.DecActiveDownstreamRequests(modelName)
 		}()
 
-		prompt, err := utils.ParsePrompt(modelRequest)
+		prompt, err := getRequestParser(path).ParsePrompt(modelRequest)
 		if err != nil {
 			accesslog.SetError(c, "prompt_parsing", "prompt not found")
 			c.AbortWithStatusJSON(http.StatusNotFound, "prompt not found")
@@ -192,6 +261,8 @@ func (r *Router) HandlerFunc() gin.HandlerFunc {
 			klog.Errorf("failed to calculate token number: %v", err)
 			inputTokens = len(promptStr) / 4 // fallback estimation
 		}
+		// Images and audio of multimodal messages are not part of the prompt string
+		inputTokens += utils.GetMediaTokens(prompt, r.mediaTokensPerItem)
 
 		// Calculate and set input tokens for access log
 		accesslog.SetTokenCounts(c, inputTokens, 0)
@@ -203,19 +274,46 @@ func (r *Router) HandlerFunc() gin.HandlerFunc {
 		metricsRecorder.RecordInputTokens(inputTokens)
 
 		// Apply rate limiting using the unified rate limiter
-		if err := r.loadRateLimiter.RateLimit(modelName, promptStr); err != nil {
+		limitKey := rateLimitKey(c, r.loadRateLimiter.Key(modelName))
+		c.Set(common.RateLimitKey, limitKey)
+
+		// Reserve the input and maximum output tokens of the request in its token quota before the rate limits,
+		// so that requests over quota don't consume their budget
+		maxTokens := maxOutputTokens(modelRequest)
+		quotaReservation, quotaStatus, err := r.quotas.Reserve(c.Request.Context(), modelName, limitKey, int64(inputTokens+maxTokens))
+		setQuotaHeaders(c, quotaStatus)
+		if err != nil {
+			accesslog.SetError(c, "quota", err.Error())
+			metricsRecorder.RecordRateLimitExceeded(metrics.LimitTypeQuota, r.loadRateLimiter.KeyLabel(modelName, limitKey))
+			setRetryAfter(c, quotaStatus.Reset)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, err.Error())
+			metricsRecorder.Finish(strconv.Itoa(http.StatusTooManyRequests), "quota")
+			return
+		}
+
+		outputReservation, err := r.loadRateLimiter.ReserveTokens(modelName, limitKey, inputTokens, maxTokens)
+		if err != nil {
+			quotaReservation.Settle(context.Background(), 0)
 			var errorMsg string
 			var errorType string
 			var tokenType string
-			switch err.(type) {
+			var retryAfter time.Duration
+			switch e := err.(type) {
 			case *ratelimit.InputRateLimitExceededError:
 				errorMsg = "input token rate limit exceeded"
 				errorType = "input_rate_limit"
 				tokenType = metrics.LimitTypeInputTokens
+				retryAfter = e.RetryAfter
 			case *ratelimit.OutputRateLimitExceededError:
 				errorMsg = "output token rate limit exceeded"
 				errorType = "output_rate_limit"
 				tokenType = metrics.LimitTypeOutputTokens
+				retryAfter = e.RetryAfter
+			case *ratelimit.RateLimitExceededError:
+				errorMsg = "request rate limit exceeded"
+				errorType = "request_rate_limit"
+				tokenType = metrics.LimitTypeRequests
+				retryAfter = e.RetryAfter
 			default:
 				errorMsg = "token usage exceeds rate limit"
 				errorType = "rate_limit"
@@ -224,11 +322,26 @@ func (r *Router) HandlerFunc() gin.HandlerFunc {
 			accesslog.SetError(c, errorType, errorMsg)
 
 			// Record rate limit exceeded
-			metricsRecorder.RecordRateLimitExceeded(tokenType)
+			metricsRecorder.RecordRateLimitExceeded(tokenType, r.loadRateLimiter.KeyLabel(modelName, limitKey))
+			setRateLimitHeaders(c, r.loadRateLimiter.Status(modelName, limitKey))
+			setRetryAfter(c, retryAfter)
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, errorMsg)
 			metricsRecorder.Finish(strconv.Itoa(http.StatusTooManyRequests), "rate_limit")
 			return
 		}
+		setRateLimitHeaders(c, r.loadRateLimiter.Status(modelName, limitKey))
+		// Charge the output tokens of the request once it completes, refunding the unused part of its reservation,
+		// settle its quota with the tokens it consumed if it succeeded, and export its usage record
+		defer func() {
+			outputTokens := c.GetInt(common.OutputTokensKey)
+			outputReservation.Settle(outputTokens)
+			var usedTokens int64
+			if c.Writer.Status() < http.StatusBadRequest {
+				usedTokens = int64(inputTokens + outputTokens)
+			}
+			quotaReservation.Settle(context.Background(), usedTokens)
+			r.recordUsage(c, modelName, inputTokens, outputTokens, start)
+		}()
 
 		requestID := uuid.New().String()
 		if c.Request.Header.Get("x-request-id") == "" {
@@ -257,12 +370,7 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 	modelName := modelRequest["model"].(string)
 	// step 3: Find pods and model server details
 	// Get gateway key from context if available (set by Gateway listener)
-	var gatewayKey string
-	if key, exists := c.Get(GatewayKey); exists {
-		if k, ok := key.(string); ok {
-			gatewayKey = k
-		}
-	}
+	gatewayKey := getGatewayKey(c)
 	modelServerName, isLora, modelRoute, err := r.store.MatchModelServer(modelName, c.Request, gatewayKey)
 	if err != nil {
 		accesslog.SetError(c, "model_server_matching", fmt.Sprintf("can't find corresponding model server: %v", err))
@@ -270,12 +378,42 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 		return
 	}
 	klog.V(4).Infof("modelServer is %v, is_lora: %v", modelServerName, isLora)
+	r.mirrorRequest(c, modelRequest, modelRoute, isLora)
+
+	if fallbacks := r.getFallbacks(c, modelName, modelRoute); len(fallbacks) > 0 {
+		r.serveWithFallbacks(c, modelRequest, modelName, modelServerName, isLora, modelRoute, fallbacks)
+		return
+	}
+	r.serveModelServer(c, modelRequest, modelName, modelServerName, isLora, modelRoute)
+}
+
+// serveModelServer serves the request with the pods of modelServerName. When the request can't be served, it responds
+// with the error and returns the trigger of a fallback to another ModelServer, or "" if no fallback can help.
+func (r *Router) serveModelServer(
+	c *gin.Context,
+	modelRequest ModelRequest,
+	modelName string,
+	modelServerName types.NamespacedName,
+	isLora bool,
+	modelRoute *v1alpha1.ModelRoute,
+) v1alpha1.FallbackTrigger {
 	pods, modelServer, err := r.getPodsAndServer(modelServerName)
 	if err != nil || len(pods) == 0 {
 		klog.Errorf("failed to get pods and model server: %v, %v", modelServerName, err)
 		accesslog.SetError(c, "pod_discovery", fmt.Sprintf("can't find model server: %v", modelServerName))
 		c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("can't find model server: %v", modelServerName))
-		return
+		return v1alpha1.FallbackOnNoHealthyPods
+	}
+
+	pods, err = newConcurrencyLimit(modelServer.Spec.TrafficPolicy).waitAvailable(c.Request.Context(), pods)
+	if err != nil {
+		klog.V(4).Infof("no pod of model server %v has capacity: %v", modelServerName, err)
+		accesslog.SetError(c, "concurrency_limit", errPodsSaturated.Error())
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, errPodsSaturated.Error())
+		if errors.Is(err, errPodsSaturated) {
+			return v1alpha1.FallbackOnAllFiltered
+		}
+		return ""
 	}
 
 	model := modelServer.Spec.Model
@@ -287,11 +425,11 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 	if modelServer.Spec.WorkloadSelector != nil {
 		pdGroup = modelServer.Spec.WorkloadSelector.PDGroup
 	}
-	prompt, err := utils.ParsePrompt(modelRequest)
+	prompt, err := getRequestParser(c.Request.URL.Path).ParsePrompt(modelRequest)
 	if err != nil {
 		accesslog.SetError(c, "prompt_parsing", "prompt not found")
 		c.AbortWithStatusJSON(http.StatusNotFound, "prompt not found")
-		return
+		return ""
 	}
 	// Get metrics recorder from gin context
 	var metricsRecorder *metrics.RequestMetricsRecorder
@@ -308,16 +446,18 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 		PDGroup:         pdGroup,
 		MetricsRecorder: metricsRecorder,
 	}
+	setSession(c, modelRequest, modelServer.Spec.TrafficPolicy, ctx)
 
 	err = r.scheduler.Schedule(ctx, pods)
 	if err != nil {
 		accesslog.SetError(c, "scheduling", fmt.Sprintf("can't schedule to target pod: %v", err))
 		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("can't schedule to target pod: %v", err))
-		return
+		return v1alpha1.FallbackOnAllFiltered
 	}
 
 	// Set complete request routing information in access log
 	modelServerFullName := fmt.Sprintf("%s/%s", modelServerName.Namespace, modelServerName.Name)
+	c.Set(common.ModelServerKey, modelServerFullName)
 	modelRouteName := ""
 	if modelRoute != nil {
 		modelRouteName = fmt.Sprintf("%s/%s", modelRoute.Namespace, modelRoute.Name)
@@ -337,18 +477,24 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 	if err := r.proxyModelEndpoint(c, req, ctx, modelRequest, modelServer.Spec.WorkloadPort.Port); err != nil {
 		klog.Errorf("request failed reqID: %s: %v", c.Request.Header.Get("x-request-id"), err)
 		accesslog.SetError(c, "proxy", "request processing failed")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, "request processing failed")
+		// The proxy may have already responded with the upstream failure
+		if !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, "request processing failed")
+		}
+		return fallbackTrigger(err)
 	}
+	return ""
 }
 
+// ParseModelRequest parses the request body with the parser of the request path.
 func ParseModelRequest(c *gin.Context) (ModelRequest, error) {
 	bodyBytes, err := io.ReadAll(c.Request.Body)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, err)
 		return nil, err
 	}
-	var modelRequest ModelRequest
-	if err := json.Unmarshal(bodyBytes, &modelRequest); err != nil {
+	modelRequest, err := getRequestParser(c.Request.URL.Path).ParseBody(c, bodyBytes)
+	if err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, err)
 		return nil, err
 	}
@@ -363,8 +509,46 @@ func ParseModelRequest(c *gin.Context) (ModelRequest, error) {
 	return modelRequest, nil
 }
 
+// rateLimitCallback returns the ModelRoute callback configuring the rate limits and token quotas of the models.
+func rateLimitCallback(loadRateLimiter *ratelimit.TokenRateLimiter, quotas *quota.Manager) datastore.CallbackFunc {
+	return func(data datastore.EventData) {
+		switch data.EventType {
+		case datastore.EventAdd, datastore.EventUpdate:
+			if data.ModelRoute == nil {
+				return
+			}
+			if data.ModelRoute.Spec.RateLimit == nil {
+				// The quota is deleted when the rate limit is removed from the ModelRoute
+				quotas.DeleteQuota(data.ModelName)
+				return
+			}
+			klog.Infof("add or update rate limit for model %s", data.ModelName)
+			if rateLimit := data.ModelRoute.Spec.RateLimit; RouterReplicas > 1 && (rateLimit.Global == nil || rateLimit.Global.Redis == nil) {
+				klog.Warningf("the rate limit of model %s has no global Redis, each of the %d router replicas enforces it separately",
+					data.ModelName, RouterReplicas)
+			}
+
+			// Configure the unified rate limiter for this model
+			if err := loadRateLimiter.AddOrUpdateLimiter(data.ModelName, data.ModelRoute.Spec.RateLimit); err != nil {
+				klog.Errorf("failed to configure rate limiter for model %s: %v", data.ModelName, err)
+			}
+			// The quota is kept in the Redis of the rate limiter with global rate limiting
+			quotas.AddOrUpdateQuota(data.ModelName, data.ModelRoute.Spec.RateLimit, loadRateLimiter.RedisClient(data.ModelName))
+
+		case datastore.EventDelete:
+			klog.Infof("delete rate limit for model %s", data.ModelName)
+			loadRateLimiter.DeleteLimiter(data.ModelName)
+			quotas.DeleteQuota(data.ModelName)
+		}
+	}
+}
+
+// getPodsAndServer returns the pods of a ModelServer which can be scheduled, i.e. the pods which pass its active health check.
 func (r *Router) getPodsAndServer(modelServerName types.NamespacedName) ([]*datastore.PodInfo, *v1alpha1.ModelServer, error) {
 	pods, err := r.store.GetPodsByModelServer(modelServerName)
+	pods = slices.DeleteFunc(pods, func(pod *datastore.PodInfo) bool {
+		return pod.IsUnhealthy()
+	})
 	if err != nil || len(pods) == 0 {
 		return nil, nil, fmt.Errorf("can't find target pods of model server: %v, err: %v", modelServerName, err)
 	}
@@ -381,6 +565,9 @@ func (r *Router) proxy(
 	ctx *framework.Context,
 	stream bool,
 	port int32,
+	policy retryPolicy,
+	limit concurrencyLimit,
+	hedging hedgePolicy,
 	onUsage func(u handlers.OpenAIResponse),
 ) error {
 	modelServerName := fmt.Sprintf("%s/%s", ctx.ModelServerName.Namespace, ctx.ModelServerName.Name)
@@ -393,26 +580,55 @@ func (r *Router) proxy(
 		}
 	}
 
-	for i := 0; i < len(ctx.BestPods); i++ {
-		// Increment upstream request count with both modelServer and modelRoute
-		r.metrics.IncActiveUpstreamRequests(modelServerName, modelRouteName)
-
-		// Request dispatched to the pod.
-		err := proxyRequest(c, req, ctx.BestPods[i].Pod.Status.PodIP, port, stream, onUsage)
+	var err error
+	for attempt := 0; attempt < policy.attempts; attempt++ {
+		if !policy.wait(req.Context(), attempt) {
+			break
+		}
+		// Retries go to the next best pod, wrapping around if there are more attempts than pods.
+		// Pods which have reached the max concurrent requests meanwhile are skipped.
+		i := limit.acquire(ctx.BestPods, attempt%len(ctx.BestPods))
+		if i < 0 {
+			err = errPodsSaturated
+			break
+		}
 
-		// Decrement upstream request count when request completes
-		r.metrics.DecActiveUpstreamRequests(modelServerName, modelRouteName)
+		if stream && hedging.maxHedges > 0 && len(ctx.BestPods) > 1 {
+			i, err = r.proxyHedged(c, req, ctx, i, port, hedging, limit, modelServerName, modelRouteName, onUsage)
+		} else {
+			// Increment upstream request count with both modelServer and modelRoute
+			r.metrics.IncActiveUpstreamRequests(modelServerName, modelRouteName)
+
+			// Request dispatched to the pod.
+			err = proxyRequest(c, req, ctx.BestPods[i].Pod.Status.PodIP, port, stream, policy.streamBufferTimeout(attempt), onUsage)
+
+			// Decrement upstream request count when request completes
+			r.metrics.DecActiveUpstreamRequests(modelServerName, modelRouteName)
+			ctx.BestPods[i].ReleaseRequest()
+			// Attempts canceled by the client say nothing about the health of the pod
+			if !errors.Is(req.Context().Err(), context.Canceled) {
+				r.store.ReportRequestResult(ctx.ModelServerName, ctx.BestPods[i], !isPodFailure(err))
+			}
+		}
 
-		if err != nil {
-			klog.Errorf(" pod request error: %v", err)
-			continue
+		if err == nil {
+			// record in prefix cache
+			r.scheduler.RunPostHooks(ctx, i)
+			return nil
 		}
-		// record in prefix cache
-		r.scheduler.RunPostHooks(ctx, i)
-		return nil
+		klog.Errorf("pod request error: %v", err)
+		if !isRetryable(req.Context(), err) {
+			break
+		}
+	}
+	if errors.Is(err, errPodsSaturated) {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, err.Error())
+		return err
 	}
-	c.AbortWithStatusJSON(http.StatusNotFound, "request to all pods failed")
-	return fmt.Errorf("request to all pods failed")
+	if !abortWithUpstreamError(c, req.Context(), err) {
+		c.AbortWithStatusJSON(http.StatusNotFound, "request to all pods failed")
+	}
+	return attemptsFailed(errAllPodsFailed, err)
 }
 
 func (r *Router) proxyModelEndpoint(
@@ -433,9 +649,52 @@ func (r *Router) proxyModelEndpoint(
 		}
 	}
 
+	var trafficPolicy *v1alpha1.TrafficPolicy
+	var inferenceEngine v1alpha1.InferenceEngine
+	if modelServer := r.store.GetModelServer(ctx.ModelServerName); modelServer != nil {
+		trafficPolicy = modelServer.Spec.TrafficPolicy
+		inferenceEngine = modelServer.Spec.InferenceEngine
+	}
+
+	if isResponsesRequest(req) && r.responsesTranslationEngines.Has(string(inferenceEngine)) {
+		chatReq, chatRequest, finish, err := translateResponsesRequest(c, req, modelRequest, ctx.Model)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, toResponsesError(http.StatusBadRequest, []byte(strconv.Quote(err.Error()))))
+			return fmt.Errorf("failed to translate responses request: %w", err)
+		}
+		defer finish()
+		req, modelRequest = chatReq, chatRequest
+	}
+
+	multipartBody, isMultipart := c.Get(multipartBodyKey)
+	openInferenceBody, isOpenInference := c.Get(openInferenceBodyKey)
+
 	// proxy to pd aggregated pod
 	if ctx.BestPods != nil {
-		decodeRequest := connectors.BuildDecodeRequest(c, req, modelRequest)
+		policy := newRetryPolicy(trafficPolicy, len(ctx.BestPods))
+		req, cancel := policy.withTimeout(req)
+		defer cancel()
+		c.Request = req
+
+		var decodeRequest *http.Request
+		if isMultipart {
+			// multipart requests are forwarded as is, only the model is rewritten
+			var err error
+			decodeRequest, err = buildMultipartRequest(req, multipartBody.([]byte), modelRequest)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("invalid multipart request: %v", err))
+				return fmt.Errorf("failed to build multipart request: %w", err)
+			}
+		} else if isOpenInference {
+			var err error
+			decodeRequest, err = buildOpenInferenceRequest(req, openInferenceBody.([]byte), modelRequest)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+				return fmt.Errorf("failed to build infer request: %w", err)
+			}
+		} else {
+			decodeRequest = connectors.BuildDecodeRequest(c, req, modelRequest)
+		}
 		// build request
 		stream := isStreaming(modelRequest)
 		userID := ""
@@ -443,14 +702,13 @@ func (r *Router) proxyModelEndpoint(
 			userID = v
 		}
 		modelName := ctx.Model
-		err := r.proxy(c, decodeRequest, ctx, stream, port, func(resp handlers.OpenAIResponse) {
+		err := r.proxy(c, decodeRequest, ctx, stream, port, policy, newConcurrencyLimit(trafficPolicy), newHedgePolicy(trafficPolicy), func(resp handlers.OpenAIResponse) {
 			if resp.Usage.TotalTokens <= 0 {
 				return
 			}
 			// Record output tokens for rate limiting
-			if r.loadRateLimiter != nil {
-				r.loadRateLimiter.RecordOutputTokens(modelName, resp.Usage.CompletionTokens)
-			}
+			addOutputTokens(c, resp.Usage.CompletionTokens)
+			c.Set(common.CachedTokensKey, c.GetInt(common.CachedTokensKey)+resp.Usage.CachedTokens)
 			// Update access log with output tokens
 			if accessCtx := accesslog.GetAccessLogContext(c); accessCtx != nil {
 				accessCtx.SetTokenCounts(accessCtx.InputTokens, resp.Usage.CompletionTokens)
@@ -472,6 +730,12 @@ func (r *Router) proxyModelEndpoint(
 		return err
 	}
 
+	// the KV connectors only know how to split OpenAI requests into prefill and decode requests
+	if isMultipart || isOpenInference {
+		c.AbortWithStatusJSON(http.StatusBadRequest, "multipart and Open Inference requests are not supported in PD disaggregated mode")
+		return fmt.Errorf("multipart and Open Inference requests are not supported in PD disaggregated mode")
+	}
+
 	// Get appropriate connector for this model server
 	kvConnector, err := r.getKVConnector(ctx.ModelServerName)
 	if err != nil {
@@ -480,7 +744,13 @@ func (r *Router) proxyModelEndpoint(
 	}
 
 	// PD disaggregated mode - use KV connector
-	return r.proxyToPDDisaggregated(c, req, ctx, kvConnector, modelRequest, port)
+	policy := newRetryPolicy(trafficPolicy, min(len(ctx.DecodePods), len(ctx.PrefillPods)))
+	req, cancel := policy.withTimeout(req)
+	defer cancel()
+	// KV connectors build the upstream requests from the gin context
+	c.Request = req
+
+	return r.proxyToPDDisaggregated(c, req, ctx, kvConnector, modelRequest, port, policy, newConcurrencyLimit(trafficPolicy))
 }
 
 func (r *Router) GetModelServer(modelName string, req *http.Request) (*v1alpha1.ModelServer, error) {
@@ -500,55 +770,121 @@ func (r *Router) GetModelServer(modelName string, req *http.Request) (*v1alpha1.
 }
 
 func (r *Router) Auth() gin.HandlerFunc {
-	return r.authenticator.Authenticate()
+	return r.apiKeys.Authenticate(r.authenticator)
+}
+
+// APIKeys returns the authenticator of the API keys, kept up to date by the API key controller
+func (r *Router) APIKeys() *auth.APIKeyAuthenticator {
+	return r.apiKeys
 }
 
 func (r *Router) AccessLog() gin.HandlerFunc {
 	return accesslog.AccessLogMiddleware(r.accessLogger)
 }
 
+// Quotas returns the manager of the token quotas of models
+func (r *Router) Quotas() *quota.Manager {
+	return r.quotas
+}
+
+// Run exports the usage records of requests and flushes the quota ledger until ctx is done, and once more
+// when it is
+func (r *Router) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.quotaLedger.Run(ctx)
+	}()
+	r.meter.Run(ctx)
+	wg.Wait()
+}
+
 // proxyRequest proxies the request to the model server pods, returns response to downstream.
+// A streaming response is held back for up to bufferTimeout until its first event, the returned error
+// then tells whether the request failed before anything was sent to the client.
 func proxyRequest(
 	c *gin.Context,
 	req *http.Request,
 	podIP string,
 	port int32,
 	stream bool,
+	bufferTimeout time.Duration,
 	onUsage func(u handlers.OpenAIResponse),
 ) error {
+	resp, err := openUpstream(req, podIP, port, stream, bufferTimeout)
+	if err != nil {
+		return err
+	}
+	defer resp.close()
+
+	forwardResponse(c, resp, onUsage)
+	return nil
+}
+
+// upstreamResponse is the response of a pod which has not been sent to the client yet.
+type upstreamResponse struct {
+	*http.Response
+	// stream reads the lines of a streaming response, it is nil for other responses.
+	stream *streamReader
+}
+
+func (r *upstreamResponse) close() {
+	if r.stream != nil {
+		r.stream.close()
+	}
+	r.Body.Close()
+}
+
+// openUpstream sends the request to the pod. A streaming response is held back until its first event for up to
+// bufferTimeout, zero disables the buffering while a negative timeout waits for the first event without a deadline.
+func openUpstream(req *http.Request, podIP string, port int32, stream bool, bufferTimeout time.Duration) (*upstreamResponse, error) {
 	resp, err := doRequest(req, podIP, port)
 	if err != nil {
-		return fmt.Errorf("decode request error: %w", err)
+		return nil, fmt.Errorf("decode request error: %w", err)
+	}
+
+	upstream := &upstreamResponse{Response: resp}
+	if stream {
+		upstream.stream = newStreamReader(resp.Body)
+		if bufferTimeout != 0 {
+			if err := upstream.stream.bufferFirstEvent(bufferTimeout); err != nil {
+				upstream.close()
+				return nil, fmt.Errorf("decode response error: %w", err)
+			}
+		}
 	}
+	return upstream, nil
+}
+
+// forwardResponse sends the response of a pod to the client.
+func forwardResponse(c *gin.Context, resp *upstreamResponse, onUsage func(u handlers.OpenAIResponse)) {
+	// Nothing has been sent to the client until now, the response is committed from here on
 	for k, vv := range resp.Header {
 		for _, v := range vv {
 			c.Header(k, v)
 		}
 	}
-	defer resp.Body.Close()
-
 	c.Status(resp.StatusCode)
 
-	if stream {
+	if resp.stream != nil {
 		// If the request is a streaming request, we need to stream the response body.
 		// Stream response: read and forward each event (line) one by one, and parse usage if present
-		c.Status(resp.StatusCode)
-		reader := bufio.NewReader(resp.Body)
 		c.Stream(func(w io.Writer) bool {
-			line, err := reader.ReadBytes('\n')
+			line, err := resp.stream.next()
 			if len(line) > 0 {
 				// Try to parse usage from this line, assuming it's a data line
 				parsed := handlers.ParseStreamRespForUsage(string(line))
 				if parsed.Usage.CompletionTokens > 0 {
 					klog.V(4).Infof("Parsed usage: %+v", parsed.Usage)
+					if onUsage != nil {
+						onUsage(parsed)
+					}
 
 					// The token usage is set by router, so remove it before sending to downstream
 					if v, ok := c.Get(common.TokenUsageKey); ok && v.(bool) {
 						return true
 					}
-					if onUsage != nil {
-						onUsage(parsed)
-					}
 				}
 				// Forward to downstream
 				_, _ = w.Write(line)
@@ -569,7 +905,7 @@ func proxyRequest(
 		_, err := io.Copy(c.Writer, ttee)
 		if err != nil {
 			klog.Errorf("copy response to downstream failed: %v", err)
-			return nil
+			return
 		}
 
 		// Parse usage if present
@@ -581,8 +917,6 @@ func proxyRequest(
 			}
 		}
 	}
-
-	return nil
 }
 
 func doRequest(
@@ -593,14 +927,20 @@ func doRequest(
 	// step 1: change request URL to prefill pod URL.
 	req.URL.Host = fmt.Sprintf("%s:%d", podIP, port)
 
-	// step 2: use http.Transport to do request to prefill pod.
+	// step 2: rewind the body, the request may have been sent to another pod before.
+	if err := connectors.ResetRequestBody(req); err != nil {
+		return nil, err
+	}
+
+	// step 3: use http.Transport to do request to prefill pod.
 	transport := http.DefaultTransport
 	resp, err := transport.RoundTrip(req)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("http resp error, http code is %d", resp.StatusCode)
+		defer resp.Body.Close()
+		return nil, common.NewUpstreamStatusError("http request", resp)
 	}
 	return resp, nil
 }
@@ -644,6 +984,8 @@ func (r *Router) proxyToPDDisaggregated(
 	kvConnector connectors.KVConnector,
 	modelRequest ModelRequest,
 	port int32,
+	policy retryPolicy,
+	limit concurrencyLimit,
 ) error {
 	// Get metrics recorder from context
 	var metricsRecorder *metrics.RequestMetricsRecorder
@@ -669,15 +1011,24 @@ func (r *Router) proxyToPDDisaggregated(
 	}
 
 	// Try multiple prefill/decode pairs
-	maxRetry := len(ctx.DecodePods)
-	if len(ctx.PrefillPods) < maxRetry {
-		maxRetry = len(ctx.PrefillPods)
-	}
+	pairs := min(len(ctx.DecodePods), len(ctx.PrefillPods))
 
-	for i := 0; i < maxRetry; i++ {
+	var err error
+	for attempt := 0; attempt < policy.attempts; attempt++ {
+		// Retries go to the next prefill/decode pair, wrapping around if there are more attempts than pairs.
+		i := attempt % pairs
 		if ctx.PrefillPods[i] == nil || ctx.DecodePods[i] == nil {
 			continue
 		}
+		if !policy.wait(req.Context(), attempt) {
+			break
+		}
+		// Pairs which have reached the max concurrent requests meanwhile are skipped.
+		i = limit.acquirePair(ctx.PrefillPods, ctx.DecodePods, i)
+		if i < 0 {
+			err = errPodsSaturated
+			break
+		}
 
 		// Build addresses for prefill and decode pods
 		prefillAddr := fmt.Sprintf("%s:%d", ctx.PrefillPods[i].Pod.Status.PodIP, port)
@@ -686,17 +1037,27 @@ func (r *Router) proxyToPDDisaggregated(
 		klog.V(4).Infof("Attempting PD disaggregated request: prefill=%s, decode=%s", prefillAddr, decodeAddr)
 
 		// Execute the PD disaggregated proxy operation
-		outputTokens, err := kvConnector.Proxy(c, modelRequest, prefillAddr, decodeAddr)
+		var outputTokens int
+		outputTokens, err = kvConnector.Proxy(c, modelRequest, prefillAddr, decodeAddr)
+		ctx.PrefillPods[i].ReleaseRequest()
+		ctx.DecodePods[i].ReleaseRequest()
+		// Attempts canceled by the client say nothing about the health of the pods
+		if !errors.Is(req.Context().Err(), context.Canceled) {
+			r.reportPDRequestResult(ctx, i, err)
+		}
 
 		if err != nil {
 			klog.Errorf("proxy failed for prefill pod %s, decode pod %s: %v",
 				ctx.PrefillPods[i].Pod.Name, ctx.DecodePods[i].Pod.Name, err)
+			if !isRetryable(req.Context(), err) {
+				break
+			}
 			continue
 		}
 
 		// Record output tokens for rate limiting
-		if outputTokens > 0 && r.loadRateLimiter != nil {
-			r.loadRateLimiter.RecordOutputTokens(ctx.Model, outputTokens)
+		if outputTokens > 0 {
+			addOutputTokens(c, outputTokens)
 		}
 
 		// Record output token metrics
@@ -713,8 +1074,26 @@ func (r *Router) proxyToPDDisaggregated(
 		return nil
 	}
 
-	c.AbortWithStatusJSON(http.StatusInternalServerError, "all prefill/decode attempts failed")
-	return fmt.Errorf("all prefill/decode attempts failed")
+	if errors.Is(err, errPodsSaturated) {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, err.Error())
+		return err
+	}
+	if !abortWithUpstreamError(c, req.Context(), err) {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, "all prefill/decode attempts failed")
+	}
+	return attemptsFailed(errAllPDPairsFailed, err)
+}
+
+// reportPDRequestResult records the result of a request to the i-th prefill/decode pair for the outlier detection.
+// The decode pod is not involved when the prefill request fails.
+func (r *Router) reportPDRequestResult(ctx *framework.Context, i int, err error) {
+	var prefillErr *connectors.PrefillError
+	if errors.As(err, &prefillErr) {
+		r.store.ReportRequestResult(ctx.ModelServerName, ctx.PrefillPods[i], !isPodFailure(err))
+		return
+	}
+	r.store.ReportRequestResult(ctx.ModelServerName, ctx.PrefillPods[i], true)
+	r.store.ReportRequestResult(ctx.ModelServerName, ctx.DecodePods[i], !isPodFailure(err))
 }
 
 // handleFairnessScheduling handles the fairness scheduling flow for requests
@@ -18,6 +18,7 @@ package router
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -28,7 +29,9 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/gin-gonic/gin"
@@ -144,7 +147,7 @@ func TestProxyModelEndpoint(t *testing.T) {
 				})
 				return patches
 			},
-			wantErr: errors.New("request to all pods failed"),
+			wantErr: errors.New("request to all pods failed: proxy error"),
 		},
 	}
 	for _, tt := range tests {
@@ -176,6 +179,66 @@ func setupTestRouter(backendHandler http.Handler) (*Router, datastore.Store, *ht
 	return router, store, backend
 }
 
+// serveAggregatedRequest sends req through the router to a single aggregated pod backed by handler.
+// The request model "test-model" is served by the ModelServer model "test-model-base".
+func serveAggregatedRequest(t *testing.T, handler http.Handler, trafficPolicy *aiv1alpha1.TrafficPolicy, req *http.Request) *httptest.ResponseRecorder {
+	return serveRequest(setupAggregatedRouter(t, handler, trafficPolicy), req)
+}
+
+// setupAggregatedRouter returns a router with the ModelRoute test-model targeting a vLLM ModelServer served by handler.
+func setupAggregatedRouter(t *testing.T, handler http.Handler, trafficPolicy *aiv1alpha1.TrafficPolicy) *Router {
+	router, store, backend := setupTestRouter(handler)
+	t.Cleanup(backend.Close)
+
+	backendURL, _ := url.Parse(backend.URL)
+	backendPort, _ := strconv.Atoi(backendURL.Port())
+
+	modelServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			Model:           func(s string) *string { return &s }("test-model-base"),
+			WorkloadPort:    aiv1alpha1.WorkloadPort{Port: int32(backendPort)},
+			InferenceEngine: "vLLM",
+			TrafficPolicy:   trafficPolicy,
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod-1", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: backendURL.Hostname(), Phase: corev1.PodRunning},
+	}
+	modelRoute := &aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}}},
+			},
+		},
+	}
+	store.AddOrUpdateModelServer(modelServer, sets.New(types.NamespacedName{Name: "pod-1", Namespace: "default"}))
+	store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{modelServer})
+	store.AddOrUpdateModelRoute(modelRoute)
+	return router
+}
+
+func serveRequest(router *Router, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(&closeNotifyRecorder{w})
+	c.Request = req
+
+	router.HandlerFunc()(c)
+	return w
+}
+
+// closeNotifyRecorder is a ResponseRecorder which can be used to stream responses with gin.
+type closeNotifyRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r *closeNotifyRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
 func TestRouter_HandlerFunc_AggregatedMode(t *testing.T) {
 	// 1. Setup backend mock
 	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -242,6 +305,40 @@ func TestRouter_HandlerFunc_AggregatedMode(t *testing.T) {
 	assert.Contains(t, w.Body.String(), `"id":"response-id"`)
 }
 
+func TestRouter_HandlerFunc_UnhealthyPod(t *testing.T) {
+	var requests atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	router := setupAggregatedRouter(t, handler, nil)
+	msName := types.NamespacedName{Namespace: "default", Name: "ms-1"}
+	podName := types.NamespacedName{Namespace: "default", Name: "pod-1"}
+	modelServer := router.store.GetModelServer(msName).DeepCopy()
+	unhealthyThreshold := int32(1)
+	modelServer.Spec.HealthCheck = &aiv1alpha1.HealthCheck{UnhealthyThreshold: &unhealthyThreshold}
+	router.store.AddOrUpdateModelServer(modelServer, sets.New(podName))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	router.store.Run(ctx)
+	assert.Eventually(t, func() bool {
+		return router.store.GetPodInfo(podName).IsUnhealthy()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The unhealthy pod is still a pod of the model server, but it is not scheduled
+	pods, err := router.store.GetPodsByModelServer(msName)
+	assert.NoError(t, err)
+	assert.Len(t, pods, 1)
+	probes := requests.Load()
+	req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serveRequest(router, req)
+	assert.NotEqual(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "can't find model server")
+	assert.Equal(t, probes, requests.Load())
+}
+
 func TestRouter_HandlerFunc_DisaggregatedMode(t *testing.T) {
 	// 1. Setup backend mock
 	prefillReqs := 0
@@ -347,6 +444,101 @@ func TestRouter_HandlerFunc_DisaggregatedMode(t *testing.T) {
 	assert.Contains(t, w.Body.String(), `data: {"id":"decode-resp"}`)
 }
 
+func TestRouter_HandlerFunc_DisaggregatedModeOutlierDetection(t *testing.T) {
+	var prefillReqs, decodeReqs atomic.Int32
+	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var reqBody ModelRequest
+		json.Unmarshal(body, &reqBody)
+
+		if _, hasStream := reqBody["stream"]; !hasStream {
+			prefillReqs.Add(1)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{"id":"prefill-resp"}`)
+		} else {
+			decodeReqs.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	router, store, backend := setupTestRouter(backendHandler)
+	defer backend.Close()
+
+	backendURL, _ := url.Parse(backend.URL)
+	backendIP := backendURL.Hostname()
+	backendPort, _ := strconv.Atoi(backendURL.Port())
+
+	consecutiveFailures, maxEjectionPercent := int32(2), int32(100)
+	modelServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			Model:           func(s string) *string { return &s }("test-model-base"),
+			WorkloadPort:    aiv1alpha1.WorkloadPort{Port: int32(backendPort)},
+			InferenceEngine: "vLLM",
+			WorkloadSelector: &aiv1alpha1.WorkloadSelector{
+				PDGroup: &aiv1alpha1.PDGroup{
+					GroupKey:      "group",
+					DecodeLabels:  map[string]string{"app": "decode"},
+					PrefillLabels: map[string]string{"app": "prefill"},
+				},
+			},
+			TrafficPolicy: &aiv1alpha1.TrafficPolicy{
+				OutlierDetection: &aiv1alpha1.OutlierDetection{
+					ConsecutiveFailures: &consecutiveFailures,
+					MaxEjectionPercent:  &maxEjectionPercent,
+				},
+			},
+		},
+	}
+	decodePod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "decode-pod-1",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "decode", "group": "test-group"},
+		},
+		Status: corev1.PodStatus{PodIP: backendIP, Phase: corev1.PodRunning},
+	}
+	prefillPod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "prefill-pod-1",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "prefill", "group": "test-group"},
+		},
+		Status: corev1.PodStatus{PodIP: backendIP, Phase: corev1.PodRunning},
+	}
+	modelRoute := &aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}}},
+			},
+		},
+	}
+	decodeName := types.NamespacedName{Name: "decode-pod-1", Namespace: "default"}
+	prefillName := types.NamespacedName{Name: "prefill-pod-1", Namespace: "default"}
+	store.AddOrUpdateModelServer(modelServer, sets.New(decodeName, prefillName))
+	store.AddOrUpdatePod(decodePod, []*aiv1alpha1.ModelServer{modelServer})
+	store.AddOrUpdatePod(prefillPod, []*aiv1alpha1.ModelServer{modelServer})
+	store.AddOrUpdateModelRoute(modelRoute)
+
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello", "stream": true}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	for i := 0; i < 3; i++ {
+		serveRequest(router, newRequest())
+	}
+	// The failures of the decode pod are reported, not the ones of the prefill pod. The decode pod is the only one,
+	// the healthy prefill pod can't serve its requests, so it is not ejected.
+	assert.Equal(t, 3, store.GetPodInfo(decodeName).GetHealthStatus().ConsecutiveFailures)
+	assert.Zero(t, store.GetPodInfo(prefillName).GetHealthStatus().ConsecutiveFailures)
+	assert.False(t, store.GetPodInfo(decodeName).IsEjected())
+	assert.Equal(t, int32(3), prefillReqs.Load())
+	assert.Equal(t, int32(3), decodeReqs.Load())
+}
+
 func TestRouter_HandlerFunc_ModelNotFound(t *testing.T) {
 	router, _, backend := setupTestRouter(nil)
 	defer backend.Close()
@@ -573,3 +765,18 @@ func parseBool(str string) (bool, error) {
 	}
 	return false, &strconv.NumError{Func: "ParseBool", Num: str, Err: strconv.ErrSyntax}
 }
+
+func TestNewMediaTokensPerItem(t *testing.T) {
+	tokensPerItem := newMediaTokensPerItem(conf.MultimodalConfig{
+		TokensPerItem: map[string]int{
+			common.ContentPartImageURL: 1024,
+			"image_pil":                256,
+		},
+	})
+
+	assert.Equal(t, 1024, tokensPerItem[common.ContentPartImageURL])
+	assert.Equal(t, 256, tokensPerItem["image_pil"])
+	assert.Equal(t, defaultMediaTokensPerItem[common.ContentPartInputAudio], tokensPerItem[common.ContentPartInputAudio])
+	// the defaults are not modified
+	assert.Equal(t, 576, defaultMediaTokensPerItem[common.ContentPartImageURL])
+}
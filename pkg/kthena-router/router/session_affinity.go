@@ -0,0 +1,65 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/filters/auth"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+// defaultSessionMaxLoadPercent matches the default of SessionAffinity.MaxLoadPercent in the ModelServer CRD.
+const defaultSessionMaxLoadPercent = 125
+
+// setSession sets the session of the request in the scheduling context, given the session affinity of the
+// TrafficPolicy of its ModelServer. Requests without a session ID are scheduled without affinity.
+func setSession(c *gin.Context, modelRequest ModelRequest, trafficPolicy *v1alpha1.TrafficPolicy, ctx *framework.Context) {
+	if trafficPolicy == nil || trafficPolicy.SessionAffinity == nil {
+		return
+	}
+	affinity := trafficPolicy.SessionAffinity
+	ctx.SessionKey = sessionKey(c, modelRequest, affinity)
+	ctx.SessionMaxLoadPercent = defaultSessionMaxLoadPercent
+	if affinity.MaxLoadPercent != nil {
+		ctx.SessionMaxLoadPercent = int(*affinity.MaxLoadPercent)
+	}
+}
+
+// sessionKey returns the session ID of the request read from the source of affinity, or "" if it has none.
+func sessionKey(c *gin.Context, modelRequest ModelRequest, affinity *v1alpha1.SessionAffinity) string {
+	switch {
+	case affinity.Header != "":
+		return c.Request.Header.Get(affinity.Header)
+	case affinity.JWTClaim != "":
+		claim, _ := auth.GetClaim(c, affinity.JWTClaim)
+		return claim
+	case affinity.BodyField != "":
+		switch value := modelRequest[affinity.BodyField].(type) {
+		case nil:
+			return ""
+		case string:
+			return value
+		case float64, bool:
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
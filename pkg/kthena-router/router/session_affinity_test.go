@@ -0,0 +1,106 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+func TestSessionKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		affinity     *aiv1alpha1.SessionAffinity
+		header       string
+		modelRequest ModelRequest
+		expected     string
+	}{
+		{
+			name:     "header",
+			affinity: &aiv1alpha1.SessionAffinity{Header: "x-session-id"},
+			header:   "session-1",
+			expected: "session-1",
+		},
+		{
+			name:     "missing header",
+			affinity: &aiv1alpha1.SessionAffinity{Header: "x-session-id"},
+			expected: "",
+		},
+		{
+			name:         "body field",
+			affinity:     &aiv1alpha1.SessionAffinity{BodyField: "user"},
+			modelRequest: ModelRequest{"model": "test-model", "user": "user-1"},
+			expected:     "user-1",
+		},
+		{
+			name:         "numeric body field",
+			affinity:     &aiv1alpha1.SessionAffinity{BodyField: "conversation"},
+			modelRequest: ModelRequest{"conversation": float64(42)},
+			expected:     "42",
+		},
+		{
+			name:         "object body field",
+			affinity:     &aiv1alpha1.SessionAffinity{BodyField: "metadata"},
+			modelRequest: ModelRequest{"metadata": map[string]interface{}{"id": "1"}},
+			expected:     "",
+		},
+		{
+			name:     "unauthenticated JWT claim",
+			affinity: &aiv1alpha1.SessionAffinity{JWTClaim: "sub"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("x-session-id", tt.header)
+			}
+			assert.Equal(t, tt.expected, sessionKey(c, tt.modelRequest, tt.affinity))
+		})
+	}
+}
+
+func TestSetSession(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	c.Request.Header.Set("x-session-id", "session-1")
+
+	ctx := &framework.Context{}
+	setSession(c, ModelRequest{}, nil, ctx)
+	assert.Empty(t, ctx.SessionKey)
+
+	setSession(c, ModelRequest{}, &aiv1alpha1.TrafficPolicy{
+		SessionAffinity: &aiv1alpha1.SessionAffinity{Header: "x-session-id"},
+	}, ctx)
+	assert.Equal(t, "session-1", ctx.SessionKey)
+	assert.Equal(t, defaultSessionMaxLoadPercent, ctx.SessionMaxLoadPercent)
+
+	maxLoadPercent := int32(150)
+	setSession(c, ModelRequest{}, &aiv1alpha1.TrafficPolicy{
+		SessionAffinity: &aiv1alpha1.SessionAffinity{Header: "x-session-id", MaxLoadPercent: &maxLoadPercent},
+	}, ctx)
+	assert.Equal(t, 150, ctx.SessionMaxLoadPercent)
+}
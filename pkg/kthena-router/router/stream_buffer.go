@@ -0,0 +1,147 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	// errStreamEndedBeforeFirstEvent is returned when the upstream closes a stream without sending any event.
+	errStreamEndedBeforeFirstEvent = errors.New("stream ended before its first event")
+	// errStreamErrorEvent is returned when the first event of a stream is an error.
+	errStreamErrorEvent = errors.New("stream failed with an error event")
+)
+
+var sseDataPrefix = []byte("data:")
+
+// streamReader reads the lines of a streaming response. It holds the response back until its first data event,
+// so that the request can be retried on another pod if the upstream fails before producing any output.
+type streamReader struct {
+	reader *bufio.Reader
+	// buffered are the lines read before the response was committed, which are yet to be forwarded.
+	buffered [][]byte
+	// pending receives the lines read by the goroutine waiting for the first event, after the buffer timed out.
+	// It is closed once the first event is read or the stream fails, then pendingErr holds the error if any.
+	pending    chan []byte
+	pendingErr error
+	stop       chan struct{}
+}
+
+// newStreamReader returns a reader of the lines of body.
+func newStreamReader(body io.Reader) *streamReader {
+	return &streamReader{reader: bufio.NewReader(body)}
+}
+
+// bufferFirstEvent reads the stream until its first data event or until timeout expires, whichever comes first.
+// A non-positive timeout never expires.
+// It returns an error if the stream fails before, in which case nothing has been sent to the client and the request
+// can be retried. On timeout, the first event keeps being waited for in the background and the lines are forwarded
+// as they come. The caller must call close once it is done with the stream.
+func (s *streamReader) bufferFirstEvent(timeout time.Duration) error {
+	s.pending = make(chan []byte, 16)
+	s.stop = make(chan struct{})
+	go func() {
+		defer close(s.pending)
+		for {
+			line, err := s.reader.ReadBytes('\n')
+			if len(line) > 0 {
+				select {
+				case s.pending <- line:
+				case <-s.stop:
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = errStreamEndedBeforeFirstEvent
+				}
+				s.pendingErr = err
+				return
+			}
+			if bytes.HasPrefix(line, sseDataPrefix) {
+				if isErrorEvent(line) {
+					s.pendingErr = errStreamErrorEvent
+				}
+				return
+			}
+		}
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		select {
+		case line, ok := <-s.pending:
+			if !ok {
+				s.pending = nil
+				return s.pendingErr
+			}
+			s.buffered = append(s.buffered, line)
+		case <-expired:
+			return nil
+		}
+	}
+}
+
+// next returns the next line of the stream, starting with the lines held back by bufferFirstEvent.
+func (s *streamReader) next() ([]byte, error) {
+	if len(s.buffered) > 0 {
+		line := s.buffered[0]
+		s.buffered = s.buffered[1:]
+		return line, nil
+	}
+	if s.pending != nil {
+		if line, ok := <-s.pending; ok {
+			return line, nil
+		}
+		s.pending = nil
+		if s.pendingErr != nil {
+			return nil, s.pendingErr
+		}
+	}
+	return s.reader.ReadBytes('\n')
+}
+
+// close stops the goroutine of bufferFirstEvent, if it is still running.
+func (s *streamReader) close() {
+	if s.stop != nil {
+		close(s.stop)
+	}
+}
+
+// isErrorEvent reports whether an SSE data line carries an error instead of output, like the error events of vLLM.
+func isErrorEvent(line []byte) bool {
+	var event struct {
+		Object string          `json:"object"`
+		Error  json.RawMessage `json:"error"`
+	}
+	data := bytes.TrimSpace(bytes.TrimPrefix(line, sseDataPrefix))
+	if err := json.Unmarshal(data, &event); err != nil {
+		return false
+	}
+	return event.Object == "error" || (len(event.Error) > 0 && !bytes.Equal(event.Error, []byte("null")))
+}
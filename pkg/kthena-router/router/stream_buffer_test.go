@@ -0,0 +1,101 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsErrorEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want bool
+	}{
+		{name: "completion chunk", line: `data: {"id":"1","object":"text_completion","choices":[{"text":"Hi"}]}`, want: false},
+		{name: "done", line: "data: [DONE]\n", want: false},
+		{name: "vLLM error object", line: `data: {"object":"error","message":"engine is dead","code":500}`, want: true},
+		{name: "OpenAI error", line: `data: {"error":{"message":"overloaded"}}` + "\n", want: true},
+		{name: "null error", line: `data: {"id":"1","error":null}`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isErrorEvent([]byte(tt.line)))
+		})
+	}
+}
+
+func readAll(t *testing.T, reader *streamReader) string {
+	var sb strings.Builder
+	for {
+		line, err := reader.next()
+		sb.Write(line)
+		if err != nil {
+			assert.ErrorIs(t, err, io.EOF)
+			return sb.String()
+		}
+	}
+}
+
+func TestStreamReaderBufferFirstEvent(t *testing.T) {
+	// The lines before the first data event are held back with it
+	reader := newStreamReader(strings.NewReader("event: message\ndata: {\"id\":\"1\"}\n\ndata: [DONE]\n\n"))
+	assert.NoError(t, reader.bufferFirstEvent(time.Second))
+	assert.Len(t, reader.buffered, 2)
+	assert.Equal(t, "event: message\ndata: {\"id\":\"1\"}\n\ndata: [DONE]\n\n", readAll(t, reader))
+	reader.close()
+
+	// A stream ending before its first event can be retried
+	reader = newStreamReader(strings.NewReader(": keep-alive\n\n"))
+	assert.ErrorIs(t, reader.bufferFirstEvent(time.Second), errStreamEndedBeforeFirstEvent)
+	reader.close()
+
+	reader = newStreamReader(strings.NewReader("data: {\"object\":\"error\"}\n\n"))
+	assert.ErrorIs(t, reader.bufferFirstEvent(time.Second), errStreamErrorEvent)
+	reader.close()
+
+	// On timeout, the lines keep being forwarded as they come
+	pr, pw := io.Pipe()
+	reader = newStreamReader(pr)
+	defer reader.close()
+	go func() {
+		_, _ = pw.Write([]byte(": keep-alive\n\n"))
+		time.Sleep(50 * time.Millisecond)
+		_, _ = pw.Write([]byte("data: {\"id\":\"1\"}\n\ndata: [DONE]\n\n"))
+		pw.Close()
+	}()
+	assert.NoError(t, reader.bufferFirstEvent(10*time.Millisecond))
+	assert.Equal(t, ": keep-alive\n\ndata: {\"id\":\"1\"}\n\ndata: [DONE]\n\n", readAll(t, reader))
+
+	// Failures after the response is committed end the stream
+	pr, pw = io.Pipe()
+	reader = newStreamReader(pr)
+	defer reader.close()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		pw.CloseWithError(errors.New("connection reset"))
+	}()
+	assert.NoError(t, reader.bufferFirstEvent(10*time.Millisecond))
+	_, err := reader.next()
+	assert.EqualError(t, err, "connection reset")
+}
@@ -0,0 +1,284 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"istio.io/istio/pkg/slices"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+const (
+	// defaultRetryInterval matches the default of Retry.RetryInterval in the ModelServer CRD.
+	defaultRetryInterval = 100 * time.Millisecond
+	// defaultFirstEventTimeout matches the default of Retry.FirstEventTimeout in the ModelServer CRD.
+	defaultFirstEventTimeout = 10 * time.Second
+	// defaultQueueTimeout matches the default of ConcurrencyLimit.QueueTimeout in the ModelServer CRD.
+	defaultQueueTimeout = 10 * time.Second
+	// capacityPollInterval is how often a queued request checks whether a pod has capacity again.
+	capacityPollInterval = 10 * time.Millisecond
+)
+
+// errPodsSaturated is returned when all the candidate pods have reached the max concurrent requests.
+var errPodsSaturated = errors.New("all pods have reached the max concurrent requests")
+
+// retryPolicy is the upstream timeout and retry behavior derived from a ModelServer TrafficPolicy.
+type retryPolicy struct {
+	// timeout bounds all upstream attempts of a request, zero means no timeout.
+	timeout time.Duration
+	// attempts is the total number of upstream attempts, including the first one.
+	attempts int
+	// interval is the time to wait between two attempts.
+	interval time.Duration
+	// firstEventTimeout is how long a streaming response is buffered waiting for its first event before it is sent
+	// to the client, so that the request can be retried if the upstream fails before. Zero disables the buffering.
+	firstEventTimeout time.Duration
+}
+
+// newRetryPolicy builds the retry policy of a request. candidates is the number of pods (or prefill/decode pairs)
+// returned by the scheduler. Without a retry policy, every candidate is tried once without waiting.
+func newRetryPolicy(trafficPolicy *v1alpha1.TrafficPolicy, candidates int) retryPolicy {
+	policy := retryPolicy{attempts: candidates}
+	if trafficPolicy == nil || candidates == 0 {
+		return policy
+	}
+	if trafficPolicy.Timeout != nil && trafficPolicy.Timeout.Duration > 0 {
+		policy.timeout = trafficPolicy.Timeout.Duration
+	}
+	if retry := trafficPolicy.Retry; retry != nil {
+		policy.attempts = 1 + int(max(retry.Attempts, 0))
+		policy.interval = defaultRetryInterval
+		if retry.RetryInterval != nil {
+			policy.interval = max(retry.RetryInterval.Duration, 0)
+		}
+		policy.firstEventTimeout = defaultFirstEventTimeout
+		if retry.FirstEventTimeout != nil {
+			policy.firstEventTimeout = max(retry.FirstEventTimeout.Duration, 0)
+		}
+	}
+	return policy
+}
+
+// withTimeout returns a copy of req whose context expires after the upstream timeout.
+func (p retryPolicy) withTimeout(req *http.Request) (*http.Request, context.CancelFunc) {
+	if p.timeout <= 0 {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), p.timeout)
+	return req.WithContext(ctx), cancel
+}
+
+// streamBufferTimeout returns how long the streaming response of attempt is buffered for. The response of the last
+// attempt is not buffered, since there is nothing left to retry.
+func (p retryPolicy) streamBufferTimeout(attempt int) time.Duration {
+	if attempt >= p.attempts-1 {
+		return 0
+	}
+	return p.firstEventTimeout
+}
+
+// wait blocks for the retry interval before attempt. It returns false if ctx is done in the meantime.
+func (p retryPolicy) wait(ctx context.Context, attempt int) bool {
+	if attempt == 0 || p.interval <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(p.interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// concurrencyLimit is the per pod concurrency limit derived from a ModelServer TrafficPolicy.
+type concurrencyLimit struct {
+	// maxRequests is the max number of requests in flight to a pod, zero means no limit.
+	maxRequests int64
+	// queueTimeout is how long a request waits for a pod with capacity, zero means it is rejected right away.
+	queueTimeout time.Duration
+}
+
+func newConcurrencyLimit(trafficPolicy *v1alpha1.TrafficPolicy) concurrencyLimit {
+	var limit concurrencyLimit
+	if trafficPolicy == nil || trafficPolicy.ConcurrencyLimit == nil || trafficPolicy.ConcurrencyLimit.MaxRequestsPerPod <= 0 {
+		return limit
+	}
+	limit.maxRequests = int64(trafficPolicy.ConcurrencyLimit.MaxRequestsPerPod)
+	if trafficPolicy.ConcurrencyLimit.Overflow == v1alpha1.OverflowQueue {
+		limit.queueTimeout = defaultQueueTimeout
+		if queueTimeout := trafficPolicy.ConcurrencyLimit.QueueTimeout; queueTimeout != nil {
+			limit.queueTimeout = max(queueTimeout.Duration, 0)
+		}
+	}
+	return limit
+}
+
+// hedgePolicy is the request hedging derived from a ModelServer TrafficPolicy.
+type hedgePolicy struct {
+	// delay is how long the first event of a pod is waited for before the request is hedged to the next pod.
+	delay time.Duration
+	// maxHedges is the max number of pods a request is hedged to, zero means requests are not hedged.
+	maxHedges int
+}
+
+func newHedgePolicy(trafficPolicy *v1alpha1.TrafficPolicy) hedgePolicy {
+	var hedging hedgePolicy
+	if trafficPolicy == nil || trafficPolicy.Hedging == nil {
+		return hedging
+	}
+	hedging.delay = max(trafficPolicy.Hedging.Delay.Duration, 0)
+	hedging.maxHedges = 1
+	if maxHedges := trafficPolicy.Hedging.MaxHedges; maxHedges != nil {
+		hedging.maxHedges = int(max(*maxHedges, 0))
+	}
+	return hedging
+}
+
+// available returns the pods which have capacity for another request.
+func (l concurrencyLimit) available(pods []*datastore.PodInfo) []*datastore.PodInfo {
+	if l.maxRequests <= 0 {
+		return pods
+	}
+	return slices.Filter(pods, func(pod *datastore.PodInfo) bool {
+		return pod.GetInFlightRequests() < l.maxRequests
+	})
+}
+
+// waitAvailable returns the pods which have capacity for another request. If all the pods are saturated,
+// it waits for one of them to complete a request, up to the queue timeout. It returns errPodsSaturated on timeout.
+func (l concurrencyLimit) waitAvailable(ctx context.Context, pods []*datastore.PodInfo) ([]*datastore.PodInfo, error) {
+	if available := l.available(pods); len(available) > 0 {
+		return available, nil
+	}
+	if l.queueTimeout <= 0 {
+		return nil, errPodsSaturated
+	}
+
+	timeout := time.NewTimer(l.queueTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(capacityPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout.C:
+			return nil, errPodsSaturated
+		case <-ticker.C:
+			if available := l.available(pods); len(available) > 0 {
+				return available, nil
+			}
+		}
+	}
+}
+
+// acquire counts a request in flight to the first pod with capacity, trying the pods from start and wrapping around.
+// It returns the index of the pod, or -1 if all the pods are saturated.
+func (l concurrencyLimit) acquire(pods []*datastore.PodInfo, start int) int {
+	for n := 0; n < len(pods); n++ {
+		i := (start + n) % len(pods)
+		if pods[i].AcquireRequest(l.maxRequests) {
+			return i
+		}
+	}
+	return -1
+}
+
+// acquirePair is like acquire for prefill/decode pairs, a request is counted in flight to both pods of the pair.
+// Pairs without a prefill pod are skipped.
+func (l concurrencyLimit) acquirePair(prefillPods, decodePods []*datastore.PodInfo, start int) int {
+	pairs := min(len(prefillPods), len(decodePods))
+	for n := 0; n < pairs; n++ {
+		i := (start + n) % pairs
+		if prefillPods[i] == nil || decodePods[i] == nil {
+			continue
+		}
+		if !decodePods[i].AcquireRequest(l.maxRequests) {
+			continue
+		}
+		if !prefillPods[i].AcquireRequest(l.maxRequests) {
+			decodePods[i].ReleaseRequest()
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// isRetryable reports whether a failed upstream attempt can be retried on another pod.
+// Connection failures and 5xx responses are retried, while 4xx responses are returned to the client
+// since another pod would answer the same. Nothing is retried once the request context is done.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *common.UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// isPodFailure reports whether a failed upstream attempt counts as a failure of the pod for the outlier detection.
+// Like for retries, connection failures and 5xx responses count, while 4xx responses do not.
+// Attempts exceeding the upstream timeout count as well.
+func isPodFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *common.UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// abortWithUpstreamError responds to the client after the last upstream attempt failed.
+// It returns false if the failure is not specific to the upstream, in which case the caller keeps its default response.
+func abortWithUpstreamError(c *gin.Context, ctx context.Context, err error) bool {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		c.AbortWithStatusJSON(http.StatusGatewayTimeout, "upstream request timeout")
+		return true
+	}
+	var statusErr *common.UpstreamStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode < http.StatusInternalServerError {
+		for k, vv := range statusErr.Header {
+			// The body may have been truncated, let the writer compute the length.
+			if k == "Content-Length" {
+				continue
+			}
+			for _, v := range vv {
+				c.Header(k, v)
+			}
+		}
+		c.Status(statusErr.StatusCode)
+		_, _ = c.Writer.Write(statusErr.Body)
+		c.Abort()
+		return true
+	}
+	return false
+}
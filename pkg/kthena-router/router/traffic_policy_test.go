@@ -0,0 +1,439 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		trafficPolicy *aiv1alpha1.TrafficPolicy
+		candidates    int
+		want          retryPolicy
+	}{
+		{
+			name:       "no traffic policy tries every candidate once",
+			candidates: 3,
+			want:       retryPolicy{attempts: 3},
+		},
+		{
+			name: "timeout only",
+			trafficPolicy: &aiv1alpha1.TrafficPolicy{
+				Timeout: &v1.Duration{Duration: 5 * time.Second},
+			},
+			candidates: 2,
+			want:       retryPolicy{timeout: 5 * time.Second, attempts: 2},
+		},
+		{
+			name: "retry with default interval",
+			trafficPolicy: &aiv1alpha1.TrafficPolicy{
+				Retry: &aiv1alpha1.Retry{Attempts: 3},
+			},
+			candidates: 1,
+			want:       retryPolicy{attempts: 4, interval: defaultRetryInterval, firstEventTimeout: defaultFirstEventTimeout},
+		},
+		{
+			name: "retry with interval",
+			trafficPolicy: &aiv1alpha1.TrafficPolicy{
+				Timeout: &v1.Duration{Duration: time.Minute},
+				Retry:   &aiv1alpha1.Retry{Attempts: 1, RetryInterval: &v1.Duration{Duration: time.Second}},
+			},
+			candidates: 5,
+			want:       retryPolicy{timeout: time.Minute, attempts: 2, interval: time.Second, firstEventTimeout: defaultFirstEventTimeout},
+		},
+		{
+			name: "zero attempts disables retries",
+			trafficPolicy: &aiv1alpha1.TrafficPolicy{
+				Retry: &aiv1alpha1.Retry{Attempts: 0},
+			},
+			candidates: 5,
+			want:       retryPolicy{attempts: 1, interval: defaultRetryInterval, firstEventTimeout: defaultFirstEventTimeout},
+		},
+		{
+			name: "zero first event timeout disables stream buffering",
+			trafficPolicy: &aiv1alpha1.TrafficPolicy{
+				Retry: &aiv1alpha1.Retry{Attempts: 1, FirstEventTimeout: &v1.Duration{}},
+			},
+			candidates: 5,
+			want:       retryPolicy{attempts: 2, interval: defaultRetryInterval},
+		},
+		{
+			name: "no candidates",
+			trafficPolicy: &aiv1alpha1.TrafficPolicy{
+				Retry: &aiv1alpha1.Retry{Attempts: 3},
+			},
+			candidates: 0,
+			want:       retryPolicy{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newRetryPolicy(tt.trafficPolicy, tt.candidates))
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{
+			name: "connection refused",
+			ctx:  context.Background(),
+			err:  fmt.Errorf("decode request error: %w", syscall.ECONNREFUSED),
+			want: true,
+		},
+		{
+			name: "service unavailable",
+			ctx:  context.Background(),
+			err:  fmt.Errorf("decode request error: %w", &common.UpstreamStatusError{StatusCode: http.StatusServiceUnavailable}),
+			want: true,
+		},
+		{
+			name: "bad gateway",
+			ctx:  context.Background(),
+			err:  &common.UpstreamStatusError{StatusCode: http.StatusBadGateway},
+			want: true,
+		},
+		{
+			name: "bad request",
+			ctx:  context.Background(),
+			err:  &common.UpstreamStatusError{StatusCode: http.StatusBadRequest},
+			want: false,
+		},
+		{
+			name: "request context done",
+			ctx:  canceled,
+			err:  errors.New("context canceled"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isRetryable(tt.ctx, tt.err))
+		})
+	}
+}
+
+// serveWithTrafficPolicy sends a completion request through the router to a single aggregated pod backed by handler.
+func serveWithTrafficPolicy(t *testing.T, handler http.Handler, trafficPolicy *aiv1alpha1.TrafficPolicy) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	return serveAggregatedRequest(t, handler, trafficPolicy, req)
+}
+
+func TestTrafficPolicyRetry(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// The body must be resent on every attempt
+		assert.Contains(t, string(body), "hello")
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"response-id"}`)
+	})
+
+	w := serveWithTrafficPolicy(t, handler, &aiv1alpha1.TrafficPolicy{
+		Retry: &aiv1alpha1.Retry{Attempts: 2, RetryInterval: &v1.Duration{Duration: time.Millisecond}},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"response-id"`)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestTrafficPolicyRetryExhausted(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	w := serveWithTrafficPolicy(t, handler, &aiv1alpha1.TrafficPolicy{
+		Retry: &aiv1alpha1.Retry{Attempts: 1, RetryInterval: &v1.Duration{Duration: time.Millisecond}},
+	})
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "request to all pods failed")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestTrafficPolicyNoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"invalid max_tokens"}`)
+	})
+
+	w := serveWithTrafficPolicy(t, handler, &aiv1alpha1.TrafficPolicy{
+		Retry: &aiv1alpha1.Retry{Attempts: 3, RetryInterval: &v1.Duration{Duration: time.Millisecond}},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"error":"invalid max_tokens"}`, w.Body.String())
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// serveStreamWithTrafficPolicy sends a streaming completion request through the router to a single aggregated pod backed by handler.
+func serveStreamWithTrafficPolicy(t *testing.T, handler http.Handler, trafficPolicy *aiv1alpha1.TrafficPolicy) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello", "stream": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	return serveAggregatedRequest(t, handler, trafficPolicy, req)
+}
+
+func TestTrafficPolicyStreamRetryBeforeFirstEvent(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := calls.Add(1)
+		w.Header().Set("X-Attempt", strconv.Itoa(int(attempt)))
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		switch attempt {
+		case 1:
+			// The engine fails right after responding
+			return
+		case 2:
+			fmt.Fprint(w, "data: {\"object\":\"error\",\"message\":\"engine is dead\"}\n\n")
+		default:
+			fmt.Fprint(w, "data: {\"id\":\"response-id\"}\n\ndata: [DONE]\n\n")
+		}
+	})
+
+	w := serveStreamWithTrafficPolicy(t, handler, &aiv1alpha1.TrafficPolicy{
+		Retry: &aiv1alpha1.Retry{Attempts: 2, RetryInterval: &v1.Duration{Duration: time.Millisecond}},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "data: {\"id\":\"response-id\"}\n\ndata: [DONE]\n\n", w.Body.String())
+	// Only the response of the successful attempt is sent to the client
+	assert.Equal(t, "3", w.Header().Get("X-Attempt"))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestTrafficPolicyStreamCommittedAfterFirstEventTimeout(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ": keep-alive\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"id\":\"response-id\"}\n\n")
+	})
+
+	w := serveStreamWithTrafficPolicy(t, handler, &aiv1alpha1.TrafficPolicy{
+		Retry: &aiv1alpha1.Retry{
+			Attempts:          2,
+			RetryInterval:     &v1.Duration{Duration: time.Millisecond},
+			FirstEventTimeout: &v1.Duration{Duration: 10 * time.Millisecond},
+		},
+	})
+
+	// The slow first event is still forwarded, after the lines buffered before the timeout
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ": keep-alive\n\ndata: {\"id\":\"response-id\"}\n\n", w.Body.String())
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestTrafficPolicyTimeout(t *testing.T) {
+	var calls atomic.Int32
+	// Simulate a hung pod until the end of the test
+	hung := make(chan struct{})
+	defer close(hung)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-hung
+	})
+
+	start := time.Now()
+	w := serveWithTrafficPolicy(t, handler, &aiv1alpha1.TrafficPolicy{
+		Timeout: &v1.Duration{Duration: 100 * time.Millisecond},
+		Retry:   &aiv1alpha1.Retry{Attempts: 3, RetryInterval: &v1.Duration{Duration: time.Millisecond}},
+	})
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	// The timeout bounds all attempts, so the request is not retried once it expired
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestNewConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		trafficPolicy *aiv1alpha1.TrafficPolicy
+		want          concurrencyLimit
+	}{
+		{
+			name: "no traffic policy",
+			want: concurrencyLimit{},
+		},
+		{
+			name: "reject by default",
+			trafficPolicy: &aiv1alpha1.TrafficPolicy{
+				ConcurrencyLimit: &aiv1alpha1.ConcurrencyLimit{MaxRequestsPerPod: 4},
+			},
+			want: concurrencyLimit{maxRequests: 4},
+		},
+		{
+			name: "queue with default timeout",
+			trafficPolicy: &aiv1alpha1.TrafficPolicy{
+				ConcurrencyLimit: &aiv1alpha1.ConcurrencyLimit{MaxRequestsPerPod: 4, Overflow: aiv1alpha1.OverflowQueue},
+			},
+			want: concurrencyLimit{maxRequests: 4, queueTimeout: defaultQueueTimeout},
+		},
+		{
+			name: "queue with timeout",
+			trafficPolicy: &aiv1alpha1.TrafficPolicy{
+				ConcurrencyLimit: &aiv1alpha1.ConcurrencyLimit{
+					MaxRequestsPerPod: 4,
+					Overflow:          aiv1alpha1.OverflowQueue,
+					QueueTimeout:      &v1.Duration{Duration: time.Second},
+				},
+			},
+			want: concurrencyLimit{maxRequests: 4, queueTimeout: time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newConcurrencyLimit(tt.trafficPolicy))
+		})
+	}
+}
+
+// serveConcurrentRequests sends a request which hangs in the backend, then a second one while the first is in flight.
+// It returns the response of the second request.
+func serveConcurrentRequests(t *testing.T, trafficPolicy *aiv1alpha1.TrafficPolicy, releaseAfter time.Duration) *httptest.ResponseRecorder {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"response-id"}`)
+	})
+	router := setupAggregatedRouter(t, handler, trafficPolicy)
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() {
+		first <- serveRequest(router, newRequest())
+	}()
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	time.AfterFunc(releaseAfter, func() { close(release) })
+	w := serveRequest(router, newRequest())
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	return w
+}
+
+func TestTrafficPolicyConcurrencyLimitReject(t *testing.T) {
+	w := serveConcurrentRequests(t, &aiv1alpha1.TrafficPolicy{
+		ConcurrencyLimit: &aiv1alpha1.ConcurrencyLimit{MaxRequestsPerPod: 1, Overflow: aiv1alpha1.OverflowReject},
+	}, 0)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), errPodsSaturated.Error())
+}
+
+func TestTrafficPolicyConcurrencyLimitQueue(t *testing.T) {
+	w := serveConcurrentRequests(t, &aiv1alpha1.TrafficPolicy{
+		ConcurrencyLimit: &aiv1alpha1.ConcurrencyLimit{
+			MaxRequestsPerPod: 1,
+			Overflow:          aiv1alpha1.OverflowQueue,
+			QueueTimeout:      &v1.Duration{Duration: 5 * time.Second},
+		},
+	}, 50*time.Millisecond)
+
+	// The second request is sent once the first one completes
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"response-id"`)
+}
+
+func TestTrafficPolicyConcurrencyLimitQueueTimeout(t *testing.T) {
+	w := serveConcurrentRequests(t, &aiv1alpha1.TrafficPolicy{
+		ConcurrencyLimit: &aiv1alpha1.ConcurrencyLimit{
+			MaxRequestsPerPod: 1,
+			Overflow:          aiv1alpha1.OverflowQueue,
+			QueueTimeout:      &v1.Duration{Duration: 50 * time.Millisecond},
+		},
+	}, 500*time.Millisecond)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestTrafficPolicyOutlierDetection(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	consecutiveFailures, maxEjectionPercent := int32(2), int32(100)
+	router := setupAggregatedRouter(t, handler, &aiv1alpha1.TrafficPolicy{
+		OutlierDetection: &aiv1alpha1.OutlierDetection{
+			ConsecutiveFailures: &consecutiveFailures,
+			MaxEjectionPercent:  &maxEjectionPercent,
+		},
+	})
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	// The only pod of the ModelServer is never ejected, so that requests can still be scheduled
+	for i := 0; i < 5; i++ {
+		w := serveRequest(router, newRequest())
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+	assert.False(t, router.store.GetPodInfo(types.NamespacedName{Namespace: "default", Name: "pod-1"}).IsEjected())
+	assert.Equal(t, int32(5), calls.Load())
+}
@@ -92,6 +92,9 @@ func registerDefaultPlugins(registry *PluginRegistry) {
 	registry.registerFilterPlugin(plugins.LoraAffinityPluginName, func(args runtime.RawExtension) framework.FilterPlugin {
 		return plugins.NewLoraAffinity()
 	})
+	registry.registerFilterPlugin(plugins.OutlierDetectionPluginName, func(args runtime.RawExtension) framework.FilterPlugin {
+		return plugins.NewOutlierDetection()
+	})
 }
 
 func getFilterPlugins(registry *PluginRegistry, filterPluginMap []string, pluginsArgMap map[string]runtime.RawExtension) []framework.FilterPlugin {
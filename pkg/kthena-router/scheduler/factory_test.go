@@ -75,6 +75,7 @@ func TestRegisterDefaultPlugins(t *testing.T) {
 	expectedFilterPlugins := []string{
 		plugins.LeastRequestPluginName,
 		plugins.LoraAffinityPluginName,
+		plugins.OutlierDetectionPluginName,
 	}
 
 	for _, pluginName := range expectedFilterPlugins {
@@ -42,6 +42,12 @@ type Context struct {
 	// 2. PD aggregated mode, BestPods is selected for inference.
 	BestPods []*datastore.PodInfo
 
+	// SessionKey identifies the session of the request when its ModelServer has a session affinity,
+	// the requests of a session are preferably scheduled to the same pod.
+	SessionKey string
+	// SessionMaxLoadPercent bounds the in-flight requests of the pod of a session, as a percentage of the average.
+	SessionMaxLoadPercent int
+
 	// MetricsRecorder for recording scheduler plugin metrics
 	MetricsRecorder *metrics.RequestMetricsRecorder
 }
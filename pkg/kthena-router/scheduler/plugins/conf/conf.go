@@ -26,8 +26,12 @@ import (
 )
 
 type RouterConfiguration struct {
-	Scheduler SchedulerConfiguration `yaml:"scheduler"`
-	Auth      AuthenticationConfig   `yaml:"auth"`
+	Scheduler    SchedulerConfiguration `yaml:"scheduler"`
+	Auth         AuthenticationConfig   `yaml:"auth"`
+	Multimodal   MultimodalConfig       `yaml:"multimodal"`
+	ResponsesAPI ResponsesAPIConfig     `yaml:"responsesAPI"`
+	Quota        QuotaConfig            `yaml:"quota"`
+	Metering     MeteringConfig         `yaml:"metering"`
 }
 
 type SchedulerConfiguration struct {
@@ -64,6 +68,72 @@ type AuthenticationConfig struct {
 	Issuer    string   `yaml:"issuer"`
 	Audiences []string `yaml:"audiences"`
 	JwksUri   string   `yaml:"jwksUri"`
+	// APIKey configures the authentication of requests with API keys, alongside JWTs
+	APIKey APIKeyConfig `yaml:"apiKey"`
+}
+
+// APIKeyConfig configures the authentication of requests with the API keys stored in labeled Secrets.
+type APIKeyConfig struct {
+	// Enabled enables the authentication of requests with API keys.
+	Enabled bool `yaml:"enabled"`
+	// Namespace is the namespace of the API key Secrets. Secrets of all namespaces are watched if it is not set.
+	Namespace string `yaml:"namespace"`
+}
+
+// MultimodalConfig configures the token accounting of the non-text content parts of chat messages.
+type MultimodalConfig struct {
+	// TokensPerItem is the estimated token cost of one content part by type, e.g. image_url or input_audio.
+	// It overrides the default cost of the type.
+	TokensPerItem map[string]int `yaml:"tokensPerItem"`
+}
+
+// ResponsesAPIConfig configures the serving of the OpenAI Responses API.
+type ResponsesAPIConfig struct {
+	// TranslateToChatCompletions lists the inference engines, e.g. SGLang, which do not implement the Responses API.
+	// Responses API requests routed to their ModelServers are translated into chat completions requests.
+	TranslateToChatCompletions []string `yaml:"translateToChatCompletions"`
+}
+
+// QuotaConfig configures the usage ledger of the token quotas of ModelRoutes without global rate limiting.
+type QuotaConfig struct {
+	// LedgerPath is the file persisting the usage of the quotas across restarts of the router.
+	// The usage is only kept in memory if it is not set.
+	LedgerPath string `yaml:"ledgerPath"`
+}
+
+// MeteringConfig configures the export of the token usage of requests, e.g. for chargeback.
+// Usage is only exported to the sinks which are configured.
+type MeteringConfig struct {
+	// File is the JSON lines file the usage records are appended to.
+	File string `yaml:"file"`
+	// Webhook configures the HTTP endpoint the batches of usage records are posted to.
+	Webhook *WebhookConfig `yaml:"webhook"`
+	// BatchSize is the maximum number of usage records exported at once. Defaults to 100.
+	BatchSize int `yaml:"batchSize"`
+	// FlushInterval is the maximum time a usage record waits to be exported, e.g. 5s. Defaults to 5s.
+	FlushInterval string `yaml:"flushInterval"`
+	// QueueSize is the number of usage records buffered in memory for each sink. Defaults to 10000.
+	QueueSize int `yaml:"queueSize"`
+	// SpoolDir is the directory where the usage records are spooled while a sink is unavailable or
+	// can't keep up. Usage records are dropped in that case if it is not set.
+	SpoolDir string `yaml:"spoolDir"`
+	// SpoolMaxBytes bounds the size of the spool of each sink. Defaults to 1GiB.
+	SpoolMaxBytes int64 `yaml:"spoolMaxBytes"`
+	// TenantHeader is the request header holding the tenant of usage records.
+	TenantHeader string `yaml:"tenantHeader"`
+	// TenantClaim is the claim of the authenticated JWT of requests holding the tenant of usage records.
+	// It takes precedence over TenantHeader.
+	TenantClaim string `yaml:"tenantClaim"`
+}
+
+// WebhookConfig configures an HTTP endpoint usage records are posted to.
+type WebhookConfig struct {
+	// URL is the endpoint the batches of usage records are posted to, as JSON arrays.
+	URL string `yaml:"url"`
+	// Headers are the headers of the requests to the endpoint, e.g. Authorization.
+	Headers map[string]string `yaml:"headers"`
+	// Timeout is the timeout of the requests to the endpoint, e.g. 10s. Defaults to 10s.
+	Timeout string `yaml:"timeout"`
 }
 
 func ParseRouterConfig(configMapPath string) (*RouterConfiguration, error) {
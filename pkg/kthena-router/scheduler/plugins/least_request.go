@@ -75,8 +75,11 @@ func (l *LeastRequest) Score(ctx *framework.Context, pods []*datastore.PodInfo)
 	baseScores := make(map[*datastore.PodInfo]float64)
 	maxScore := 0.0
 	for _, info := range pods {
+		// The requests in flight from the router are counted as soon as they are dispatched, while the scraped ones lag
+		// behind by up to a scrape interval. Taking the larger one spreads a burst of requests across the pods.
+		running := max(info.RequestRunningNum, float64(info.GetInFlightRequests()))
 		// The weight of waiting requests is 100. It's a magic number just to sinificantly lower the score of the pod when there are waiting reqs.
-		base := info.RequestRunningNum + 100*info.RequestWaitingNum
+		base := running + 100*info.RequestWaitingNum
 		baseScores[info] = base
 		if base > maxScore {
 			maxScore = base
@@ -0,0 +1,52 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+func TestLeastRequest_ScoreInFlightRequests(t *testing.T) {
+	store := datastore.New()
+	var pods []*datastore.PodInfo
+	for _, name := range []string{"pod1", "pod2"} {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+		assert.NoError(t, store.AddOrUpdatePod(pod, nil))
+		podInfo := store.GetPodInfo(types.NamespacedName{Namespace: "default", Name: name})
+		// Both pods report the same metrics at the last scrape
+		podInfo.RequestRunningNum = 1
+		pods = append(pods, podInfo)
+	}
+	// A burst of requests dispatched to pod1 since the last scrape
+	for i := 0; i < 4; i++ {
+		pods[0].AcquireRequest(0)
+	}
+
+	plugin := NewLeastRequest(runtime.RawExtension{Raw: []byte(`{"maxWaitingRequests": 10}`)})
+	scores := plugin.Score(&framework.Context{}, pods)
+	assert.Equal(t, 0, scores[pods[0]])
+	assert.Equal(t, 75, scores[pods[1]])
+}
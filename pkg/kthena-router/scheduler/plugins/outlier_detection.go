@@ -0,0 +1,49 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"istio.io/istio/pkg/slices"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+const OutlierDetectionPluginName = "outlier-detection"
+
+// OutlierDetection filters out the pods ejected by the outlier detection of their ModelServer.
+type OutlierDetection struct {
+	name string
+}
+
+var _ framework.FilterPlugin = &OutlierDetection{}
+
+func NewOutlierDetection() *OutlierDetection {
+	return &OutlierDetection{
+		name: OutlierDetectionPluginName,
+	}
+}
+
+func (o *OutlierDetection) Name() string {
+	return o.name
+}
+
+func (o *OutlierDetection) Filter(ctx *framework.Context, pods []*datastore.PodInfo) []*datastore.PodInfo {
+	return slices.FilterInPlace(pods, func(info *datastore.PodInfo) bool {
+		return !info.IsEjected()
+	})
+}
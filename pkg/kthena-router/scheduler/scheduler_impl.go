@@ -18,6 +18,7 @@ package scheduler
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"time"
 
@@ -69,6 +70,7 @@ func NewScheduler(store datastore.Store, routerConfig *conf.RouterConfiguration)
 	}
 	filterPluginMap := []string{
 		"least-request",
+		"outlier-detection",
 	}
 	pluginsArgMap := map[string]runtime.RawExtension{
 		"least-request": {Raw: []byte(`{"maxWaitingRequests": 10}`)},
@@ -109,11 +111,17 @@ func (s *SchedulerImpl) Schedule(ctx *framework.Context, pods []*datastore.PodIn
 		// Use optimized PDGroup scheduling with pre-categorized pods from store
 		klog.V(4).Info("Using optimized PD disaggregated scheduling")
 
-		// Get decode pods directly from store (O(1) lookup)
+		// Get decode pods directly from store (O(1) lookup), keeping the ones which passed the filter plugins,
+		// e.g. the pods which are not ejected by the outlier detection
+		filtered := make(map[*datastore.PodInfo]struct{}, len(pods))
+		for _, pod := range pods {
+			filtered[pod] = struct{}{}
+		}
 		decodePods, err := s.store.GetDecodePods(ctx.ModelServerName)
 		if err != nil {
 			return fmt.Errorf("failed to get decode pods: %v", err)
 		}
+		decodePods = keepFiltered(decodePods, filtered)
 
 		if len(decodePods) == 0 {
 			return fmt.Errorf("no decode pod found")
@@ -122,7 +130,7 @@ func (s *SchedulerImpl) Schedule(ctx *framework.Context, pods []*datastore.PodIn
 		klog.V(4).Info("Running score plugins for decode pod")
 		scores := s.RunScorePlugins(decodePods, ctx)
 
-		topNDecodePods := TopNPodInfos(scores, topN)
+		topNDecodePods := preferSessionPod(ctx, decodePods, TopNPodInfos(scores, topN))
 		ctx.DecodePods = topNDecodePods
 		prefillPods := make([]*datastore.PodInfo, len(topNDecodePods))
 
@@ -133,6 +141,7 @@ func (s *SchedulerImpl) Schedule(ctx *framework.Context, pods []*datastore.PodIn
 					Namespace: decodePod.Pod.Namespace,
 					Name:      decodePod.Pod.Name,
 				})
+			selectedPods = keepFiltered(selectedPods, filtered)
 			if err != nil || len(selectedPods) == 0 {
 				klog.V(4).InfoS("prefill pods for decode group not found", "decode instance", klog.KObj(decodePod.Pod), "error", err)
 				continue
@@ -149,11 +158,19 @@ func (s *SchedulerImpl) Schedule(ctx *framework.Context, pods []*datastore.PodIn
 
 	klog.V(4).Info("Running score plugins for PD aggregated pod")
 	scores := s.RunScorePlugins(pods, ctx)
-	ctx.BestPods = TopNPodInfos(scores, topN)
+	ctx.BestPods = preferSessionPod(ctx, pods, TopNPodInfos(scores, topN))
 
 	return nil
 }
 
+// keepFiltered returns the pods which are in filtered
+func keepFiltered(pods []*datastore.PodInfo, filtered map[*datastore.PodInfo]struct{}) []*datastore.PodInfo {
+	return slices.DeleteFunc(pods, func(pod *datastore.PodInfo) bool {
+		_, ok := filtered[pod]
+		return !ok
+	})
+}
+
 func (s *SchedulerImpl) RunFilterPlugins(pods []*datastore.PodInfo, ctx *framework.Context) ([]*datastore.PodInfo, error) {
 	for _, filterPlugin := range s.filterPlugins {
 		// Record filter plugin execution time
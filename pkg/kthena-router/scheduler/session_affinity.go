@@ -0,0 +1,95 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"slices"
+
+	"github.com/cespare/xxhash"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+// sessionPod returns the pod of the session of the request among pods, or nil if the request has no session.
+//
+// The pods are ranked by rendezvous hashing of the session key and the pod name, and the first pod whose in-flight
+// requests are within ctx.SessionMaxLoadPercent of the average is chosen, i.e. consistent hashing with bounded load.
+// A session keeps its pod across router restarts and replicas as long as the pod is available and not overloaded,
+// and the sessions of a removed pod are the only ones moving to other pods.
+func sessionPod(ctx *framework.Context, pods []*datastore.PodInfo) *datastore.PodInfo {
+	if ctx.SessionKey == "" || len(pods) == 0 {
+		return nil
+	}
+
+	type rankedPod struct {
+		pod  *datastore.PodInfo
+		rank uint64
+	}
+	ranked := make([]rankedPod, 0, len(pods))
+	var total int64
+	for _, pod := range pods {
+		if pod.Pod == nil {
+			continue
+		}
+		total += pod.GetInFlightRequests()
+		ranked = append(ranked, rankedPod{pod: pod, rank: xxhash.Sum64String(ctx.SessionKey + "/" + pod.Pod.Name)})
+	}
+	slices.SortFunc(ranked, func(a, b rankedPod) int {
+		switch {
+		case a.rank > b.rank:
+			return -1
+		case a.rank < b.rank:
+			return 1
+		}
+		return 0
+	})
+
+	// A pod takes another request while it stays within the bound of the average load including this request.
+	// At least one pod is always within the bound, as the average can't be above all the pods.
+	maxLoadPercent := int64(max(ctx.SessionMaxLoadPercent, 100))
+	for i, candidate := range ranked {
+		if candidate.pod.GetInFlightRequests()*100*int64(len(ranked)) < maxLoadPercent*(total+1) {
+			if i > 0 {
+				klog.V(4).Infof("session pod %s is overloaded, using pod %s", ranked[0].pod.Pod.Name, candidate.pod.Pod.Name)
+			}
+			return candidate.pod
+		}
+	}
+	return nil
+}
+
+// preferSessionPod moves the pod of the session of the request to the front of the best pods, adding it if it
+// is not among them. The other pods are kept as fallbacks, in case the pod of the session fails.
+func preferSessionPod(ctx *framework.Context, pods []*datastore.PodInfo, best []*datastore.PodInfo) []*datastore.PodInfo {
+	pod := sessionPod(ctx, pods)
+	if pod == nil {
+		return best
+	}
+	res := make([]*datastore.PodInfo, 0, len(best)+1)
+	res = append(res, pod)
+	for _, p := range best {
+		if p != pod {
+			res = append(res, p)
+		}
+	}
+	if len(res) > max(len(best), 1) {
+		res = res[:len(best)]
+	}
+	return res
+}
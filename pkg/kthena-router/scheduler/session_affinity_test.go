@@ -0,0 +1,123 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+// newSessionPods returns n pods of a store, so that their in-flight requests are tracked.
+func newSessionPods(t *testing.T, n int) []*datastore.PodInfo {
+	store := datastore.New()
+	pods := make([]*datastore.PodInfo, 0, n)
+	for i := 0; i < n; i++ {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"}}
+		assert.NoError(t, store.AddOrUpdatePod(pod, nil))
+		pods = append(pods, store.GetPodInfo(types.NamespacedName{Namespace: "default", Name: pod.Name}))
+	}
+	return pods
+}
+
+func TestSessionPod(t *testing.T) {
+	pods := newSessionPods(t, 5)
+
+	t.Run("no session", func(t *testing.T) {
+		assert.Nil(t, sessionPod(&framework.Context{}, pods))
+	})
+
+	t.Run("sessions keep their pod", func(t *testing.T) {
+		used := map[*datastore.PodInfo]bool{}
+		for i := 0; i < 50; i++ {
+			ctx := &framework.Context{SessionKey: fmt.Sprintf("session-%d", i), SessionMaxLoadPercent: 125}
+			pod := sessionPod(ctx, pods)
+			assert.NotNil(t, pod)
+			assert.Same(t, pod, sessionPod(ctx, pods))
+			used[pod] = true
+		}
+		// The sessions are spread over the pods
+		assert.Greater(t, len(used), 1)
+	})
+
+	t.Run("only the sessions of a removed pod move", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			ctx := &framework.Context{SessionKey: fmt.Sprintf("session-%d", i), SessionMaxLoadPercent: 125}
+			pod := sessionPod(ctx, pods)
+			remaining := make([]*datastore.PodInfo, 0, len(pods)-1)
+			for _, p := range pods {
+				if p != pods[0] {
+					remaining = append(remaining, p)
+				}
+			}
+			if pod != pods[0] {
+				assert.Same(t, pod, sessionPod(ctx, remaining))
+			} else {
+				assert.NotSame(t, pod, sessionPod(ctx, remaining))
+			}
+		}
+	})
+
+	t.Run("overloaded pod is skipped", func(t *testing.T) {
+		ctx := &framework.Context{SessionKey: "session-0", SessionMaxLoadPercent: 125}
+		pod := sessionPod(ctx, pods)
+		for i := 0; i < 10; i++ {
+			pod.AcquireRequest(0)
+		}
+		defer func() {
+			for i := 0; i < 10; i++ {
+				pod.ReleaseRequest()
+			}
+		}()
+
+		other := sessionPod(ctx, pods)
+		assert.NotNil(t, other)
+		assert.NotSame(t, pod, other)
+	})
+}
+
+func TestPreferSessionPod(t *testing.T) {
+	pods := newSessionPods(t, 4)
+	ctx := &framework.Context{SessionKey: "session-0", SessionMaxLoadPercent: 125}
+	pod := sessionPod(ctx, pods)
+
+	var others []*datastore.PodInfo
+	for _, p := range pods {
+		if p != pod {
+			others = append(others, p)
+		}
+	}
+
+	// The pod of the session is moved to the front
+	best := preferSessionPod(ctx, pods, append([]*datastore.PodInfo{others[0], pod}, others[1:]...))
+	assert.Equal(t, append([]*datastore.PodInfo{pod}, others...), best)
+
+	// The pod of the session is added when it is not among the best pods
+	best = preferSessionPod(ctx, pods, others[:2])
+	assert.Equal(t, []*datastore.PodInfo{pod, others[0]}, best)
+
+	// Requests without a session keep the best pods
+	best = preferSessionPod(&framework.Context{}, pods, others)
+	assert.Equal(t, others, best)
+}
@@ -19,6 +19,7 @@ package utils
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -70,15 +71,13 @@ func ParsePrompt(body map[string]interface{}) (common.ChatMessage, error) {
 				continue
 			}
 
-			content, ok := msgMap["content"].(string)
+			msg, ok := parseMessageContent(msgMap["content"])
 			if !ok {
 				continue
 			}
+			msg.Role = role
 
-			msgs = append(msgs, common.Message{
-				Role:    role,
-				Content: content,
-			})
+			msgs = append(msgs, msg)
 		}
 
 		return common.ChatMessage{
@@ -89,6 +88,52 @@ func ParsePrompt(body map[string]interface{}) (common.ChatMessage, error) {
 	return common.ChatMessage{}, fmt.Errorf("prompt or messages not found in request body")
 }
 
+// parseMessageContent parses the content of a chat message, which is either a string
+// or an array of content parts like {"type": "text", "text": "..."} or {"type": "image_url", "image_url": {...}}.
+func parseMessageContent(content interface{}) (common.Message, bool) {
+	switch c := content.(type) {
+	case string:
+		return common.Message{Content: c}, true
+	case []interface{}:
+		var msg common.Message
+		var texts []string
+		for _, item := range c {
+			partMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			partType, ok := partMap["type"].(string)
+			if !ok {
+				continue
+			}
+			part := common.ContentPart{Type: partType}
+			if partType == common.ContentPartText {
+				part.Text, _ = partMap["text"].(string)
+				texts = append(texts, part.Text)
+			}
+			msg.Parts = append(msg.Parts, part)
+		}
+		msg.Content = strings.Join(texts, "\n")
+		return msg, true
+	default:
+		return common.Message{}, false
+	}
+}
+
+// GetMediaTokens returns the estimated number of tokens of the non-text content parts of the chat messages.
+// tokensPerItem maps a content part type to the token cost of one item, types that are not in it cost nothing.
+func GetMediaTokens(chatMessage common.ChatMessage, tokensPerItem map[string]int) int {
+	tokens := 0
+	for _, msg := range chatMessage.Messages {
+		for _, part := range msg.Parts {
+			if part.Type != common.ContentPartText {
+				tokens += tokensPerItem[part.Type]
+			}
+		}
+	}
+	return tokens
+}
+
 func GetPromptString(chatMessage common.ChatMessage) string {
 	// If Text field is present, return text directly (for prompt format)
 	if chatMessage.Text != "" {
@@ -0,0 +1,136 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+)
+
+func TestParsePrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    common.ChatMessage
+		wantErr bool
+	}{
+		{
+			name: "prompt",
+			body: `{"prompt": "hello"}`,
+			want: common.ChatMessage{Text: "hello"},
+		},
+		{
+			name:    "prompt is not a string",
+			body:    `{"prompt": 1}`,
+			wantErr: true,
+		},
+		{
+			name: "text messages",
+			body: `{"messages": [{"role": "system", "content": "be nice"}, {"role": "user", "content": "hi"}]}`,
+			want: common.ChatMessage{Messages: []common.Message{
+				{Role: "system", Content: "be nice"},
+				{Role: "user", Content: "hi"},
+			}},
+		},
+		{
+			name: "multimodal message",
+			body: `{"messages": [{"role": "user", "content": [
+				{"type": "text", "text": "what is in this image?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA"}},
+				{"type": "input_audio", "input_audio": {"data": "AAAA", "format": "wav"}},
+				{"type": "text", "text": "and in this audio?"}
+			]}]}`,
+			want: common.ChatMessage{Messages: []common.Message{
+				{
+					Role:    "user",
+					Content: "what is in this image?\nand in this audio?",
+					Parts: []common.ContentPart{
+						{Type: common.ContentPartText, Text: "what is in this image?"},
+						{Type: common.ContentPartImageURL},
+						{Type: common.ContentPartInputAudio},
+						{Type: common.ContentPartText, Text: "and in this audio?"},
+					},
+				},
+			}},
+		},
+		{
+			name: "messages without content are skipped",
+			body: `{"messages": [{"role": "assistant", "content": null, "tool_calls": []}, {"role": "tool", "content": "42"}]}`,
+			want: common.ChatMessage{Messages: []common.Message{{Role: "tool", Content: "42"}}},
+		},
+		{
+			name:    "no prompt nor messages",
+			body:    `{"model": "m"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &body))
+			got, err := ParsePrompt(body)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetMediaTokens(t *testing.T) {
+	tokensPerItem := map[string]int{
+		common.ContentPartImageURL:   100,
+		common.ContentPartInputAudio: 10,
+	}
+	chatMessage := common.ChatMessage{Messages: []common.Message{
+		{Role: "system", Content: "be nice"},
+		{
+			Role:    "user",
+			Content: "compare",
+			Parts: []common.ContentPart{
+				{Type: common.ContentPartText, Text: "compare"},
+				{Type: common.ContentPartImageURL},
+				{Type: common.ContentPartImageURL},
+				{Type: common.ContentPartInputAudio},
+				{Type: "unknown"},
+			},
+		},
+	}}
+
+	assert.Equal(t, 210, GetMediaTokens(chatMessage, tokensPerItem))
+	assert.Equal(t, 0, GetMediaTokens(common.ChatMessage{Text: "hello"}, tokensPerItem))
+	assert.Equal(t, 0, GetMediaTokens(chatMessage, nil))
+}
+
+func TestGetPromptStringMultimodal(t *testing.T) {
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"messages": [{"role": "user", "content": [
+		{"type": "image_url", "image_url": {"url": "https://example.com/cat.png"}},
+		{"type": "text", "text": "describe it"}
+	]}]}`), &body))
+	chatMessage, err := ParsePrompt(body)
+	require.NoError(t, err)
+	// Only the text parts are hashed and tokenized
+	assert.Equal(t, "<|im_start|>user\ndescribe it<|im_end|>\n", GetPromptString(chatMessage))
+}
@@ -408,7 +408,7 @@ func (c *ModelServingController) syncModelServing(ctx context.Context, key strin
 		return fmt.Errorf("cannot manage role replicas: %v", err)
 	}
 
-	err = c.manageServingGroupRollingUpdate(mi, revision)
+	err = c.manageServingGroupRollingUpdate(ctx, mi, revision)
 	if err != nil {
 		return fmt.Errorf("cannot manage ServingGroup rollingUpdate: %v", err)
 	}
@@ -557,9 +557,25 @@ func (c *ModelServingController) manageServingGroupReplicas(ctx context.Context,
 		return nil
 	}
 
+	if curReplicas > expectedCount {
+		// During a rolling update, the ServingGroups surged by maxSurge are kept until the outdated groups are replaced.
+		maxSurge, _, err := utils.GetMaxSurgeAndMaxUnavailable(mi)
+		if err != nil {
+			return err
+		}
+		if maxSurge > 0 {
+			outdatedGroups := c.getOutdatedServingGroups(mi, servingGroupList, newRevision)
+			expectedCount += min(maxSurge, len(outdatedGroups))
+			if curReplicas <= expectedCount {
+				klog.V(4).Infof("keep %d surge ServingGroups during rolling update", curReplicas-int(*mi.Spec.Replicas))
+				return nil
+			}
+		}
+	}
+
 	// Determine whether it is a scale-up or scale-down scenario
 	if curReplicas < expectedCount {
-		err = c.scaleUpServingGroups(ctx, mi, servingGroupList, expectedCount, newRevision)
+		err = c.scaleUpServingGroups(ctx, mi, servingGroupList, expectedCount, 0, newRevision)
 		if err != nil {
 			return fmt.Errorf("failed to scale up ServingGroups: %v", err)
 		}
@@ -573,32 +589,31 @@ func (c *ModelServingController) manageServingGroupReplicas(ctx context.Context,
 }
 
 // scaleUpServingGroups scales up the ServingGroups to the expected count.
-// It creates new ServingGroups with increasing indices starting from the current max index + 1.
-func (c *ModelServingController) scaleUpServingGroups(ctx context.Context, mi *workloadv1alpha1.ModelServing, servingGroupList []datastore.ServingGroup, expectedCount int, newRevision string) error {
-	// Find the maximum existing valid index to determine the starting point for new ServingGroups
+// It creates new ServingGroups at the smallest free indices that are not below minIndex, so the ordinals
+// freed by deleted ServingGroups, e.g. the outdated groups replaced in a rolling update, are reused.
+func (c *ModelServingController) scaleUpServingGroups(ctx context.Context, mi *workloadv1alpha1.ModelServing, servingGroupList []datastore.ServingGroup, expectedCount int, minIndex int, newRevision string) error {
+	// Collect the existing valid indices, including ServingGroups being deleted whose names are still in use
 	// Also count only ServingGroups with valid ordinals
-	maxIndex := -1
-	validCount := 0
+	usedIndices := make(map[int]struct{}, len(servingGroupList))
 	for _, group := range servingGroupList {
 		_, servingGroupOrdinal := utils.GetParentNameAndOrdinal(group.Name)
 		if servingGroupOrdinal >= 0 {
-			validCount++
-			if servingGroupOrdinal > maxIndex {
-				maxIndex = servingGroupOrdinal
-			}
+			usedIndices[servingGroupOrdinal] = struct{}{}
 		}
 	}
 
 	// Calculate how many new ServingGroups we need to create
-	toCreate := expectedCount - validCount
+	toCreate := expectedCount - len(usedIndices)
 	if toCreate <= 0 {
 		// No new ServingGroups need to be created
 		return nil
 	}
 
-	// Create new ServingGroups with increasing indices
-	for i := 0; i < toCreate; i++ {
-		newIndex := maxIndex + 1 + i
+	// Create new ServingGroups at the smallest free indices
+	for newIndex := minIndex; toCreate > 0; newIndex++ {
+		if _, ok := usedIndices[newIndex]; ok {
+			continue
+		}
 		// Create pods for ServingGroup
 		err := c.CreatePodsForServingGroup(ctx, mi, newIndex, newRevision)
 		if err != nil {
@@ -606,6 +621,7 @@ func (c *ModelServingController) scaleUpServingGroups(ctx context.Context, mi *w
 		}
 		// Insert new ServingGroup to global storage
 		c.store.AddServingGroup(utils.GetNamespaceName(mi), newIndex, newRevision)
+		toCreate--
 	}
 
 	return nil
@@ -980,38 +996,95 @@ func (c *ModelServingController) DeleteRole(ctx context.Context, mi *workloadv1a
 	}
 }
 
-func (c *ModelServingController) manageServingGroupRollingUpdate(mi *workloadv1alpha1.ModelServing, revision string) error {
-	// we compute the minimum ordinal of the target sequence for a destructive update based on the strategy.
-	updateMin := 0
-	if mi.Spec.RolloutStrategy != nil && mi.Spec.RolloutStrategy.RollingUpdateConfiguration != nil && mi.Spec.RolloutStrategy.RollingUpdateConfiguration.Partition != nil {
-		updateMin = int(*mi.Spec.RolloutStrategy.RollingUpdateConfiguration.Partition)
-	}
+func (c *ModelServingController) manageServingGroupRollingUpdate(ctx context.Context, mi *workloadv1alpha1.ModelServing, revision string) error {
 	servingGroupList, err := c.store.GetServingGroupByModelServing(utils.GetNamespaceName(mi))
 	if err != nil {
 		return fmt.Errorf("cannot get ServingGroupList from store, err:%v", err)
 	}
-	// we terminate the ServingGroup with the largest ordinal that does not match the update revision.
-	for i := len(servingGroupList) - 1; i >= updateMin; i-- {
-		if c.isServingGroupOutdated(servingGroupList[i], mi.Namespace, revision) {
-			// target ServingGroup is not the latest version, needs to be updated
-			klog.V(2).Infof("ServingGroup %s will be terminating for update", servingGroupList[i].Name)
-			c.DeleteServingGroup(mi, servingGroupList[i].Name)
-			return nil
+	maxSurge, maxUnavailable, err := utils.GetMaxSurgeAndMaxUnavailable(mi)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range servingGroupList {
+		if group.Status == datastore.ServingGroupDeleting {
+			// Check again whether the deletion has completed, the group will be recreated with the latest version.
+			c.DeleteServingGroup(mi, group.Name)
 		}
-		if servingGroupList[i].Status != datastore.ServingGroupRunning {
-			// target ServingGroup is the latest version, but not running. We need to wait for the status to change to running.
-			// If the group fails after rolling, it will automatically be deleted and rebuilt when detecting the pod failure.
-			// If the group still pending due to reasons such as being unable to be scheduled, rolling update process will stop
-			// to avoid affecting other groups that are running normally.
-			klog.V(4).Infof("waiting for the ServingGroup %s status become running", servingGroupList[i].Name)
-			return nil
+	}
+
+	outdatedGroups := c.getOutdatedServingGroups(mi, servingGroupList, revision)
+	if len(outdatedGroups) == 0 {
+		klog.V(2).Infof("all target groups of modelServing %s have been updated", mi.Name)
+		return nil
+	}
+
+	// Surge new ServingGroups above replicas, but never more than the outdated groups left to replace.
+	// New groups reuse the ordinals freed by the replaced groups and are never created below the partition,
+	// since the free ordinals there belong to the ServingGroups kept at the old revision. This way the ordinals
+	// stay below replicas+maxSurge after any number of rollouts.
+	replicas := int(*mi.Spec.Replicas)
+	if expectedCount := replicas + min(maxSurge, len(outdatedGroups)); len(servingGroupList) < expectedCount {
+		klog.V(2).Infof("surge ServingGroups of modelServing %s to %d for update", mi.Name, expectedCount)
+		if err := c.scaleUpServingGroups(ctx, mi, servingGroupList, expectedCount, getPartition(mi), revision); err != nil {
+			return fmt.Errorf("failed to surge ServingGroups: %v", err)
 		}
-		// target ServingGroup is already the latest version and running, processing the rolling update of the next group.
 	}
-	klog.V(2).Infof("all target groups of modelServing %s have been updated", mi.Name)
+
+	// Only running ServingGroups are available. A group of the latest version that is not running yet, e.g. pending
+	// because it cannot be scheduled, consumes the maxUnavailable budget and stops the rolling update,
+	// to avoid affecting other groups that are running normally.
+	available := 0
+	for _, group := range servingGroupList {
+		if group.Status == datastore.ServingGroupRunning {
+			available++
+		}
+	}
+	toDelete := min(available-(replicas-maxUnavailable), len(outdatedGroups))
+	if toDelete <= 0 {
+		klog.V(4).Infof("waiting for ServingGroups of modelServing %s to become running, available: %d", mi.Name, available)
+		return nil
+	}
+
+	// we terminate the ServingGroups with the largest ordinals that do not match the update revision.
+	for _, group := range outdatedGroups[:toDelete] {
+		klog.V(2).Infof("ServingGroup %s will be terminating for update", group.Name)
+		c.DeleteServingGroup(mi, group.Name)
+	}
 	return nil
 }
 
+// getOutdatedServingGroups returns the ServingGroups that do not match the update revision and are not being deleted,
+// ordered from the largest ordinal. ServingGroups below the partition are never returned.
+func (c *ModelServingController) getOutdatedServingGroups(mi *workloadv1alpha1.ModelServing, servingGroupList []datastore.ServingGroup, revision string) []datastore.ServingGroup {
+	// we compute the minimum ordinal of the target sequence for a destructive update based on the strategy.
+	// The ordinals may have gaps, so the parsed ordinal of each group is compared rather than its position in the list.
+	updateMin := getPartition(mi)
+
+	var outdatedGroups []datastore.ServingGroup
+	for i := len(servingGroupList) - 1; i >= 0; i-- {
+		group := servingGroupList[i]
+		if _, ordinal := utils.GetParentNameAndOrdinal(group.Name); ordinal < updateMin {
+			continue
+		}
+		if group.Status == datastore.ServingGroupDeleting {
+			continue
+		}
+		if c.isServingGroupOutdated(group, mi.Namespace, revision) {
+			outdatedGroups = append(outdatedGroups, group)
+		}
+	}
+	return outdatedGroups
+}
+
+// getPartition returns the partition of the rolling update, ServingGroups with a smaller ordinal are not updated.
+func getPartition(mi *workloadv1alpha1.ModelServing) int {
+	if mi.Spec.RolloutStrategy != nil && mi.Spec.RolloutStrategy.RollingUpdateConfiguration != nil && mi.Spec.RolloutStrategy.RollingUpdateConfiguration.Partition != nil {
+		return int(*mi.Spec.RolloutStrategy.RollingUpdateConfiguration.Partition)
+	}
+	return 0
+}
+
 func (c *ModelServingController) handleReadyPod(mi *workloadv1alpha1.ModelServing, servingGroupName string, newPod *corev1.Pod) error {
 	// Add the running pod to the global storage and try to update the ServingGroup status
 	c.store.AddRunningPodToServingGroup(types.NamespacedName{
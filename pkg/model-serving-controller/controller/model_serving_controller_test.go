@@ -24,6 +24,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
@@ -1557,6 +1558,7 @@ func TestScaleUpServingGroups(t *testing.T) {
 		expectedCount      int   // Target count for scale up
 		expectedNewIndices []int // Expected indices for newly created groups
 		expectNoCreation   bool  // Whether no new groups should be created
+		minIndex           int   // Smallest index new groups may be created at
 	}{
 		{
 			name:               "scale up from 0 to 2 groups",
@@ -1573,17 +1575,17 @@ func TestScaleUpServingGroups(t *testing.T) {
 			expectNoCreation:   false,
 		},
 		{
-			name:               "scale up with gap in indices - should use increasing indices from max",
+			name:               "scale up with gap in indices - should reuse the free indices",
 			existingIndices:    []int{0, 5}, // Gap: indices 1-4 missing
 			expectedCount:      4,
-			expectedNewIndices: []int{6, 7}, // Should continue from max index (5) + 1
+			expectedNewIndices: []int{1, 2}, // Should fill the gap from the smallest free index
 			expectNoCreation:   false,
 		},
 		{
 			name:               "scale up with only high index existing",
 			existingIndices:    []int{10},
 			expectedCount:      3,
-			expectedNewIndices: []int{11, 12}, // Should continue from max index (10) + 1
+			expectedNewIndices: []int{0, 1}, // Should start from the smallest free index
 			expectNoCreation:   false,
 		},
 		{
@@ -1607,6 +1609,14 @@ func TestScaleUpServingGroups(t *testing.T) {
 			expectedNewIndices: []int{1, 2, 3, 4},
 			expectNoCreation:   false,
 		},
+		{
+			name:               "scale up with min index - should not reuse the free indices below the min index",
+			existingIndices:    []int{0, 3},
+			expectedCount:      4,
+			expectedNewIndices: []int{2, 4},
+			expectNoCreation:   false,
+			minIndex:           2,
+		},
 	}
 
 	for idx, tt := range tests {
@@ -1666,7 +1676,7 @@ func TestScaleUpServingGroups(t *testing.T) {
 			}
 
 			// Call scaleUpServingGroups directly (not through syncModelServing)
-			err = controller.scaleUpServingGroups(context.Background(), mi, existingGroups, tt.expectedCount, "new-revision")
+			err = controller.scaleUpServingGroups(context.Background(), mi, existingGroups, tt.expectedCount, tt.minIndex, "new-revision")
 			assert.NoError(t, err)
 
 			// Verify the results
@@ -2085,3 +2095,522 @@ func TestScaleDownRoles(t *testing.T) {
 		})
 	}
 }
+
+// TestManageServingGroupRollingUpdate tests how many ServingGroups are surged and terminated in one rolling update round
+func TestManageServingGroupRollingUpdate(t *testing.T) {
+	tests := []struct {
+		name              string
+		replicas          int32
+		config            *workloadv1alpha1.RollingUpdateConfiguration
+		outdatedIndices   []int                                // Running ServingGroups of the old revision
+		updatedGroups     map[int]datastore.ServingGroupStatus // ServingGroups of the new revision and their status
+		expectedDeleting  []int
+		expectedNewGroups []int
+	}{
+		{
+			name:             "default strategy terminates one group with the largest ordinal",
+			replicas:         4,
+			outdatedIndices:  []int{0, 1, 2, 3},
+			expectedDeleting: []int{3},
+		},
+		{
+			name:     "maxUnavailable terminates several groups at once",
+			replicas: 4,
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxUnavailable: intstr.FromInt32(2),
+			},
+			outdatedIndices:  []int{0, 1, 2, 3},
+			expectedDeleting: []int{3, 2},
+		},
+		{
+			name:     "maxUnavailable as percentage",
+			replicas: 4,
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxUnavailable: intstr.FromString("75%"),
+			},
+			outdatedIndices:  []int{0, 1, 2, 3},
+			expectedDeleting: []int{3, 2, 1},
+		},
+		{
+			name:     "maxSurge creates new groups before terminating outdated ones",
+			replicas: 4,
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxSurge:       intstr.FromInt32(2),
+				MaxUnavailable: intstr.FromInt32(0),
+			},
+			outdatedIndices:   []int{0, 1, 2, 3},
+			expectedNewGroups: []int{4, 5},
+		},
+		{
+			name:     "running surge group allows terminating an outdated group",
+			replicas: 4,
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxSurge:       intstr.FromInt32(1),
+				MaxUnavailable: intstr.FromInt32(0),
+			},
+			outdatedIndices:  []int{0, 1, 2, 3},
+			updatedGroups:    map[int]datastore.ServingGroupStatus{4: datastore.ServingGroupRunning},
+			expectedDeleting: []int{3},
+		},
+		{
+			name:     "surge is bounded by the outdated groups left",
+			replicas: 4,
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxSurge:       intstr.FromInt32(3),
+				MaxUnavailable: intstr.FromInt32(1),
+			},
+			outdatedIndices: []int{0},
+			updatedGroups: map[int]datastore.ServingGroupStatus{
+				1: datastore.ServingGroupRunning,
+				2: datastore.ServingGroupRunning,
+				3: datastore.ServingGroupRunning,
+				4: datastore.ServingGroupRunning,
+				5: datastore.ServingGroupRunning,
+				6: datastore.ServingGroupRunning,
+			},
+			expectedDeleting: []int{0},
+		},
+		{
+			name:     "groups not running consume the maxUnavailable budget",
+			replicas: 4,
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxUnavailable: intstr.FromInt32(2),
+			},
+			outdatedIndices:  []int{0, 1, 2},
+			updatedGroups:    map[int]datastore.ServingGroupStatus{3: datastore.ServingGroupCreating},
+			expectedDeleting: []int{2},
+		},
+		{
+			name:            "pending group stops the rolling update",
+			replicas:        4,
+			outdatedIndices: []int{0, 1, 2},
+			updatedGroups:   map[int]datastore.ServingGroupStatus{3: datastore.ServingGroupCreating},
+		},
+		{
+			name:     "partition is respected",
+			replicas: 4,
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxUnavailable: intstr.FromInt32(4),
+				Partition:      ptr.To[int32](2),
+			},
+			outdatedIndices:  []int{0, 1, 2, 3},
+			expectedDeleting: []int{3, 2},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kthenaClient := kthenafake.NewSimpleClientset()
+			volcanoClient := volcanofake.NewSimpleClientset()
+
+			controller, err := NewModelServingController(kubeClient, kthenaClient, volcanoClient)
+			assert.NoError(t, err)
+
+			miName := fmt.Sprintf("test-rolling-update-%d", idx)
+			mi := &workloadv1alpha1.ModelServing{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      miName,
+				},
+				Spec: workloadv1alpha1.ModelServingSpec{
+					Replicas:      ptr.To(tt.replicas),
+					SchedulerName: "volcano",
+					Template: workloadv1alpha1.ServingGroup{
+						Roles: []workloadv1alpha1.Role{
+							{
+								Name:     "prefill",
+								Replicas: ptr.To[int32](1),
+								EntryTemplate: workloadv1alpha1.PodTemplateSpec{
+									Spec: corev1.PodSpec{
+										Containers: []corev1.Container{
+											{
+												Name:  "prefill-container",
+												Image: "test-image:latest",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					RecoveryPolicy: workloadv1alpha1.RoleRecreate,
+				},
+			}
+			if tt.config != nil {
+				mi.Spec.RolloutStrategy = &workloadv1alpha1.RolloutStrategy{
+					Type:                       workloadv1alpha1.ServingGroupRollingUpdate,
+					RollingUpdateConfiguration: tt.config,
+				}
+			}
+			miNamedName := utils.GetNamespaceName(mi)
+
+			// Outdated groups are detected by the revision of their pods
+			indexer := controller.podsInformer.GetIndexer()
+			for _, ordinal := range tt.outdatedIndices {
+				groupName := utils.GenerateServingGroupName(miName, ordinal)
+				controller.store.AddServingGroup(miNamedName, ordinal, "old-revision")
+				assert.NoError(t, controller.store.UpdateServingGroupStatus(miNamedName, groupName, datastore.ServingGroupRunning))
+				assert.NoError(t, indexer.Add(&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      groupName + "-prefill-0",
+						Labels: map[string]string{
+							workloadv1alpha1.GroupNameLabelKey: groupName,
+							workloadv1alpha1.RevisionLabelKey:  "old-revision",
+						},
+					},
+				}))
+			}
+			for ordinal, status := range tt.updatedGroups {
+				groupName := utils.GenerateServingGroupName(miName, ordinal)
+				controller.store.AddServingGroup(miNamedName, ordinal, "new-revision")
+				assert.NoError(t, controller.store.UpdateServingGroupStatus(miNamedName, groupName, status))
+			}
+
+			err = controller.manageServingGroupRollingUpdate(context.Background(), mi, "new-revision")
+			assert.NoError(t, err)
+
+			groups, err := controller.store.GetServingGroupByModelServing(miNamedName)
+			assert.NoError(t, err)
+
+			deleting := []int{}
+			newGroups := []int{}
+			for _, g := range groups {
+				_, ordinal := utils.GetParentNameAndOrdinal(g.Name)
+				if g.Status == datastore.ServingGroupDeleting {
+					deleting = append(deleting, ordinal)
+				}
+				if _, ok := tt.updatedGroups[ordinal]; !ok && g.Revision == "new-revision" {
+					newGroups = append(newGroups, ordinal)
+				}
+			}
+			assert.ElementsMatch(t, tt.expectedDeleting, deleting, "Terminating group indices should match expected")
+			assert.ElementsMatch(t, tt.expectedNewGroups, newGroups, "Surged group indices should match expected")
+		})
+	}
+}
+
+// TestManageServingGroupReplicasKeepsSurge tests that surged ServingGroups are not scaled down during a rolling update
+func TestManageServingGroupReplicasKeepsSurge(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	kthenaClient := kthenafake.NewSimpleClientset()
+	volcanoClient := volcanofake.NewSimpleClientset()
+
+	controller, err := NewModelServingController(kubeClient, kthenaClient, volcanoClient)
+	assert.NoError(t, err)
+
+	mi := &workloadv1alpha1.ModelServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test-keep-surge",
+		},
+		Spec: workloadv1alpha1.ModelServingSpec{
+			Replicas: ptr.To[int32](2),
+			RolloutStrategy: &workloadv1alpha1.RolloutStrategy{
+				Type: workloadv1alpha1.ServingGroupRollingUpdate,
+				RollingUpdateConfiguration: &workloadv1alpha1.RollingUpdateConfiguration{
+					MaxSurge: intstr.FromInt32(1),
+				},
+			},
+		},
+	}
+	miNamedName := utils.GetNamespaceName(mi)
+
+	// Group 0 and 1 are outdated, group 2 is the surge group of the new revision
+	indexer := controller.podsInformer.GetIndexer()
+	for ordinal := range 2 {
+		groupName := utils.GenerateServingGroupName(mi.Name, ordinal)
+		controller.store.AddServingGroup(miNamedName, ordinal, "old-revision")
+		assert.NoError(t, indexer.Add(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      groupName + "-prefill-0",
+				Labels: map[string]string{
+					workloadv1alpha1.GroupNameLabelKey: groupName,
+					workloadv1alpha1.RevisionLabelKey:  "old-revision",
+				},
+			},
+		}))
+	}
+	controller.store.AddServingGroup(miNamedName, 2, "new-revision")
+
+	err = controller.manageServingGroupReplicas(context.Background(), mi, "new-revision")
+	assert.NoError(t, err)
+	groups, err := controller.store.GetServingGroupByModelServing(miNamedName)
+	assert.NoError(t, err)
+	assert.Len(t, groups, 3)
+	for _, g := range groups {
+		assert.NotEqual(t, datastore.ServingGroupDeleting, g.Status)
+	}
+
+	// Once the outdated groups are gone, the surge group is scaled down
+	for _, obj := range indexer.List() {
+		assert.NoError(t, indexer.Delete(obj))
+	}
+	err = controller.manageServingGroupReplicas(context.Background(), mi, "new-revision")
+	assert.NoError(t, err)
+	groups, err = controller.store.GetServingGroupByModelServing(miNamedName)
+	assert.NoError(t, err)
+	assert.Len(t, groups, 2)
+}
+
+// TestServingGroupRollingUpdateOrdinals tests that surge rolling updates reuse the ordinals of the replaced ServingGroups,
+// so the ordinals stay below replicas+maxSurge however many times the ModelServing is updated.
+func TestServingGroupRollingUpdateOrdinals(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	kthenaClient := kthenafake.NewSimpleClientset()
+	volcanoClient := volcanofake.NewSimpleClientset()
+
+	controller, err := NewModelServingController(kubeClient, kthenaClient, volcanoClient)
+	assert.NoError(t, err)
+
+	mi := &workloadv1alpha1.ModelServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test-rolling-update-ordinals",
+		},
+		Spec: workloadv1alpha1.ModelServingSpec{
+			Replicas:      ptr.To[int32](4),
+			SchedulerName: "volcano",
+			Template: workloadv1alpha1.ServingGroup{
+				Roles: []workloadv1alpha1.Role{
+					{
+						Name:     "prefill",
+						Replicas: ptr.To[int32](1),
+						EntryTemplate: workloadv1alpha1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{
+									{
+										Name:  "prefill-container",
+										Image: "test-image:latest",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			RolloutStrategy: &workloadv1alpha1.RolloutStrategy{
+				Type: workloadv1alpha1.ServingGroupRollingUpdate,
+				RollingUpdateConfiguration: &workloadv1alpha1.RollingUpdateConfiguration{
+					MaxSurge:       intstr.FromInt32(1),
+					MaxUnavailable: intstr.FromInt32(0),
+				},
+			},
+			RecoveryPolicy: workloadv1alpha1.RoleRecreate,
+		},
+	}
+	miNamedName := utils.GetNamespaceName(mi)
+
+	// The informer is not running, so the pods of the ServingGroups are maintained in the indexer by hand.
+	indexer := controller.podsInformer.GetIndexer()
+	podOf := func(groupName, revision string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      groupName + "-prefill-0",
+				Labels: map[string]string{
+					workloadv1alpha1.GroupNameLabelKey: groupName,
+					workloadv1alpha1.RevisionLabelKey:  revision,
+				},
+			},
+		}
+	}
+	for ordinal := range 4 {
+		groupName := utils.GenerateServingGroupName(mi.Name, ordinal)
+		controller.store.AddServingGroup(miNamedName, ordinal, "revision-0")
+		assert.NoError(t, controller.store.UpdateServingGroupStatus(miNamedName, groupName, datastore.ServingGroupRunning))
+		assert.NoError(t, indexer.Add(podOf(groupName, "revision-0")))
+	}
+
+	// rollout runs the reconciliation until all ServingGroups are updated, completing the deletion
+	// of terminating groups and making new groups running after every round.
+	rollout := func(revision string) []int {
+		for range 20 {
+			assert.NoError(t, controller.manageServingGroupReplicas(context.Background(), mi, revision))
+			assert.NoError(t, controller.manageServingGroupRollingUpdate(context.Background(), mi, revision))
+
+			groups, err := controller.store.GetServingGroupByModelServing(miNamedName)
+			assert.NoError(t, err)
+			assert.LessOrEqual(t, len(groups), 5, "ServingGroups should never exceed replicas+maxSurge")
+			updated := true
+			for _, g := range groups {
+				switch {
+				case g.Status == datastore.ServingGroupDeleting:
+					assert.NoError(t, indexer.Delete(podOf(g.Name, "")))
+					controller.store.DeleteServingGroup(miNamedName, g.Name)
+					updated = false
+				case g.Revision == revision && g.Status != datastore.ServingGroupRunning:
+					assert.NoError(t, controller.store.UpdateServingGroupStatus(miNamedName, g.Name, datastore.ServingGroupRunning))
+					assert.NoError(t, indexer.Add(podOf(g.Name, revision)))
+					updated = false
+				case g.Revision != revision:
+					updated = false
+				}
+			}
+			if updated && len(groups) == 4 {
+				ordinals := make([]int, 0, len(groups))
+				for _, g := range groups {
+					_, ordinal := utils.GetParentNameAndOrdinal(g.Name)
+					ordinals = append(ordinals, ordinal)
+				}
+				return ordinals
+			}
+		}
+		t.Fatalf("rollout to %s did not complete", revision)
+		return nil
+	}
+
+	// The surge group takes the ordinal after the last group, and each outdated group is replaced at its own ordinal,
+	// except the last one whose replacement is the surge group.
+	assert.Equal(t, []int{1, 2, 3, 4}, rollout("revision-1"))
+	// The freed ordinal is reused by the surge group of the next rollout.
+	assert.Equal(t, []int{0, 2, 3, 4}, rollout("revision-2"))
+	assert.Equal(t, []int{1, 2, 3, 4}, rollout("revision-3"))
+}
+
+// TestManageServingGroupReplicasWithPartition tests that the partition does not affect the ordinals of the ServingGroups
+// created on creation and scale up, which fill the smallest free ordinals starting at 0.
+func TestManageServingGroupReplicasWithPartition(t *testing.T) {
+	tests := []struct {
+		name             string
+		existingIndices  []int
+		replicas         int32
+		expectedOrdinals []int
+	}{
+		{
+			name:             "create with partition",
+			existingIndices:  []int{},
+			replicas:         5,
+			expectedOrdinals: []int{0, 1, 2, 3, 4},
+		},
+		{
+			name:             "scale up with partition",
+			existingIndices:  []int{0, 1},
+			replicas:         4,
+			expectedOrdinals: []int{0, 1, 2, 3},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kthenaClient := kthenafake.NewSimpleClientset()
+			volcanoClient := volcanofake.NewSimpleClientset()
+
+			controller, err := NewModelServingController(kubeClient, kthenaClient, volcanoClient)
+			assert.NoError(t, err)
+
+			mi := &workloadv1alpha1.ModelServing{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      fmt.Sprintf("test-partition-%d", idx),
+				},
+				Spec: workloadv1alpha1.ModelServingSpec{
+					Replicas:      ptr.To[int32](tt.replicas),
+					SchedulerName: "volcano",
+					Template: workloadv1alpha1.ServingGroup{
+						Roles: []workloadv1alpha1.Role{
+							{
+								Name:     "prefill",
+								Replicas: ptr.To[int32](1),
+								EntryTemplate: workloadv1alpha1.PodTemplateSpec{
+									Spec: corev1.PodSpec{
+										Containers: []corev1.Container{
+											{
+												Name:  "prefill-container",
+												Image: "test-image:latest",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					RolloutStrategy: &workloadv1alpha1.RolloutStrategy{
+						Type: workloadv1alpha1.ServingGroupRollingUpdate,
+						RollingUpdateConfiguration: &workloadv1alpha1.RollingUpdateConfiguration{
+							Partition: ptr.To[int32](3),
+						},
+					},
+					RecoveryPolicy: workloadv1alpha1.RoleRecreate,
+				},
+			}
+			miNamedName := utils.GetNamespaceName(mi)
+			for _, ordinal := range tt.existingIndices {
+				controller.store.AddServingGroup(miNamedName, ordinal, "revision-0")
+			}
+
+			err = controller.manageServingGroupReplicas(context.Background(), mi, "revision-0")
+			assert.NoError(t, err)
+
+			groups, err := controller.store.GetServingGroupByModelServing(miNamedName)
+			assert.NoError(t, err)
+			ordinals := make([]int, 0, len(groups))
+			for _, g := range groups {
+				_, ordinal := utils.GetParentNameAndOrdinal(g.Name)
+				ordinals = append(ordinals, ordinal)
+			}
+			assert.ElementsMatch(t, tt.expectedOrdinals, ordinals)
+		})
+	}
+}
+
+// TestGetOutdatedServingGroupsWithGaps tests that the partition is compared with the ordinals of the ServingGroups
+// rather than their positions in the list, which differ when the ordinals have gaps.
+func TestGetOutdatedServingGroupsWithGaps(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	kthenaClient := kthenafake.NewSimpleClientset()
+	volcanoClient := volcanofake.NewSimpleClientset()
+
+	controller, err := NewModelServingController(kubeClient, kthenaClient, volcanoClient)
+	assert.NoError(t, err)
+
+	mi := &workloadv1alpha1.ModelServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test-outdated-gaps",
+		},
+		Spec: workloadv1alpha1.ModelServingSpec{
+			Replicas: ptr.To[int32](3),
+			RolloutStrategy: &workloadv1alpha1.RolloutStrategy{
+				Type: workloadv1alpha1.ServingGroupRollingUpdate,
+				RollingUpdateConfiguration: &workloadv1alpha1.RollingUpdateConfiguration{
+					Partition: ptr.To[int32](2),
+				},
+			},
+		},
+	}
+	miNamedName := utils.GetNamespaceName(mi)
+
+	// Ordinal 1 is free, so group 3 is at position 2 and group 4 at position 3 in the list.
+	indexer := controller.podsInformer.GetIndexer()
+	for _, ordinal := range []int{0, 2, 3, 4} {
+		groupName := utils.GenerateServingGroupName(mi.Name, ordinal)
+		controller.store.AddServingGroup(miNamedName, ordinal, "old-revision")
+		assert.NoError(t, indexer.Add(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      groupName + "-prefill-0",
+				Labels: map[string]string{
+					workloadv1alpha1.GroupNameLabelKey: groupName,
+					workloadv1alpha1.RevisionLabelKey:  "old-revision",
+				},
+			},
+		}))
+	}
+
+	groups, err := controller.store.GetServingGroupByModelServing(miNamedName)
+	assert.NoError(t, err)
+	outdated := controller.getOutdatedServingGroups(mi, groups, "new-revision")
+	names := make([]string, 0, len(outdated))
+	for _, g := range outdated {
+		names = append(names, g.Name)
+	}
+	assert.Equal(t, []string{
+		utils.GenerateServingGroupName(mi.Name, 4),
+		utils.GenerateServingGroupName(mi.Name, 3),
+		utils.GenerateServingGroupName(mi.Name, 2),
+	}, names)
+}
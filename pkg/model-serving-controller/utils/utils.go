@@ -30,6 +30,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
@@ -362,6 +363,31 @@ func ContainerRestarted(pod *corev1.Pod) bool {
 	return false
 }
 
+// GetMaxSurgeAndMaxUnavailable returns the maxSurge and maxUnavailable of a rolling update as absolute numbers of ServingGroups,
+// scaled against spec.replicas. maxSurge is rounded up and maxUnavailable is rounded down.
+// If both are 0, maxUnavailable is set to 1 so that the rolling update can always make progress.
+func GetMaxSurgeAndMaxUnavailable(mi *workloadv1alpha1.ModelServing) (int, int, error) {
+	maxSurge, maxUnavailable := 0, 1
+	if mi.Spec.RolloutStrategy == nil || mi.Spec.RolloutStrategy.RollingUpdateConfiguration == nil {
+		return maxSurge, maxUnavailable, nil
+	}
+
+	replicas := int(ptr.Deref(mi.Spec.Replicas, 1))
+	config := mi.Spec.RolloutStrategy.RollingUpdateConfiguration
+	maxSurge, err := intstr.GetScaledValueFromIntOrPercent(&config.MaxSurge, replicas, true)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid maxSurge: %v", err)
+	}
+	maxUnavailable, err = intstr.GetScaledValueFromIntOrPercent(&config.MaxUnavailable, replicas, false)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid maxUnavailable: %v", err)
+	}
+	if maxSurge == 0 && maxUnavailable == 0 {
+		maxUnavailable = 1
+	}
+	return maxSurge, maxUnavailable, nil
+}
+
 func newCondition(condType workloadv1alpha1.ModelServingConditionType, message string) metav1.Condition {
 	var conditionType, reason string
 	switch condType {
@@ -417,6 +443,11 @@ func SetCondition(mi *workloadv1alpha1.ModelServing, progressingGroups, updatedG
 			if newCond.Status != curCondition.Status {
 				mi.Status.Conditions[i] = newCond
 				shouldUpdate = true
+			} else if newCond.Message != curCondition.Message {
+				// Keep the transition time, but refresh the message so that it reflects the progress of the groups.
+				mi.Status.Conditions[i].Reason = newCond.Reason
+				mi.Status.Conditions[i].Message = newCond.Message
+				shouldUpdate = true
 			}
 			found = true
 		} else {
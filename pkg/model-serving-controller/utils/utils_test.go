@@ -21,6 +21,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 
 	workloadv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/workload/v1alpha1"
 )
@@ -97,3 +99,82 @@ func TestSetCondition(t *testing.T) {
 		assert.Contains(t, cond.Message, SomeGroupsAreProgressing)
 	})
 }
+
+func TestGetMaxSurgeAndMaxUnavailable(t *testing.T) {
+	tests := []struct {
+		name               string
+		config             *workloadv1alpha1.RollingUpdateConfiguration
+		replicas           int32
+		wantMaxSurge       int
+		wantMaxUnavailable int
+		wantErr            bool
+	}{
+		{
+			name:               "no rolling update configuration",
+			replicas:           4,
+			wantMaxSurge:       0,
+			wantMaxUnavailable: 1,
+		},
+		{
+			name: "absolute values",
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxSurge:       intstr.FromInt32(2),
+				MaxUnavailable: intstr.FromInt32(3),
+			},
+			replicas:           10,
+			wantMaxSurge:       2,
+			wantMaxUnavailable: 3,
+		},
+		{
+			name: "percentages are rounded up for maxSurge and down for maxUnavailable",
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxSurge:       intstr.FromString("25%"),
+				MaxUnavailable: intstr.FromString("25%"),
+			},
+			replicas:           10,
+			wantMaxSurge:       3,
+			wantMaxUnavailable: 2,
+		},
+		{
+			name: "both zero falls back to one unavailable",
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxUnavailable: intstr.FromString("10%"),
+			},
+			replicas:           5,
+			wantMaxSurge:       0,
+			wantMaxUnavailable: 1,
+		},
+		{
+			name: "invalid value",
+			config: &workloadv1alpha1.RollingUpdateConfiguration{
+				MaxSurge: intstr.FromString("abc"),
+			},
+			replicas: 5,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mi := &workloadv1alpha1.ModelServing{
+				Spec: workloadv1alpha1.ModelServingSpec{
+					Replicas: ptr.To(tt.replicas),
+				},
+			}
+			if tt.config != nil {
+				mi.Spec.RolloutStrategy = &workloadv1alpha1.RolloutStrategy{
+					Type:                       workloadv1alpha1.ServingGroupRollingUpdate,
+					RollingUpdateConfiguration: tt.config,
+				}
+			}
+			maxSurge, maxUnavailable, err := GetMaxSurgeAndMaxUnavailable(mi)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMaxSurge, maxSurge)
+			assert.Equal(t, tt.wantMaxUnavailable, maxUnavailable)
+		})
+	}
+}